@@ -1,3 +1,5 @@
+// Command basic is the smallest complete service: one HTTP endpoint whose
+// requests are traced, with logs attached to the active span.
 package main
 
 import (
@@ -19,16 +21,20 @@ func main() {
 		observability.WithApmURL("http://localhost:4318"),
 	)
 
-	// 2. Initialize all observability components and defer the shutdown.
-	// SetupOrExit will log a fatal error and exit if initialization fails.
-	shutdowner := obsFactory.SetupOrExit("Failed to setup observability")
-	defer shutdowner.ShutdownOrLog("Error during observability shutdown")
+	// 2. Set up all observability components, serve until SIGINT or
+	// SIGTERM, then drain in-flight requests and flush telemetry on exit.
+	server := &http.Server{Addr: ":8080", Handler: newMux(obsFactory)}
+	if err := obsFactory.RunServer(context.Background(), server); err != nil {
+		observability.LogFatal("Service failed", "error", err)
+	}
+}
 
-	// 3. Get a background logger for startup and shutdown events.
-	bgObs := obsFactory.NewBackgroundObservability(context.Background())
+// newMux returns the service's routes.
+func newMux(obsFactory *observability.Factory) *http.ServeMux {
+	mux := http.NewServeMux()
 
-	// 4. Instrument your HTTP handlers.
-	http.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+	// 3. Instrument your HTTP handlers.
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
 		// This one line handles context propagation and creates the root span.
 		r, ctx, span, _ := obsFactory.StartSpanFromRequest(r)
 		defer span.End()
@@ -37,24 +43,21 @@ func main() {
 		handleHello(ctx, w, r)
 	})
 
-	bgObs.Log.Info("Server starting on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		bgObs.ErrorHandler.Fatal("Server failed to start", "error", err)
-	}
+	return mux
 }
 
 func handleHello(ctx context.Context, w http.ResponseWriter, r *http.Request) {
-	// 5. Create a new span. This returns a new context, a new observability
+	// 4. Create a new span. This returns a new context, a new observability
 	// object, and the span. The new 'obs' is tied to the new span's context.
 	ctx, obs, span := observability.StartSpanFromCtx(ctx, "say-hello",
 		observability.SpanAttributes{"name": "world"},
 	)
 	defer span.End()
 
-	// 6. This log is now automatically attached to the new child span ("say-hello").
+	// 5. This log is now automatically attached to the new child span ("say-hello").
 	obs.Log.Info("Handling hello request", "user-agent", r.UserAgent())
 
 	w.Write([]byte("Hello, world!"))
 
 	obs.Log.Info("Wrote response to client")
-}
\ No newline at end of file
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/app-obs/go/observability"
+)
+
+func TestHello(t *testing.T) {
+	f := observability.NewFactory(observability.WithServiceName("basic-smoke"))
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdowner.Shutdown(context.Background())
+
+	srv := httptest.NewServer(newMux(f))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/hello")
+	if err != nil {
+		t.Fatalf("GET /hello: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "Hello, world!" {
+		t.Fatalf("GET /hello = %d %q, want 200 %q", resp.StatusCode, body, "Hello, world!")
+	}
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"context"
+
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel"
+
+	"github.com/app-obs/go/observability"
+)
+
+// kafkaBroker is the address of the Kafka broker started by the example's
+// docker-compose.yml.
+const kafkaBroker = "localhost:9092"
+
+// ordersTopic is the Kafka topic accepted orders are published to.
+const ordersTopic = "orders"
+
+// workflowHeader is the message header that carries an order's workflow ID.
+const workflowHeader = "workflow-id"
+
+// newOrderMessage returns the message announcing j, with the trace context
+// of ctx and the order's workflow ID in its headers.
+func newOrderMessage(ctx context.Context, j job) kafka.Message {
+	msg := kafka.Message{
+		Topic:   ordersTopic,
+		Key:     []byte(j.orderID),
+		Headers: []kafka.Header{{Key: workflowHeader, Value: []byte(j.workflowID)}},
+	}
+	otel.GetTextMapPropagator().Inject(ctx, (*headerCarrier)(&msg.Headers))
+	return msg
+}
+
+// consumeOrder submits the order announced by msg to w, in a span that
+// continues the trace of the request that published it.
+func consumeOrder(ctx context.Context, f *observability.Factory, w *worker, msg kafka.Message) (err error) {
+	ctx = otel.GetTextMapPropagator().Extract(ctx, (*headerCarrier)(&msg.Headers))
+	j := job{orderID: string(msg.Key)}
+	for _, h := range msg.Headers {
+		if h.Key == workflowHeader {
+			j.workflowID = string(h.Value)
+		}
+	}
+	ctx, obs, span := f.NewBackgroundObservability(ctx).StartSpan(msg.Topic+" process", observability.SpanAttributes{
+		"messaging.system":           "kafka",
+		"messaging.destination.name": msg.Topic,
+		"order.id":                   j.orderID,
+	})
+	defer span.EndWith(&err)
+
+	if err := w.submit(ctx, j); err != nil {
+		return err
+	}
+	obs.Log.Info("Order queued for fulfilment", "order.id", j.orderID)
+	return nil
+}
+
+// producer publishes orders to Kafka. It implements
+// observability.Shutdowner.
+type producer struct {
+	writer *kafka.Writer
+}
+
+// publish publishes j, waiting until the broker has acknowledged it.
+func (p *producer) publish(ctx context.Context, j job) error {
+	return p.writer.WriteMessages(ctx, newOrderMessage(ctx, j))
+}
+
+// Shutdown flushes pending messages and closes the connections.
+func (p *producer) Shutdown(context.Context) error {
+	return p.writer.Close()
+}
+
+// ShutdownOrLog implements observability.Shutdowner.
+func (p *producer) ShutdownOrLog(msg string) {
+	if err := p.Shutdown(context.Background()); err != nil {
+		observability.LogShutdownError(msg, err)
+	}
+}
+
+// consumer reads the orders topic and submits each order to the worker,
+// committing a message once its order is queued. It implements
+// observability.Shutdowner.
+type consumer struct {
+	reader *kafka.Reader
+	done   chan struct{}
+}
+
+func newConsumer(f *observability.Factory, w *worker, reader *kafka.Reader) *consumer {
+	c := &consumer{reader: reader, done: make(chan struct{})}
+	go c.run(f, w)
+	return c
+}
+
+func (c *consumer) run(f *observability.Factory, w *worker) {
+	defer close(c.done)
+	ctx := context.Background()
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			// The reader was closed.
+			return
+		}
+		if err := consumeOrder(ctx, f, w, msg); err != nil {
+			// The error is recorded on the message's span, and the message
+			// left uncommitted.
+			continue
+		}
+		if err := c.reader.CommitMessages(ctx, msg); err != nil {
+			f.NewBackgroundObservability(ctx).ErrorHandler.Record(err, "Failed to commit order message")
+		}
+	}
+}
+
+// Shutdown stops reading and waits for the order being consumed, if any.
+func (c *consumer) Shutdown(ctx context.Context) error {
+	err := c.reader.Close()
+	select {
+	case <-c.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownOrLog implements observability.Shutdowner.
+func (c *consumer) ShutdownOrLog(msg string) {
+	if err := c.Shutdown(context.Background()); err != nil {
+		observability.LogShutdownError(msg, err)
+	}
+}
+
+// headerCarrier lets the OpenTelemetry propagators read and write Kafka
+// message headers.
+type headerCarrier []kafka.Header
+
+func (c *headerCarrier) Get(key string) string {
+	for _, h := range *c {
+		if h.Key == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+func (c *headerCarrier) Set(key, value string) {
+	for i, h := range *c {
+		if h.Key == key {
+			(*c)[i].Value = []byte(value)
+			return
+		}
+	}
+	*c = append(*c, kafka.Header{Key: key, Value: []byte(value)})
+}
+
+func (c *headerCarrier) Keys() []string {
+	keys := make([]string, len(*c))
+	for i, h := range *c {
+		keys[i] = h.Key
+	}
+	return keys
+}
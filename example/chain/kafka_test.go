@@ -0,0 +1,56 @@
+//go:build otlp || !(datadog || none)
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/app-obs/go/observability"
+)
+
+func TestOrderMessageContinuesTrace(t *testing.T) {
+	// Spans are written to a file, so the test can check the trace.
+	spanFile := filepath.Join(t.TempDir(), "spans.jsonl")
+	f := observability.NewFactory(
+		observability.WithServiceName("chain-kafka-smoke"),
+		observability.WithApmType("file"),
+		observability.WithApmURL(spanFile),
+	)
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	worker := newWorker(f, newOrderStore(), 1)
+
+	ctx, _, span := f.NewBackgroundObservability(context.Background()).StartSpan("create-order", nil)
+	ctx, workflowID := observability.StartWorkflow(ctx)
+	msg := newOrderMessage(ctx, job{orderID: "o-1", workflowID: workflowID})
+	span.End()
+	if err := consumeOrder(context.Background(), f, worker, msg); err != nil {
+		t.Fatalf("consumeOrder: %v", err)
+	}
+
+	if err := worker.Shutdown(context.Background()); err != nil {
+		t.Fatalf("worker Shutdown: %v", err)
+	}
+	if err := shutdowner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	spans, err := observability.ReadSpanFile(spanFile)
+	if err != nil {
+		t.Fatalf("ReadSpanFile: %v", err)
+	}
+	byName := make(map[string]observability.SpanRecord)
+	for _, s := range spans {
+		byName[s.Name] = s
+	}
+	producer, consumer, fulfil := byName["create-order"], byName["orders process"], byName["fulfil-order"]
+	if consumer.TraceID != producer.TraceID || consumer.ParentSpanID != producer.SpanID {
+		t.Errorf("consumer span %+v, want a child of %+v", consumer, producer)
+	}
+	if fulfil.Attributes[observability.WorkflowIDKey] != workflowID {
+		t.Errorf("fulfil-order %s = %v, want %s", observability.WorkflowIDKey, fulfil.Attributes[observability.WorkflowIDKey], workflowID)
+	}
+}
@@ -0,0 +1,259 @@
+// Command chain runs a request flow across four components in one process,
+// to show how telemetry follows a request through services, a Kafka topic,
+// and asynchronous work:
+//
+//	client → gateway (:8080) → orders (:8081) → Kafka "orders" topic
+//	       → consumer → fulfilment worker
+//
+// The gateway calls the orders service over HTTP, propagating the trace
+// context in the request headers, so both services' spans form one trace.
+// The orders service starts a workflow for the order and publishes it to
+// Kafka with the trace context and workflow ID in the message headers, so
+// the consumer's span joins the same trace. The worker fulfils the order
+// after the request has ended, outside its trace, and adopts the workflow ID
+// so its logs and spans can still be joined with the request that queued it.
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+
+	"github.com/app-obs/go/observability"
+)
+
+func main() {
+	f := observability.NewFactory(
+		observability.WithServiceName("chain"),
+		observability.WithApmType("otlp"),
+		observability.WithMetricsType("otlp"),
+		observability.WithApmURL("http://localhost:4318"),
+	)
+	orders := newOrderStore()
+	worker := newWorker(f, orders, 100)
+	consumer := newConsumer(f, worker, kafka.NewReader(kafka.ReaderConfig{
+		Brokers: []string{kafkaBroker},
+		GroupID: "fulfilment",
+		Topic:   ordersTopic,
+	}))
+	producer := &producer{writer: &kafka.Writer{
+		Addr:                   kafka.TCP(kafkaBroker),
+		Balancer:               &kafka.Hash{},
+		AllowAutoTopicCreation: true,
+	}}
+	// Components are shut down in reverse order, after the servers have
+	// stopped and before telemetry stops: the producer, then the consumer,
+	// then the worker, which drains its queue.
+	f.RegisterShutdowner("fulfilment-worker", worker)
+	f.RegisterShutdowner("orders-consumer", consumer)
+	f.RegisterShutdowner("orders-producer", producer)
+
+	err := f.Run(context.Background(), func(ctx context.Context) error {
+		servers := []*http.Server{
+			{Addr: ":8080", Handler: f.Middleware(newGateway(http.DefaultClient, "http://localhost:8081"))},
+			{Addr: ":8081", Handler: f.Middleware(newOrdersService(orders, producer.publish))},
+		}
+		errc := make(chan error, len(servers))
+		for _, srv := range servers {
+			go func() { errc <- srv.ListenAndServe() }()
+		}
+		f.NewBackgroundObservability(ctx).Log.Info("Gateway listening on :8080, orders service on :8081")
+
+		var err error
+		select {
+		case err = <-errc:
+		case <-ctx.Done():
+		}
+		// The gateway stops first, so no checkout reaches a stopped orders
+		// service.
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		for _, srv := range servers {
+			srv.Shutdown(shutdownCtx)
+		}
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		observability.LogFatal("Service failed", "error", err)
+	}
+}
+
+// newGateway returns the public API. A checkout is forwarded to the orders
+// service at ordersURL.
+func newGateway(client *http.Client, ordersURL string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /checkout", func(w http.ResponseWriter, r *http.Request) {
+		ctx, obs, span := observability.StartSpanFromCtx(r.Context(), "create-order", nil)
+		defer span.End()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, ordersURL+"/orders", nil)
+		if err != nil {
+			obs.ErrorHandler.HTTP(w, "Failed to build orders request", http.StatusInternalServerError)
+			return
+		}
+		// The orders service continues this trace from the injected headers.
+		obs.Trace.InjectHTTP(req)
+		resp, err := client.Do(req)
+		if err != nil {
+			obs.ErrorHandler.Record(err, "Orders service unavailable")
+			http.Error(w, "orders service unavailable", http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+	})
+	return mux
+}
+
+// newOrdersService returns the orders API, which records orders and
+// publishes them, with publish, for fulfilment.
+func newOrdersService(orders *orderStore, publish func(context.Context, job) error) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		// The workflow ID outlives the request's trace and follows the order
+		// into the worker.
+		ctx, workflowID := observability.StartWorkflow(r.Context())
+		obs := observability.ObsFromCtx(ctx)
+
+		id := newOrderID()
+		orders.set(id, "pending")
+		if err := publish(ctx, job{orderID: id, workflowID: workflowID}); err != nil {
+			orders.set(id, "rejected")
+			obs.ErrorHandler.Record(err, "Failed to publish order")
+			http.Error(w, "order queue unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		obs.Log.Info("Order accepted", "order.id", id)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(map[string]string{"order_id": id, "workflow_id": workflowID})
+	})
+	mux.HandleFunc("GET /orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		status, ok := orders.get(r.PathValue("id"))
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"status": status})
+	})
+	return mux
+}
+
+// job is a queued fulfilment request.
+type job struct {
+	orderID    string
+	workflowID string
+}
+
+// worker fulfils queued orders in the background. It implements
+// observability.Shutdowner so the factory can drain it on shutdown.
+type worker struct {
+	f      *observability.Factory
+	orders *orderStore
+	jobs   chan job
+	done   chan struct{}
+}
+
+func newWorker(f *observability.Factory, orders *orderStore, queueSize int) *worker {
+	w := &worker{
+		f:      f,
+		orders: orders,
+		jobs:   make(chan job, queueSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// submit queues j, waiting for room in the queue until ctx is done.
+func (w *worker) submit(ctx context.Context, j job) error {
+	select {
+	case w.jobs <- j:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (w *worker) run() {
+	defer close(w.done)
+	for j := range w.jobs {
+		w.fulfil(j)
+	}
+}
+
+func (w *worker) fulfil(j job) {
+	ctx := observability.AdoptWorkflow(context.Background(), j.workflowID)
+	_, obs, span := w.f.NewBackgroundObservability(ctx).StartSpan("fulfil-order",
+		observability.SpanAttributes{"order.id": j.orderID},
+	)
+	defer span.End()
+
+	time.Sleep(50 * time.Millisecond) // Stands in for real work.
+	w.orders.set(j.orderID, "fulfilled")
+	obs.Log.Info("Order fulfilled", "order.id", j.orderID)
+}
+
+// Shutdown stops accepting jobs and waits for the queued ones to finish.
+// No job may be submitted once it is called.
+func (w *worker) Shutdown(ctx context.Context) error {
+	close(w.jobs)
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ShutdownOrLog implements observability.Shutdowner.
+func (w *worker) ShutdownOrLog(msg string) {
+	if err := w.Shutdown(context.Background()); err != nil {
+		observability.LogShutdownError(msg, err)
+	}
+}
+
+// orderStore holds the status of every order.
+type orderStore struct {
+	mu     sync.Mutex
+	status map[string]string
+}
+
+func newOrderStore() *orderStore {
+	return &orderStore{status: make(map[string]string)}
+}
+
+func (s *orderStore) set(id, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status[id] = status
+}
+
+func (s *orderStore) get(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status, ok := s.status[id]
+	return status, ok
+}
+
+func newOrderID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/app-obs/go/observability"
+)
+
+func TestCheckoutIsFulfilled(t *testing.T) {
+	f := observability.NewFactory(observability.WithServiceName("chain-smoke"))
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdowner.Shutdown(context.Background())
+
+	orders := newOrderStore()
+	worker := newWorker(f, orders, 10)
+	// Orders go through a Kafka message, but are consumed directly instead
+	// of through a broker.
+	publish := func(ctx context.Context, j job) error {
+		return consumeOrder(ctx, f, worker, newOrderMessage(ctx, j))
+	}
+	ordersSrv := httptest.NewServer(f.Middleware(newOrdersService(orders, publish)))
+	defer ordersSrv.Close()
+	gatewaySrv := httptest.NewServer(f.Middleware(newGateway(ordersSrv.Client(), ordersSrv.URL)))
+	defer gatewaySrv.Close()
+
+	resp, err := http.Post(gatewaySrv.URL+"/checkout", "", nil)
+	if err != nil {
+		t.Fatalf("POST /checkout: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("POST /checkout = %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	var accepted struct {
+		OrderID    string `json:"order_id"`
+		WorkflowID string `json:"workflow_id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&accepted); err != nil {
+		t.Fatalf("decoding checkout response: %v", err)
+	}
+	if accepted.OrderID == "" || accepted.WorkflowID == "" {
+		t.Fatalf("checkout response = %+v, want order and workflow IDs", accepted)
+	}
+
+	// Draining the worker finishes the queued job.
+	if err := worker.Shutdown(context.Background()); err != nil {
+		t.Fatalf("worker Shutdown: %v", err)
+	}
+	if status, _ := orders.get(accepted.OrderID); status != "fulfilled" {
+		t.Fatalf("order status = %q, want %q", status, "fulfilled")
+	}
+}
@@ -0,0 +1,58 @@
+//go:build datadog || !(otlp || none)
+
+// Command datadog runs a service that sends its traces and metrics to a
+// local Datadog Agent. Logs carry the Datadog trace and span IDs, so the Agent's log
+// collection can link them to the trace.
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/app-obs/go/observability"
+)
+
+func main() {
+	f := observability.NewFactory(
+		observability.WithServiceName("checkout"),
+		observability.WithServiceEnv("development"),
+		observability.WithApmType("datadog"),
+		// Metrics go to the Agent's DogStatsD listener, localhost:8125.
+		observability.WithMetricsType("dogstatsd"),
+		// The address of the Agent's trace intake.
+		observability.WithApmURL("localhost:8126"),
+	)
+	server := &http.Server{Addr: ":8080", Handler: f.Middleware(newMux())}
+	if err := f.RunServer(context.Background(), server); err != nil {
+		observability.LogFatal("Service failed", "error", err)
+	}
+}
+
+// newMux returns the service's routes. A checkout of a cart with more than
+// ten items fails, to show how errors are reported on the trace.
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /checkout", func(w http.ResponseWriter, r *http.Request) {
+		items, err := strconv.Atoi(r.URL.Query().Get("items"))
+		if err != nil || items < 1 {
+			http.Error(w, "items must be a positive number", http.StatusBadRequest)
+			return
+		}
+
+		_, obs, span := observability.StartSpanFromCtx(r.Context(), "charge-card",
+			observability.SpanAttributes{"cart.items": items},
+		)
+		defer span.End()
+
+		if items > 10 {
+			// Logged errors mark the span as failed.
+			obs.ErrorHandler.HTTP(w, "Payment declined", http.StatusPaymentRequired)
+			return
+		}
+		obs.Log.Info("Card charged", "cart.items", items)
+		obs.Metrics.Inc("checkout.completed")
+		w.Write([]byte("OK"))
+	})
+	return mux
+}
@@ -0,0 +1,47 @@
+//go:build datadog || !(otlp || none)
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/app-obs/go/observability"
+)
+
+func TestCheckout(t *testing.T) {
+	// No Agent is needed: spans are dropped when none is listening.
+	f := observability.NewFactory(
+		observability.WithServiceName("checkout-smoke"),
+		observability.WithApmType("datadog"),
+		observability.WithApmURL("127.0.0.1:1"),
+	)
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdowner.Shutdown(context.Background())
+
+	srv := httptest.NewServer(f.Middleware(newMux()))
+	defer srv.Close()
+
+	for _, tt := range []struct {
+		query string
+		want  int
+	}{
+		{"items=2", http.StatusOK},
+		{"items=11", http.StatusPaymentRequired},
+		{"items=none", http.StatusBadRequest},
+	} {
+		resp, err := http.Post(srv.URL+"/checkout?"+tt.query, "", nil)
+		if err != nil {
+			t.Fatalf("POST /checkout?%s: %v", tt.query, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tt.want {
+			t.Errorf("POST /checkout?%s = %d, want %d", tt.query, resp.StatusCode, tt.want)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Command grpc shows a trace crossing a gRPC call:
+//
+//	client → gateway (:8080) → inventory (:50051, gRPC)
+//
+// The gateway's /ready endpoint asks the inventory service, over the
+// standard gRPC health checking protocol, whether it is serving. A client
+// interceptor injects the trace context into the call's metadata and a
+// server interceptor extracts it, so the spans of both services form one
+// trace. The interceptors use the OpenTelemetry propagator that Setup
+// installs globally (see WithGlobalProviders).
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/app-obs/go/observability"
+)
+
+// inventoryService is the name the inventory service reports its health
+// under.
+const inventoryService = "inventory"
+
+func main() {
+	f := observability.NewFactory(
+		observability.WithServiceName("grpc"),
+		observability.WithApmType("otlp"),
+		observability.WithMetricsType("otlp"),
+		observability.WithApmURL("http://localhost:4318"),
+	)
+
+	err := f.Run(context.Background(), func(ctx context.Context) error {
+		lis, err := net.Listen("tcp", ":50051")
+		if err != nil {
+			return err
+		}
+		inventory := newInventoryServer(f)
+		go inventory.Serve(lis)
+		// Deferred calls run after the gateway has stopped below, so no
+		// request reaches a stopped inventory service.
+		defer inventory.GracefulStop()
+
+		conn, err := grpc.NewClient("localhost:50051",
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithUnaryInterceptor(clientInterceptor),
+		)
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		gateway := &http.Server{Addr: ":8080", Handler: f.Middleware(newGateway(healthpb.NewHealthClient(conn)))}
+		errc := make(chan error, 1)
+		go func() { errc <- gateway.ListenAndServe() }()
+		f.NewBackgroundObservability(ctx).Log.Info("Gateway listening on :8080, inventory service on :50051")
+
+		select {
+		case err = <-errc:
+		case <-ctx.Done():
+		}
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 10*time.Second)
+		defer cancel()
+		gateway.Shutdown(shutdownCtx)
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		observability.LogFatal("Service failed", "error", err)
+	}
+}
+
+// newGateway returns the public API. /ready reports whether the inventory
+// service, reached through inventory, is serving.
+func newGateway(inventory healthpb.HealthClient) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ready", func(w http.ResponseWriter, r *http.Request) {
+		obs := observability.ObsFromCtx(r.Context())
+		resp, err := inventory.Check(r.Context(), &healthpb.HealthCheckRequest{Service: inventoryService})
+		if err != nil {
+			obs.ErrorHandler.Record(err, "Inventory service unavailable")
+			http.Error(w, "inventory service unavailable", http.StatusBadGateway)
+			return
+		}
+		if resp.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			obs.Log.Warn("Inventory service not serving", "status", resp.GetStatus().String())
+			http.Error(w, "inventory service not serving", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ready"))
+	})
+	return mux
+}
+
+// newInventoryServer returns the inventory service, which only answers
+// health checks, with its calls traced by serverInterceptor.
+func newInventoryServer(f *observability.Factory) *grpc.Server {
+	srv := grpc.NewServer(grpc.UnaryInterceptor(serverInterceptor(f)))
+	checks := health.NewServer()
+	checks.SetServingStatus(inventoryService, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(srv, checks)
+	return srv
+}
+
+// clientInterceptor runs each outgoing call in a span of the caller's trace
+// and sends the trace context along in the call's metadata.
+func clientInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) (err error) {
+	ctx, _, span := observability.StartSpanFromCtx(ctx, strings.TrimPrefix(method, "/"), rpcAttributes(method))
+	defer span.EndWith(&err)
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	otel.GetTextMapPropagator().Inject(ctx, metadataCarrier(md))
+	return invoker(metadata.NewOutgoingContext(ctx, md), method, req, reply, cc, opts...)
+}
+
+// serverInterceptor continues the caller's trace, sent in the call's
+// metadata, with a span for each incoming call.
+func serverInterceptor(f *observability.Factory) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		ctx = otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+		ctx, obs, span := f.NewBackgroundObservability(ctx).StartSpan(strings.TrimPrefix(info.FullMethod, "/"), rpcAttributes(info.FullMethod))
+		defer span.EndWith(&err)
+
+		obs.Log.Info("Handling call", "rpc.method", info.FullMethod)
+		return handler(ctx, req)
+	}
+}
+
+// rpcAttributes describes the call to fullMethod, of the form
+// /package.Service/Method, as the RPC semantic conventions do.
+func rpcAttributes(fullMethod string) observability.SpanAttributes {
+	service, method, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+	return observability.SpanAttributes{"rpc.system": "grpc", "rpc.service": service, "rpc.method": method}
+}
+
+// metadataCarrier lets the OpenTelemetry propagators read and write gRPC
+// metadata.
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	if v := metadata.MD(c).Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -0,0 +1,101 @@
+//go:build otlp || !(datadog || none)
+
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/app-obs/go/observability"
+)
+
+func TestReadyContinuesTraceOverGRPC(t *testing.T) {
+	// Spans are written to a file, so the test can check the trace.
+	spanFile := filepath.Join(t.TempDir(), "spans.jsonl")
+	f := observability.NewFactory(
+		observability.WithServiceName("grpc-smoke"),
+		observability.WithApmType("file"),
+		observability.WithApmURL(spanFile),
+	)
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inventory := newInventoryServer(f)
+	go inventory.Serve(lis)
+	defer inventory.Stop()
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(clientInterceptor),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	gatewaySrv := httptest.NewServer(f.Middleware(newGateway(healthpb.NewHealthClient(conn))))
+	defer gatewaySrv.Close()
+
+	resp, err := http.Get(gatewaySrv.URL + "/ready")
+	if err != nil {
+		t.Fatalf("GET /ready: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "ready" {
+		t.Fatalf("GET /ready = %d %q, want 200 %q", resp.StatusCode, body, "ready")
+	}
+
+	if err := shutdowner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	spans, err := observability.ReadSpanFile(spanFile)
+	if err != nil {
+		t.Fatalf("ReadSpanFile: %v", err)
+	}
+	var root observability.SpanRecord
+	for _, s := range spans {
+		if s.Kind == "server" && s.ParentSpanID == "" {
+			root = s
+		}
+	}
+	if root.SpanID == "" {
+		t.Fatalf("no server span for GET /ready in %+v", spans)
+	}
+	// The gateway's span for the call is a child of the request's span, and
+	// the inventory service's span a child of the gateway's, across the call.
+	parent := root
+	for _, side := range []string{"client", "server"} {
+		span, ok := childOf(spans, parent)
+		if !ok {
+			t.Fatalf("no %s span for the call under %s in %+v", side, parent.Name, spans)
+		}
+		if span.Name != "grpc.health.v1.Health/Check" || span.TraceID != root.TraceID {
+			t.Errorf("%s span = %s in trace %s, want grpc.health.v1.Health/Check in trace %s", side, span.Name, span.TraceID, root.TraceID)
+		}
+		parent = span
+	}
+}
+
+// childOf returns the span among spans whose parent is parent.
+func childOf(spans []observability.SpanRecord, parent observability.SpanRecord) (observability.SpanRecord, bool) {
+	for _, s := range spans {
+		if s.ParentSpanID == parent.SpanID {
+			return s, true
+		}
+	}
+	return observability.SpanRecord{}, false
+}
@@ -0,0 +1,80 @@
+// Command lambda is an AWS Lambda function instrumented with LambdaHandler:
+// each invocation runs in a server span that continues the X-Ray trace the
+// runtime passes in, records whether it was a cold start, and flushes the
+// telemetry before the runtime freezes the function.
+//
+// A deployed function passes the wrapped handler to lambda.Start from
+// github.com/aws/aws-lambda-go/lambda:
+//
+//	lambda.Start(observability.LambdaHandler(f, handleOrder))
+//
+// This module does not depend on aws-lambda-go, so main instead invokes the
+// handler once with the event read from standard input, as a local test
+// harness would.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/app-obs/go/observability"
+)
+
+func main() {
+	f := observability.NewFactory(
+		observability.WithServiceName("order-function"),
+		observability.WithApmType("otlp"),
+		observability.WithMetricsType("otlp"),
+		observability.WithApmURL("http://localhost:4318"),
+		// Logs are written synchronously, since the runtime may freeze the
+		// function as soon as the handler returns.
+		observability.WithServerlessMode(true),
+		observability.WithXRayCompatibility(true),
+	)
+	shutdowner := f.SetupOrExit("Failed to set up observability")
+	defer shutdowner.ShutdownOrLog("Failed to shut down observability")
+
+	var event orderEvent
+	if err := json.NewDecoder(os.Stdin).Decode(&event); err != nil {
+		observability.LogFatal("Failed to read event", "error", err)
+	}
+	result, err := observability.LambdaHandler(f, handleOrder)(context.Background(), event)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "invocation failed:", err)
+		return
+	}
+	json.NewEncoder(os.Stdout).Encode(result)
+}
+
+// orderEvent is the event the function is invoked with.
+type orderEvent struct {
+	OrderID  string `json:"order_id"`
+	Quantity int    `json:"quantity"`
+}
+
+// orderResult is the function's response.
+type orderResult struct {
+	OrderID string `json:"order_id"`
+	Status  string `json:"status"`
+}
+
+// handleOrder reserves stock for an order. It is an ordinary Lambda handler:
+// LambdaHandler gives it the invocation's Observability through its context.
+func handleOrder(ctx context.Context, event orderEvent) (orderResult, error) {
+	obs := observability.ObsFromCtx(ctx)
+	if event.Quantity <= 0 {
+		return orderResult{}, errors.New("quantity must be positive")
+	}
+
+	_, obs, span := obs.StartSpan("reserve-stock", observability.SpanAttributes{
+		"order.id":       event.OrderID,
+		"order.quantity": event.Quantity,
+	})
+	defer span.End()
+	obs.Log.Info("Stock reserved", "order.id", event.OrderID)
+
+	return orderResult{OrderID: event.OrderID, Status: "reserved"}, nil
+}
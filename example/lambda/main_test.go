@@ -0,0 +1,70 @@
+//go:build otlp || !(datadog || none)
+
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/app-obs/go/observability"
+)
+
+func TestHandleOrderInvocations(t *testing.T) {
+	// Spans are written to a file, so the test can check the invocations.
+	spanFile := filepath.Join(t.TempDir(), "spans.jsonl")
+	f := observability.NewFactory(
+		observability.WithServiceName("lambda-smoke"),
+		observability.WithApmType("file"),
+		observability.WithApmURL(spanFile),
+		observability.WithServerlessMode(true),
+		observability.WithXRayCompatibility(true),
+	)
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	handler := observability.LambdaHandler(f, handleOrder)
+
+	// The Go runtime passes the X-Ray trace header in the context.
+	ctx := context.WithValue(context.Background(), "x-amzn-trace-id",
+		"Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	result, err := handler(ctx, orderEvent{OrderID: "o-1", Quantity: 2})
+	if err != nil || result != (orderResult{OrderID: "o-1", Status: "reserved"}) {
+		t.Fatalf("handler = %+v, %v, want o-1 reserved", result, err)
+	}
+	if _, err := handler(context.Background(), orderEvent{OrderID: "o-2"}); err == nil {
+		t.Fatal("handler accepted an order without quantity")
+	}
+
+	if err := shutdowner.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	spans, err := observability.ReadSpanFile(spanFile)
+	if err != nil {
+		t.Fatalf("ReadSpanFile: %v", err)
+	}
+	var invocations []observability.SpanRecord
+	for _, s := range spans {
+		if s.Kind == "server" {
+			invocations = append(invocations, s)
+		}
+	}
+	if len(invocations) != 2 {
+		t.Fatalf("got %d invocation spans, want 2: %+v", len(invocations), spans)
+	}
+	first, second := invocations[0], invocations[1]
+	if first.StartTime.After(second.StartTime) {
+		first, second = second, first
+	}
+	if first.TraceID != "5759e988bd862e3fe1be46a994272793" {
+		t.Errorf("first invocation trace ID = %s, want the X-Ray trace", first.TraceID)
+	}
+	if first.Attributes[observability.FaaSColdStartKey] != true || second.Attributes[observability.FaaSColdStartKey] != false {
+		t.Errorf("%s = %v, %v, want true, false", observability.FaaSColdStartKey,
+			first.Attributes[observability.FaaSColdStartKey], second.Attributes[observability.FaaSColdStartKey])
+	}
+	if first.StatusCode == "Error" || second.StatusCode != "Error" {
+		t.Errorf("status codes = %q, %q, want the second invocation failed", first.StatusCode, second.StatusCode)
+	}
+}
@@ -0,0 +1,65 @@
+// Command prometheus runs a service whose metrics end up in Prometheus. It
+// sends them over OTLP like any other scenario; the collector started by
+// the example's docker-compose.yml exposes them in the Prometheus format,
+// and the Prometheus server scrapes the collector:
+//
+//	service → collector (OTLP, :4318) → collector's exporter (:8889) ← Prometheus (:9090)
+//
+// Prometheus stores running totals, so metrics are exported with cumulative
+// temporality.
+package main
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/app-obs/go/observability"
+)
+
+func main() {
+	f := observability.NewFactory(
+		observability.WithServiceName("orders"),
+		observability.WithApmType("otlp"),
+		observability.WithApmURL("http://localhost:4318/v1/traces"),
+		observability.WithMetricsType("otlp"),
+		observability.WithMetricsURL("http://localhost:4318/v1/metrics"),
+		observability.WithMetricTemporality("cumulative"),
+	)
+	server := &http.Server{Addr: ":8080", Handler: f.Middleware(newMux())}
+	if err := f.RunServer(context.Background(), server); err != nil {
+		observability.LogFatal("Service failed", "error", err)
+	}
+}
+
+// newMux returns the service's routes. Each order placed is counted in
+// orders.placed, and its number of items recorded in the orders.items
+// histogram; Prometheus shows them as orders_placed_total and
+// orders_items_bucket.
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /orders", func(w http.ResponseWriter, r *http.Request) {
+		obs := observability.ObsFromCtx(r.Context())
+		items, err := strconv.Atoi(r.URL.Query().Get("items"))
+		if err != nil || items < 1 {
+			http.Error(w, "items must be a positive number", http.StatusBadRequest)
+			return
+		}
+
+		obs.Metrics.Inc("orders.placed")
+		sizes, err := obs.Metrics.Histogram("orders.items",
+			metric.WithDescription("Number of items in each order placed."),
+			metric.WithExplicitBucketBoundaries(1, 2, 5, 10, 20),
+		)
+		if err != nil {
+			obs.ErrorHandler.Record(err, "Failed to create the orders.items histogram")
+		} else {
+			sizes.Record(r.Context(), float64(items))
+		}
+		obs.Log.Info("Order placed", "order.items", items)
+		w.WriteHeader(http.StatusCreated)
+	})
+	return mux
+}
@@ -0,0 +1,91 @@
+//go:build otlp || !(datadog || none)
+
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	colmetricpb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricpb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/app-obs/go/observability"
+)
+
+func TestOrdersExportsCumulativeMetrics(t *testing.T) {
+	// A fake collector records the metrics the service exports.
+	var (
+		mu      sync.Mutex
+		metrics = map[string]*metricpb.Metric{}
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		var req colmetricpb.ExportMetricsServiceRequest
+		if err := proto.Unmarshal(body, &req); err != nil {
+			t.Errorf("decode export request: %v", err)
+		}
+		mu.Lock()
+		for _, rm := range req.GetResourceMetrics() {
+			for _, sm := range rm.GetScopeMetrics() {
+				for _, m := range sm.GetMetrics() {
+					metrics[m.GetName()] = m
+				}
+			}
+		}
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		body, _ = proto.Marshal(&colmetricpb.ExportMetricsServiceResponse{})
+		w.Write(body)
+	}))
+	defer collector.Close()
+
+	f := observability.NewFactory(
+		observability.WithServiceName("prometheus-smoke"),
+		observability.WithMetricsType("otlp"),
+		observability.WithMetricsURL(collector.URL+"/v1/metrics"),
+		observability.WithMetricTemporality("cumulative"),
+	)
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdowner.Shutdown(context.Background())
+
+	srv := httptest.NewServer(f.Middleware(newMux()))
+	defer srv.Close()
+	for _, items := range []string{"2", "7", "none"} {
+		resp, err := http.Post(srv.URL+"/orders?items="+items, "", nil)
+		if err != nil {
+			t.Fatalf("POST /orders: %v", err)
+		}
+		resp.Body.Close()
+	}
+	if err := f.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	placed := metrics["orders.placed"].GetSum()
+	if placed == nil || len(placed.GetDataPoints()) != 1 {
+		t.Fatalf("orders.placed = %v, want one sum data point", metrics["orders.placed"])
+	}
+	if got := placed.GetDataPoints()[0].GetAsDouble(); got != 2 {
+		t.Errorf("orders.placed = %v, want 2", got)
+	}
+	if placed.GetAggregationTemporality() != metricpb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE {
+		t.Errorf("orders.placed temporality = %v, want cumulative", placed.GetAggregationTemporality())
+	}
+	sizes := metrics["orders.items"].GetHistogram()
+	if sizes == nil || len(sizes.GetDataPoints()) != 1 {
+		t.Fatalf("orders.items = %v, want one histogram data point", metrics["orders.items"])
+	}
+	if dp := sizes.GetDataPoints()[0]; dp.GetCount() != 2 || dp.GetSum() != 9 {
+		t.Errorf("orders.items count, sum = %d, %v, want 2, 9", dp.GetCount(), dp.GetSum())
+	}
+}
@@ -0,0 +1,90 @@
+package observability
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// serveConfig serves the factory's effective configuration, with the source
+// of each value.
+func (f *Factory) serveConfig(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, f.state().Config)
+}
+
+// serveLogLevel serves the minimum level of the default log output on GET,
+// and sets it on PUT or POST to the level in the level parameter or the
+// request body, such as "debug" or "warn".
+func (f *Factory) serveLogLevel(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+	case http.MethodPut, http.MethodPost:
+		text := r.URL.Query().Get("level")
+		if text == "" {
+			body, err := io.ReadAll(io.LimitReader(r.Body, 64))
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			text = strings.TrimSpace(string(body))
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(text)); err != nil {
+			http.Error(w, "Invalid log level: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if previous := f.logLevel.Level(); level != previous {
+			f.logLevel.Set(level)
+			f.providers.logger.Warn("Log level changed", "previous", previous, "level", level)
+		}
+	default:
+		w.Header().Set("Allow", "GET, PUT, POST")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]slog.Level{"level": f.logLevel.Level()})
+}
+
+// serveHealth serves the status of each pipeline component. It responds
+// with 503 Service Unavailable if any component failed or was shut down.
+func (f *Factory) serveHealth(w http.ResponseWriter, r *http.Request) {
+	components := f.state().Components
+	status, code := "ok", http.StatusOK
+	for _, c := range components {
+		if c.Status == statusFailed || c.Status == statusStopped {
+			status, code = "unhealthy", http.StatusServiceUnavailable
+			break
+		}
+	}
+	writeJSON(w, code, struct {
+		Status     string                     `json:"status"`
+		Components map[string]componentStatus `json:"components"`
+	}{status, components})
+}
+
+// serveBuild serves the identity of the service and of the running binary.
+func (f *Factory) serveBuild(w http.ResponseWriter, r *http.Request) {
+	info := map[string]any{
+		"service.name":            f.config.ServiceName.Value,
+		"service.version":         f.config.ServiceVersion.Value,
+		"application":             f.config.ServiceApp.Value,
+		"environment":             f.config.ServiceEnv.Value,
+		"module.version":          f.build.version,
+		"vcs.revision":            f.build.revision,
+		"vcs.modified":            f.build.modified,
+		"process.runtime.version": f.build.goVersion,
+	}
+	writeJSON(w, http.StatusOK, info)
+}
+
+// writeJSON writes v as an indented JSON response with the status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,194 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// adminServer serves the admin endpoints on their own listener, apart from
+// the service's public traffic.
+type adminServer struct {
+	server *http.Server
+	done   chan struct{}
+}
+
+// startAdminServer listens on addr and serves the admin endpoints in the
+// background. Listening before returning makes an unusable address fail
+// Setup instead of surfacing later.
+func (f *Factory) startAdminServer(addr string) (*adminServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on admin address %q: %w", addr, err)
+	}
+	s := &adminServer{
+		server: &http.Server{Handler: f.AdminHandler(), ReadHeaderTimeout: 10 * time.Second},
+		done:   make(chan struct{}),
+	}
+	go func() {
+		defer close(s.done)
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			f.providers.logger.Error("Admin server failed", "addr", ln.Addr().String(), "error", err)
+		}
+	}()
+	f.providers.logger.Info("Admin server started", "addr", ln.Addr().String())
+	return s, nil
+}
+
+// AdminHandler returns the handler of the admin server started by
+// WithAdminServer, for services that serve it on a listener of their own.
+// It serves:
+//
+//   - /debug/config: the effective configuration, with the source of each
+//     value
+//   - /debug/loglevel: the minimum log level on GET; PUT or POST sets it,
+//     e.g. with ?level=debug
+//   - /debug/health: the status of each pipeline component, with 503 if any
+//     failed
+//   - /debug/build: the service's identity and the binary's build info
+//   - /debug/pprof/: the net/http/pprof profiles
+//
+// The endpoints are unauthenticated and must not be reachable from outside
+// the service's network.
+func (f *Factory) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/config", f.serveConfig)
+	mux.HandleFunc("/debug/loglevel", f.serveLogLevel)
+	mux.HandleFunc("/debug/health", f.serveHealth)
+	mux.HandleFunc("/debug/build", f.serveBuild)
+	mux.HandleFunc("/debug/pprof/", servePprofIndex)
+	mux.HandleFunc("/debug/pprof/cmdline", servePprofCmdline)
+	mux.HandleFunc("/debug/pprof/profile", servePprofCPU)
+	mux.HandleFunc("/debug/pprof/trace", servePprofTrace)
+	return mux
+}
+
+// Shutdown stops the admin server, waiting for in-flight requests such as a
+// running CPU profile.
+func (s *adminServer) Shutdown(ctx context.Context) error {
+	if err := s.server.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown admin server: %w", err)
+	}
+	<-s.done
+	return nil
+}
+
+// ShutdownOrLog implements the Shutdowner interface.
+func (s *adminServer) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(s, msg)
+}
+
+// The pprof handlers below serve the same URLs as net/http/pprof, so that
+// go tool pprof works against the admin server, without importing it:
+// net/http/pprof registers its handlers on http.DefaultServeMux, which
+// would expose them on the public listener of any service using it.
+
+// servePprofIndex serves the named profile in the path, such as
+// /debug/pprof/heap, or else a list of the available profiles.
+func servePprofIndex(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+	if name == "" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "<html><head><title>/debug/pprof/</title></head><body><p>Profiles:</p><ul>\n")
+		for _, p := range pprof.Profiles() {
+			fmt.Fprintf(w, "<li>%d <a href=\"%s?debug=1\">%s</a></li>\n", p.Count(), html.EscapeString(p.Name()), html.EscapeString(p.Name()))
+		}
+		fmt.Fprint(w, "<li><a href=\"profile\">profile</a> (CPU, ?seconds=30)</li>\n")
+		fmt.Fprint(w, "<li><a href=\"trace\">trace</a> (execution trace, ?seconds=1)</li>\n")
+		fmt.Fprint(w, "</ul></body></html>\n")
+		return
+	}
+
+	p := pprof.Lookup(name)
+	if p == nil {
+		http.Error(w, "Unknown profile: "+name, http.StatusNotFound)
+		return
+	}
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if name == "heap" && r.FormValue("gc") != "" {
+		runtime.GC()
+	}
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if debug != 0 {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	}
+	p.WriteTo(w, debug)
+}
+
+// servePprofCmdline serves the command line of the process, with its
+// arguments separated by NUL bytes.
+func servePprofCmdline(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, strings.Join(os.Args, "\x00"))
+}
+
+// servePprofCPU serves a CPU profile of the number of seconds in the
+// seconds parameter, 30 by default. Continuous profiling pauses meanwhile.
+func servePprofCPU(w http.ResponseWriter, r *http.Request) {
+	duration := pprofDuration(r, 30*time.Second)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+	if !acquireCPUProfile(r.Context().Done(), true) {
+		return
+	}
+	defer releaseCPUProfile()
+	if err := pprof.StartCPUProfile(w); err != nil {
+		pprofError(w, "Could not enable CPU profiling: "+err.Error())
+		return
+	}
+	sleep(r, duration)
+	pprof.StopCPUProfile()
+}
+
+// servePprofTrace serves an execution trace of the number of seconds in the
+// seconds parameter, 1 by default.
+func servePprofTrace(w http.ResponseWriter, r *http.Request) {
+	duration := pprofDuration(r, time.Second)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+	if err := trace.Start(w); err != nil {
+		pprofError(w, "Could not enable tracing: "+err.Error())
+		return
+	}
+	sleep(r, duration)
+	trace.Stop()
+}
+
+func pprofDuration(r *http.Request, def time.Duration) time.Duration {
+	if sec, err := strconv.ParseFloat(r.FormValue("seconds"), 64); err == nil && sec > 0 {
+		return time.Duration(sec * float64(time.Second))
+	}
+	return def
+}
+
+// sleep waits for d, or until the client goes away.
+func sleep(r *http.Request, d time.Duration) {
+	select {
+	case <-time.After(d):
+	case <-r.Context().Done():
+	}
+}
+
+// pprofError reports a failure before any of the profile was written.
+func pprofError(w http.ResponseWriter, msg string) {
+	w.Header().Del("Content-Disposition")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	fmt.Fprintln(w, msg)
+}
@@ -1,6 +1,9 @@
 package observability
 
-import "strings"
+import (
+	"strings"
+	"sync"
+)
 
 // APMType defines the type of Application Performance Monitoring.
 type APMType string
@@ -12,18 +15,79 @@ const (
 	Datadog APMType = "datadog"
 	// None disables APM.
 	None APMType = "none"
+	// Stdout writes spans to stdout, for local development.
+	Stdout APMType = "stdout"
+	// File appends spans as JSON lines to a rotating file, for environments
+	// that ship telemetry as files.
+	File APMType = "file"
+	// Jaeger exports spans to Jaeger's OTLP receiver and propagates Jaeger's
+	// trace header as well as W3C trace context.
+	Jaeger APMType = "jaeger"
+	// DatadogOTel sends spans to the Datadog Agent through dd-trace-go's
+	// OpenTelemetry API, so spans are OpenTelemetry spans in the process and
+	// Datadog spans on export.
+	DatadogOTel APMType = "ddotel"
 )
 
+// apmProvider pairs the setup function and span factory registered for an APM type.
+type apmProvider struct {
+	setup SetupFunc
+	spans SpanFactory
+}
+
+var (
+	apmProvidersMu sync.RWMutex
+	apmProviders   = make(map[APMType]apmProvider)
+)
+
+// RegisterAPMProvider makes an APM backend available under the given name, so
+// it can be selected at runtime with WithApmType or OBS_APM_TYPE. Names are
+// case-insensitive, and registering an existing name replaces it.
+//
+// The built-in providers register themselves when compiled in (see the build
+// tags in the README):
+//
+//   - "otlp", "stdout", "file", and "jaeger", with the otlp tag or without
+//     APM build tags;
+//   - "datadog", with the datadog tag or without APM build tags;
+//   - "ddotel", with both the otlp and datadog tags or without APM build
+//     tags;
+//   - "none", always.
+//
+// Custom providers must be registered before Factory.Setup is called,
+// typically from an init function.
+func RegisterAPMProvider(name string, setup SetupFunc, spans SpanFactory) {
+	apmProvidersMu.Lock()
+	defer apmProvidersMu.Unlock()
+	apmProviders[APMType(strings.ToLower(name))] = apmProvider{setup: setup, spans: spans}
+}
+
+// lookupAPMProvider returns the provider registered for apmType, if any.
+func lookupAPMProvider(apmType APMType) (apmProvider, bool) {
+	apmProvidersMu.RLock()
+	defer apmProvidersMu.RUnlock()
+	p, ok := apmProviders[apmType]
+	return p, ok
+}
+
+// spanFactoryFor returns the span factory registered for apmType, or a no-op
+// factory if the type is not available in this build.
+func spanFactoryFor(apmType APMType) SpanFactory {
+	if p, ok := lookupAPMProvider(apmType); ok {
+		return p.spans
+	}
+	return noneSpanFactory{}
+}
+
 // normalizeAPMType converts a string to a canonical APMType, ignoring case.
 func normalizeAPMType(apmType string) APMType {
-	switch strings.ToLower(apmType) {
-	case "otlp":
-		return OTLP
-	case "datadog":
-		return Datadog
-	case "none":
-		return None
-	default:
-		return None // Default to no APM if the type is unknown
+	t := APMType(strings.ToLower(apmType))
+	switch t {
+	case OTLP, Datadog, None, Stdout, File, Jaeger, DatadogOTel:
+		return t
+	}
+	if _, ok := lookupAPMProvider(t); ok {
+		return t
 	}
+	return None // Default to no APM if the type is unknown
 }
@@ -0,0 +1,98 @@
+package observability
+
+import (
+	"path"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// AttributeFilter decides which span attributes leave the process. Its
+// patterns match attribute keys as path.Match does, so "user.*" matches
+// user.email and user.id, and "*" matches any key without a slash.
+type AttributeFilter struct {
+	// Allow, if not empty, keeps only the attributes whose key matches
+	// one of its patterns.
+	Allow []string
+	// Deny drops the attributes whose key matches one of its patterns,
+	// even if Allow matches it too.
+	Deny []string
+}
+
+// keeps reports whether the attribute named key passes the filter.
+func (f AttributeFilter) keeps(key string) bool {
+	if len(f.Allow) > 0 && !matchAny(f.Allow, key) {
+		return false
+	}
+	return !matchAny(f.Deny, key)
+}
+
+// validate returns the error of the first malformed pattern, if any.
+func (f AttributeFilter) validate() error {
+	for _, pattern := range append(f.Allow[:len(f.Allow):len(f.Allow)], f.Deny...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func matchAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAttributePatterns parses the comma-separated patterns of
+// OBS_ATTRIBUTE_ALLOW and OBS_ATTRIBUTE_DENY. Empty patterns are skipped.
+func parseAttributePatterns(val string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(val, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// filterAttributes returns attrs without those f does not keep. attrs is
+// copied on the first attribute dropped, so the slice given is never
+// modified.
+func filterAttributes(attrs []attribute.KeyValue, f *AttributeFilter) []attribute.KeyValue {
+	if f == nil {
+		return attrs
+	}
+	for i, kv := range attrs {
+		if f.keeps(string(kv.Key)) {
+			continue
+		}
+		out := make([]attribute.KeyValue, i, len(attrs)-1)
+		copy(out, attrs[:i])
+		for _, kv := range attrs[i+1:] {
+			if f.keeps(string(kv.Key)) {
+				out = append(out, kv)
+			}
+		}
+		return out
+	}
+	return attrs
+}
+
+// attributeTransform rewrites the attributes of a span, or of one of its
+// events or links, before they are exported. It must not modify the slice
+// it is given.
+type attributeTransform func([]attribute.KeyValue) []attribute.KeyValue
+
+// attributeTransform returns the transform that applies the attribute
+// filter and the value limit of cfg, or nil if there is neither.
+func (cfg TracingConfig) attributeTransform() attributeTransform {
+	if cfg.AttributeFilter == nil && cfg.AttrValueLimit <= 0 {
+		return nil
+	}
+	return func(attrs []attribute.KeyValue) []attribute.KeyValue {
+		return truncateAttributes(filterAttributes(attrs, cfg.AttributeFilter), cfg.AttrValueLimit)
+	}
+}
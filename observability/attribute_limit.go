@@ -0,0 +1,70 @@
+package observability
+
+import (
+	"unicode/utf8"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// truncatedMarker ends the string attribute values cut by
+// WithAttributeValueLimit.
+const truncatedMarker = "…[truncated]"
+
+// truncateValue cuts s to its first limit bytes, backing off to the start
+// of a UTF-8 sequence, and appends truncatedMarker. A limit of zero or less,
+// or a string within it, leaves s as it is.
+func truncateValue(s string, limit int) string {
+	if limit <= 0 || len(s) <= limit {
+		return s
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncatedMarker
+}
+
+// truncateAttributes returns attrs with their string and string slice
+// values cut to limit bytes. attrs is copied on the first value cut, so the
+// slice given is never modified.
+func truncateAttributes(attrs []attribute.KeyValue, limit int) []attribute.KeyValue {
+	if limit <= 0 {
+		return attrs
+	}
+	out := attrs
+	for i, kv := range attrs {
+		truncated, ok := truncateAttribute(kv, limit)
+		if !ok {
+			continue
+		}
+		if &out[0] == &attrs[0] {
+			out = make([]attribute.KeyValue, len(attrs))
+			copy(out, attrs)
+		}
+		out[i] = truncated
+	}
+	return out
+}
+
+// truncateAttribute returns kv with its value cut to limit bytes, and
+// whether anything was cut.
+func truncateAttribute(kv attribute.KeyValue, limit int) (attribute.KeyValue, bool) {
+	switch kv.Value.Type() {
+	case attribute.STRING:
+		if s := kv.Value.AsString(); len(s) > limit {
+			return kv.Key.String(truncateValue(s, limit)), true
+		}
+	case attribute.STRINGSLICE:
+		values := kv.Value.AsStringSlice()
+		cut := false
+		for i, s := range values {
+			if len(s) > limit {
+				values[i], cut = truncateValue(s, limit), true
+			}
+		}
+		if cut {
+			return kv.Key.StringSlice(values), true
+		}
+	}
+	return kv, false
+}
@@ -0,0 +1,56 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// transformingSpanExporter rewrites the attributes of the spans it exports,
+// and of their events and links, with transform.
+type transformingSpanExporter struct {
+	sdktrace.SpanExporter
+	transform attributeTransform
+}
+
+func (e transformingSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	transformed := make([]sdktrace.ReadOnlySpan, len(spans))
+	for i, s := range spans {
+		transformed[i] = transformedSpan{ReadOnlySpan: s, transform: e.transform}
+	}
+	return e.SpanExporter.ExportSpans(ctx, transformed)
+}
+
+// transformedSpan is a finished span whose attributes are rewritten with
+// transform.
+type transformedSpan struct {
+	sdktrace.ReadOnlySpan
+	transform attributeTransform
+}
+
+func (s transformedSpan) Attributes() []attribute.KeyValue {
+	return s.transform(s.ReadOnlySpan.Attributes())
+}
+
+func (s transformedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	out := make([]sdktrace.Event, len(events))
+	for i, e := range events {
+		e.Attributes = s.transform(e.Attributes)
+		out[i] = e
+	}
+	return out
+}
+
+func (s transformedSpan) Links() []sdktrace.Link {
+	links := s.ReadOnlySpan.Links()
+	out := make([]sdktrace.Link, len(links))
+	for i, l := range links {
+		l.Attributes = s.transform(l.Attributes)
+		out[i] = l
+	}
+	return out
+}
@@ -0,0 +1,239 @@
+package observability
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// AuditOutcome is the result of an audited action.
+type AuditOutcome string
+
+const (
+	AuditSuccess AuditOutcome = "success"
+	AuditFailure AuditOutcome = "failure"
+	// AuditDenied is an action refused for lack of permission.
+	AuditDenied AuditOutcome = "denied"
+)
+
+// AuditEvent is a compliance event: who did what to which resource, and
+// with what outcome. Actor, Action, Target, and Outcome are required.
+type AuditEvent struct {
+	// Actor identifies who performed the action, such as a user or service
+	// account ID.
+	Actor string
+	// Action is what was done, such as "user.delete".
+	Action string
+	// Target identifies the resource acted on.
+	Target string
+	// Outcome is the result of the action.
+	Outcome AuditOutcome
+	// Attrs are further details of the event.
+	Attrs SpanAttributes
+}
+
+// Audit writes audit records, for compliance events that must not share
+// the fate of debug logs. Records bypass the logging pipeline: they are
+// written synchronously to the audit destination (see WithAuditLog), are
+// never sampled, filtered by level, or dropped by asynchronous logging, and
+// each is written with a single Write. Every record carries a sequence
+// number, audit.seq, and the SHA-256 hash of the record before it,
+// audit.prev_hash, so that removed, reordered, or altered records can be
+// detected with VerifyAuditLog.
+type Audit struct {
+	obs *Observability
+}
+
+func newAudit(obs *Observability) *Audit {
+	return &Audit{obs: obs}
+}
+
+// Record writes event as an audit record, with the trace and span IDs of
+// the Observability. It returns an error if a required field is missing or
+// the record could not be written; callers that must not proceed without
+// an audit trail should fail the action then.
+func (a *Audit) Record(event AuditEvent) error {
+	switch {
+	case event.Actor == "":
+		return errors.New("audit event has no actor")
+	case event.Action == "":
+		return errors.New("audit event has no action")
+	case event.Target == "":
+		return errors.New("audit event has no target")
+	case event.Outcome == "":
+		return errors.New("audit event has no outcome")
+	}
+
+	attrs := make([]slog.Attr, 0, len(event.Attrs)+8)
+	attrs = append(attrs,
+		slog.String("audit.actor", event.Actor),
+		slog.String("audit.action", event.Action),
+		slog.String("audit.target", event.Target),
+		slog.String("audit.outcome", string(event.Outcome)),
+		slog.String("service.name", a.obs.serviceName),
+	)
+	traceID, spanID := a.obs.providers.spans.TraceIDs(a.obs.ctx)
+	if traceID != "" {
+		attrs = append(attrs, slog.String("trace.id", traceID), slog.String("span.id", spanID))
+	}
+	for _, k := range slices.Sorted(maps.Keys(event.Attrs)) {
+		attrs = append(attrs, slog.Any(k, event.Attrs[k]))
+	}
+	return a.obs.providers.audit.write(attrs)
+}
+
+// auditLog is the destination of a factory's audit records, and the state
+// of its hash chain.
+type auditLog struct {
+	mu       sync.Mutex
+	w        io.Writer
+	seq      uint64
+	prevHash string
+	buf      bytes.Buffer
+}
+
+// defaultAuditLog writes the audit records of instances not created by a
+// Factory.
+var defaultAuditLog = newAuditLog(os.Stdout)
+
+func newAuditLog(w io.Writer) *auditLog {
+	return &auditLog{w: w}
+}
+
+// setWriter replaces the destination, starting a new hash chain.
+func (l *auditLog) setWriter(w io.Writer) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.w, l.seq, l.prevHash = w, 0, ""
+}
+
+func (l *auditLog) write(attrs []slog.Attr) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf.Reset()
+	h := slog.NewJSONHandler(&l.buf, nil)
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "audit", 0)
+	r.AddAttrs(slog.Uint64("audit.seq", l.seq+1), slog.String("audit.prev_hash", l.prevHash))
+	r.AddAttrs(attrs...)
+	if err := h.Handle(context.Background(), r); err != nil {
+		return fmt.Errorf("failed to format audit record: %w", err)
+	}
+	if _, err := l.w.Write(l.buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write audit record: %w", err)
+	}
+	l.seq++
+	l.prevHash = auditHash(l.buf.Bytes())
+	return nil
+}
+
+// auditHash returns the hash of a record, as it is chained into the next
+// one: the SHA-256 of its line, without the newline, in hex.
+func auditHash(line []byte) string {
+	sum := sha256.Sum256(bytes.TrimSuffix(line, []byte("\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// auditFile is the audit file set with WithAuditFile. Every record is
+// synced as it is written, so an acknowledged audit record survives a crash.
+type auditFile struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openAuditFile opens path for appending, creating it and its directory if
+// needed.
+func openAuditFile(path string) (*auditFile, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditFile{file: file}, nil
+}
+
+func (f *auditFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	n, err := f.file.Write(p)
+	if err != nil {
+		return n, err
+	}
+	return n, f.file.Sync()
+}
+
+// Shutdown closes the audit file; records written after it fail.
+func (f *auditFile) Shutdown(ctx context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	if err != nil {
+		return fmt.Errorf("failed to close audit file: %w", err)
+	}
+	return nil
+}
+
+// ShutdownOrLog implements the Shutdowner interface.
+func (f *auditFile) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(f, msg)
+}
+
+// VerifyAuditLog checks the hash chain of the audit records read from r,
+// one JSON record per line as Audit writes them, and returns an error
+// naming the first record that was altered, removed, or reordered. Each
+// process run starts a new chain at audit.seq 1; lines that are not audit
+// records are skipped, so the records can be verified in a shared log.
+func VerifyAuditLog(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	var (
+		line     int
+		seq      uint64
+		prevHash string
+	)
+	for scanner.Scan() {
+		line++
+		var record struct {
+			Msg      string  `json:"msg"`
+			Seq      *uint64 `json:"audit.seq"`
+			PrevHash string  `json:"audit.prev_hash"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &record) != nil || record.Msg != "audit" || record.Seq == nil {
+			continue
+		}
+		switch {
+		case *record.Seq == 1:
+			if record.PrevHash != "" {
+				return fmt.Errorf("line %d: audit record 1 has a previous hash", line)
+			}
+		case *record.Seq != seq+1:
+			return fmt.Errorf("line %d: audit record %d follows record %d", line, *record.Seq, seq)
+		case record.PrevHash != prevHash:
+			return fmt.Errorf("line %d: audit record %d does not match the hash of record %d", line, *record.Seq, seq)
+		}
+		seq, prevHash = *record.Seq, auditHash(scanner.Bytes())
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,92 @@
+package observability
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// auditLines writes n audit records and returns their lines.
+func auditLines(t *testing.T, n int) []string {
+	t.Helper()
+	var buf bytes.Buffer
+	l := newAuditLog(&buf)
+	for i := range n {
+		if err := l.write([]slog.Attr{slog.String("audit.actor", "alice"), slog.Int("i", i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.SplitAfter(buf.String(), "\n")
+	return lines[:len(lines)-1]
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	lines := auditLines(t, 3)
+	restarted := auditLines(t, 2)
+	tests := []struct {
+		name    string
+		lines   []string
+		wantErr string
+	}{
+		{name: "intact", lines: lines},
+		{name: "empty"},
+		{
+			name:  "other lines skipped",
+			lines: []string{lines[0], `{"msg":"request handled"}` + "\n", "not json\n", lines[1], lines[2]},
+		},
+		{name: "new chain", lines: append(append([]string{}, lines...), restarted...)},
+		{
+			name:    "altered",
+			lines:   []string{lines[0], strings.Replace(lines[1], "alice", "mallory", 1), lines[2]},
+			wantErr: "line 3: audit record 3 does not match the hash of record 2",
+		},
+		{
+			name:    "removed",
+			lines:   []string{lines[0], lines[2]},
+			wantErr: "line 2: audit record 3 follows record 1",
+		},
+		{
+			name:    "reordered",
+			lines:   []string{lines[0], lines[2], lines[1]},
+			wantErr: "line 2: audit record 3 follows record 1",
+		},
+		{
+			name:    "first record with previous hash",
+			lines:   []string{strings.Replace(lines[0], `"audit.prev_hash":""`, `"audit.prev_hash":"00"`, 1)},
+			wantErr: "line 1: audit record 1 has a previous hash",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyAuditLog(strings.NewReader(strings.Join(tt.lines, "")))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("VerifyAuditLog: %v", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("VerifyAuditLog error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuditRecordRequiredFields(t *testing.T) {
+	complete := AuditEvent{Actor: "alice", Action: "user.delete", Target: "user/42", Outcome: AuditSuccess}
+	tests := []struct {
+		name    string
+		clear   func(*AuditEvent)
+		wantErr string
+	}{
+		{"actor", func(e *AuditEvent) { e.Actor = "" }, "audit event has no actor"},
+		{"action", func(e *AuditEvent) { e.Action = "" }, "audit event has no action"},
+		{"target", func(e *AuditEvent) { e.Target = "" }, "audit event has no target"},
+		{"outcome", func(e *AuditEvent) { e.Outcome = "" }, "audit event has no outcome"},
+	}
+	for _, tt := range tests {
+		event := complete
+		tt.clear(&event)
+		if err := (&Audit{}).Record(event); err == nil || err.Error() != tt.wantErr {
+			t.Errorf("without %s: error = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
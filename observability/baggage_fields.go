@@ -0,0 +1,60 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// baggageReader is implemented by span factories whose provider carries
+// baggage of its own, besides W3C baggage, such as Datadog's ot-baggage-*
+// items.
+type baggageReader interface {
+	baggageItem(ctx context.Context, key string) string
+}
+
+// baggageFields returns the context fields of WithBaggageFields: the members
+// of the W3C baggage in ctx named by keys or, failing that, the baggage
+// items of the APM provider, under their own names. The span factory is
+// looked up on each call, since Setup replaces it.
+func (f *Factory) baggageFields(keys []string) func(context.Context) []slog.Attr {
+	return func(ctx context.Context) []slog.Attr {
+		bag := baggage.FromContext(ctx)
+		reader, _ := f.providers.spans.(baggageReader)
+		var attrs []slog.Attr
+		for _, key := range keys {
+			value := bag.Member(key).Value()
+			if value == "" && reader != nil {
+				value = reader.baggageItem(ctx, key)
+			}
+			if value != "" {
+				attrs = append(attrs, slog.String(key, value))
+			}
+		}
+		return attrs
+	}
+}
+
+// contextFields returns the functions that extract context fields: those of
+// WithContextFields, and one for the keys of WithBaggageFields.
+func (f *Factory) contextFields() []func(context.Context) []slog.Attr {
+	fields := f.config.ContextFields.Value
+	if keys := f.config.BaggageFields.Value; len(keys) > 0 {
+		fields = append(fields[:len(fields):len(fields)], f.baggageFields(keys))
+	}
+	return fields
+}
+
+// parseBaggageFields parses the comma-separated keys of OBS_BAGGAGE_FIELDS.
+// Empty keys are skipped.
+func parseBaggageFields(val string) []string {
+	var keys []string
+	for _, key := range strings.Split(val, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
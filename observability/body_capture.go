@@ -0,0 +1,177 @@
+package observability
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const defaultBodyCaptureMaxBytes = 4096
+
+// BodyCapture selects which HTTP request and response bodies Middleware
+// records on the request span, to debug failures that depend on the payload.
+// Only JSON bodies are captured, and only the allowlisted fields are kept.
+// Nothing is captured unless both Paths and Fields are set.
+type BodyCapture struct {
+	// Paths lists the URL path prefixes whose bodies are captured.
+	Paths []string
+	// Fields lists the JSON fields to keep, using dots for nested objects
+	// (e.g. "order.id"). Every other field is dropped before the body is
+	// attached to the span, and so is every field, or field nested in a kept
+	// object, whose dotted path WithAttributeFilter does not keep.
+	Fields []string
+	// MaxBytes limits how many bytes of each body are buffered. Bodies that
+	// are longer are not parsed; the span only notes that they were
+	// truncated. Zero means 4096.
+	MaxBytes int
+}
+
+// enabledFor reports whether bodies of requests to path are captured.
+func (c BodyCapture) enabledFor(path string) bool {
+	if len(c.Fields) == 0 {
+		return false
+	}
+	for _, prefix := range c.Paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c BodyCapture) maxBytes() int {
+	if c.MaxBytes > 0 {
+		return c.MaxBytes
+	}
+	return defaultBodyCaptureMaxBytes
+}
+
+// captureBuffer keeps the first max bytes written to it.
+type captureBuffer struct {
+	data      []byte
+	max       int
+	truncated bool
+}
+
+func newCaptureBuffer(max int) *captureBuffer {
+	return &captureBuffer{max: max}
+}
+
+func (b *captureBuffer) write(p []byte) {
+	if room := b.max - len(b.data); len(p) > room {
+		p = p[:room]
+		b.truncated = true
+	}
+	b.data = append(b.data, p...)
+}
+
+// teeBody copies what the handler reads from a request body into a
+// captureBuffer. Bytes the handler never reads are not captured.
+type teeBody struct {
+	io.ReadCloser
+	buf *captureBuffer
+}
+
+func (t *teeBody) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.buf.write(p[:n])
+	return n, err
+}
+
+// isJSON reports whether contentType is application/json or a +json type.
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// bodyAttributes returns the span attributes for a captured body under key:
+// the allowlisted fields that filter, if set, keeps as a JSON object, or a
+// truncation marker.
+func bodyAttributes(key string, buf *captureBuffer, fields []string, filter *AttributeFilter) []attribute.KeyValue {
+	if buf.truncated {
+		return []attribute.KeyValue{attribute.Bool(key+".truncated", true)}
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(buf.data, &doc); err != nil {
+		return nil
+	}
+	snippet, err := json.Marshal(selectFields(doc, fields, filter))
+	if err != nil {
+		return nil
+	}
+	return []attribute.KeyValue{attribute.String(key, string(snippet))}
+}
+
+// selectFields copies the dotted field paths present in doc into a new
+// document with the same nesting. Paths through arrays are not followed.
+// With a filter, the fields whose paths it does not keep are left out, as
+// are the fields of copied objects whose paths it does not keep.
+func selectFields(doc map[string]any, fields []string, filter *AttributeFilter) map[string]any {
+	out := make(map[string]any)
+	for _, field := range fields {
+		if filter != nil && !filter.keeps(field) {
+			continue
+		}
+		selectField(doc, strings.Split(field, "."), out, field, filter)
+	}
+	return out
+}
+
+// selectField copies the value at keys, the path field, from src into dst,
+// creating parent objects in dst only when the value exists. It reports
+// whether it did.
+func selectField(src map[string]any, keys []string, dst map[string]any, field string, filter *AttributeFilter) bool {
+	val, ok := src[keys[0]]
+	if !ok {
+		return false
+	}
+	if len(keys) == 1 {
+		if filter != nil {
+			val = filterValue(val, field, *filter)
+		}
+		dst[keys[0]] = val
+		return true
+	}
+	next, ok := val.(map[string]any)
+	if !ok {
+		return false
+	}
+	if child, ok := dst[keys[0]].(map[string]any); ok {
+		return selectField(next, keys[1:], child, field, filter)
+	}
+	child := make(map[string]any)
+	if !selectField(next, keys[1:], child, field, filter) {
+		return false
+	}
+	dst[keys[0]] = child
+	return true
+}
+
+// filterValue returns val, the value at path, without the object fields
+// nested in it whose paths filter does not keep. The elements of arrays
+// share the array's path.
+func filterValue(val any, path string, filter AttributeFilter) any {
+	switch v := val.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, nested := range v {
+			if p := path + "." + k; filter.keeps(p) {
+				out[k] = filterValue(nested, p, filter)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, elem := range v {
+			out[i] = filterValue(elem, path, filter)
+		}
+		return out
+	}
+	return val
+}
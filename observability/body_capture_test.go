@@ -0,0 +1,215 @@
+package observability
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSelectFields(t *testing.T) {
+	doc := map[string]any{
+		"order": map[string]any{"id": "o-1", "total": 42.0},
+		"user": map[string]any{
+			"name":    "alice",
+			"email":   "alice@example.com",
+			"address": map[string]any{"city": "Oslo", "street": "Main St 1"},
+		},
+		"items":    []any{map[string]any{"sku": "a", "secret": "x"}},
+		"password": "hunter2",
+	}
+	tests := []struct {
+		name   string
+		fields []string
+		filter *AttributeFilter
+		want   map[string]any
+	}{
+		{
+			name:   "nested field",
+			fields: []string{"order.id"},
+			want:   map[string]any{"order": map[string]any{"id": "o-1"}},
+		},
+		{
+			name:   "sibling fields share the parent",
+			fields: []string{"order.id", "order.total"},
+			want:   map[string]any{"order": map[string]any{"id": "o-1", "total": 42.0}},
+		},
+		{
+			name:   "missing field",
+			fields: []string{"order.missing", "nothing"},
+			want:   map[string]any{},
+		},
+		{
+			name:   "path through a non-object",
+			fields: []string{"password.length"},
+			want:   map[string]any{},
+		},
+		{
+			name:   "denied field",
+			fields: []string{"order.id", "password"},
+			filter: &AttributeFilter{Deny: []string{"password"}},
+			want:   map[string]any{"order": map[string]any{"id": "o-1"}},
+		},
+		{
+			name:   "denied field nested in a kept object",
+			fields: []string{"user"},
+			filter: &AttributeFilter{Deny: []string{"user.email", "*.street"}},
+			want: map[string]any{"user": map[string]any{
+				"name":    "alice",
+				"address": map[string]any{"city": "Oslo"},
+			}},
+		},
+		{
+			name:   "denied field in array elements",
+			fields: []string{"items"},
+			filter: &AttributeFilter{Deny: []string{"items.secret"}},
+			want:   map[string]any{"items": []any{map[string]any{"sku": "a"}}},
+		},
+		{
+			name:   "allow list",
+			fields: []string{"order", "user.name"},
+			filter: &AttributeFilter{Allow: []string{"order", "order.id", "user.name"}},
+			want: map[string]any{
+				"order": map[string]any{"id": "o-1"},
+				"user":  map[string]any{"name": "alice"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectFields(doc, tt.fields, tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBodyAttributes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		max  int
+		want []attribute.KeyValue
+	}{
+		{
+			name: "selected fields",
+			body: `{"order":{"id":"o-1"},"card":"4111"}`,
+			max:  100,
+			want: []attribute.KeyValue{attribute.String("http.request.body", `{"order":{"id":"o-1"}}`)},
+		},
+		{
+			name: "truncated",
+			body: `{"order":{"id":"o-1"},"card":"4111"}`,
+			max:  10,
+			want: []attribute.KeyValue{attribute.Bool("http.request.body.truncated", true)},
+		},
+		{
+			name: "exactly the limit",
+			body: `{"card":1}`,
+			max:  10,
+			want: []attribute.KeyValue{attribute.String("http.request.body", `{}`)},
+		},
+		{
+			name: "not JSON",
+			body: `order=o-1`,
+			max:  100,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := newCaptureBuffer(tt.max)
+			buf.write([]byte(tt.body))
+			got := bodyAttributes("http.request.body", buf, []string{"order.id"}, nil)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("bodyAttributes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsJSON(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/json", true},
+		{"application/json; charset=utf-8", true},
+		{"application/problem+json", true},
+		{"Application/JSON", true},
+		{"text/plain", false},
+		{"application/x-www-form-urlencoded", false},
+		{"multipart/form-data; boundary=x", false},
+		{"application/jsonp", false},
+		{"", false},
+		{"not a media type;", false},
+	}
+	for _, tt := range tests {
+		if got := isJSON(tt.contentType); got != tt.want {
+			t.Errorf("isJSON(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
+
+// recordingSpan is a recording span that keeps the attributes set on it.
+type recordingSpan struct {
+	benchSpan
+	attrs map[attribute.Key]attribute.Value
+}
+
+func (s *recordingSpan) IsRecording() bool { return true }
+func (s *recordingSpan) SetAttributes(attrs ...attribute.KeyValue) {
+	if s.attrs == nil {
+		s.attrs = make(map[attribute.Key]attribute.Value)
+	}
+	for _, kv := range attrs {
+		s.attrs[kv.Key] = kv.Value
+	}
+}
+
+func TestMiddlewareBodyCaptureContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		captured    bool
+	}{
+		{name: "JSON", contentType: "application/json", captured: true},
+		{name: "form", contentType: "application/x-www-form-urlencoded"},
+		{name: "text", contentType: "text/plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &recordingSpan{}
+			f := NewFactory(WithBodyCapture(BodyCapture{Paths: []string{"/orders"}, Fields: []string{"order.id"}}))
+			f.providers = defaultProviders(None)
+			f.providers.spans = benchSpanFactory{span: rec}
+			handler := f.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.Copy(io.Discard, r.Body)
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Write([]byte(`{"order":{"id":"o-1"}}`))
+			}))
+			req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewReader([]byte(`{"order":{"id":"o-1"}}`)))
+			req.Header.Set("Content-Type", tt.contentType)
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			for _, key := range []string{"http.request.body", "http.response.body"} {
+				got, ok := rec.attrs[attribute.Key(key)]
+				if ok != tt.captured {
+					t.Errorf("%s set = %v, want %v", key, ok, tt.captured)
+					continue
+				}
+				if ok {
+					var doc map[string]any
+					if err := json.Unmarshal([]byte(got.AsString()), &doc); err != nil || !strings.Contains(got.AsString(), "o-1") {
+						t.Errorf("%s = %s, want the order ID", key, got.AsString())
+					}
+				}
+			}
+		})
+	}
+}
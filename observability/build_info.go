@@ -0,0 +1,91 @@
+package observability
+
+import (
+	"context"
+	"runtime/debug"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// buildInfo is the identity of the running binary, read from the build
+// information the Go toolchain embeds.
+type buildInfo struct {
+	// version is the main module version, empty for local builds.
+	version   string
+	revision  string
+	modified  bool
+	goVersion string
+}
+
+// readBuildInfo returns the embedded build information, or a zero value if
+// the binary was built without module support.
+func readBuildInfo() buildInfo {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return buildInfo{}
+	}
+	info := buildInfo{goVersion: bi.GoVersion}
+	if bi.Main.Version != "(devel)" {
+		info.version = bi.Main.Version
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.revision = s.Value
+		case "vcs.modified":
+			info.modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// serviceVersion derives a version for the service: the module version if
+// the binary was built from a tagged module, otherwise the short VCS
+// revision, suffixed with "-dirty" for builds with uncommitted changes.
+func (b buildInfo) serviceVersion() string {
+	if b.version != "" {
+		return b.version
+	}
+	if b.revision == "" {
+		return ""
+	}
+	v := b.revision
+	if len(v) > 12 {
+		v = v[:12]
+	}
+	if b.modified {
+		v += "-dirty"
+	}
+	return v
+}
+
+// attributes returns the VCS and toolchain attributes of the build.
+func (b buildInfo) attributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if b.revision != "" {
+		attrs = append(attrs,
+			attribute.String("vcs.revision", b.revision),
+			attribute.Bool("vcs.modified", b.modified),
+		)
+	}
+	if b.goVersion != "" {
+		attrs = append(attrs, attribute.String("process.runtime.version", b.goVersion))
+	}
+	return attrs
+}
+
+// registerBuildInfo reports the build.info gauge: a constant 1 whose
+// attributes identify the binary, for joining metrics to releases.
+func registerBuildInfo(meter metric.Meter, serviceVersion string, b buildInfo) error {
+	attrs := append([]attribute.KeyValue{attribute.String("service.version", serviceVersion)}, b.attributes()...)
+	set := metric.WithAttributeSet(attribute.NewSet(attrs...))
+	_, err := meter.Int64ObservableGauge("build.info",
+		metric.WithDescription("Build information of the running binary; the value is always 1"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(1, set)
+			return nil
+		}),
+	)
+	return err
+}
@@ -0,0 +1,32 @@
+package observability
+
+import (
+	"context"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+// FaaSColdStartKey is the attribute with which the root spans of serverless
+// invocations report whether they started a new instance of the function.
+const FaaSColdStartKey = "faas.coldstart"
+
+// coldStarted is set once the process handled its first invocation. A
+// function instance is a process, so the cold start is the process's.
+var coldStarted atomic.Bool
+
+// coldStart reports whether the invocation is the first of the process and,
+// if so, counts it in the faas.coldstarts metric.
+func (f *Factory) coldStart(ctx context.Context) bool {
+	if coldStarted.Swap(true) {
+		return false
+	}
+	counter, err := f.providers.meters.Meter("go-observability").Int64Counter("faas.coldstarts",
+		metric.WithDescription("Number of invocations that started a new instance of the function"),
+		metric.WithUnit("{coldstart}"),
+	)
+	if err == nil {
+		counter.Add(ctx, 1)
+	}
+	return true
+}
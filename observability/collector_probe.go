@@ -0,0 +1,110 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// collectorProbeTimeout bounds each probe request.
+const collectorProbeTimeout = 2 * time.Second
+
+// Outcomes of probing an OTLP/HTTP endpoint.
+const (
+	probeSupported   = "supported"
+	probeUnsupported = "unsupported"
+	probeUnreachable = "unreachable"
+	probeError       = "error"
+)
+
+// probeResult is what the collector answered for one signal.
+type probeResult struct {
+	URL    string
+	Status string
+	Detail string
+}
+
+// collectorCapabilities records the probe outcome per signal. A signal that
+// was not probed has an empty Status.
+type collectorCapabilities struct {
+	Traces  probeResult
+	Metrics probeResult
+}
+
+// unsupported reports whether the collector answered that it does not accept
+// the signal. Unreachable collectors are not considered unsupported, since
+// they may come up later.
+func (r probeResult) unsupported() bool {
+	return r.Status == probeUnsupported
+}
+
+func (r probeResult) logValue() slog.Value {
+	if r.Status == "" {
+		return slog.StringValue("not probed")
+	}
+	return slog.GroupValue(
+		slog.String("url", redactedURL(r.URL)),
+		slog.String("status", r.Status),
+		slog.String("detail", r.Detail),
+	)
+}
+
+// probeOTLPEndpoint sends an empty OTLP/HTTP protobuf export request to url.
+// An empty request is valid for every signal and exports nothing, so the
+// response tells whether the collector serves the signal and accepts the
+// protobuf encoding the exporters use.
+func probeOTLPEndpoint(ctx context.Context, client *http.Client, url string) probeResult {
+	result := probeResult{URL: url}
+	ctx, cancel := context.WithTimeout(ctx, collectorProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(nil))
+	if err != nil {
+		result.Status, result.Detail = probeError, urlErrorCause(err).Error()
+		return result
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		result.Status, result.Detail = probeUnreachable, urlErrorCause(err).Error()
+		return result
+	}
+	resp.Body.Close()
+
+	result.Detail = resp.Status
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		result.Status = probeSupported
+	case resp.StatusCode == http.StatusNotFound,
+		resp.StatusCode == http.StatusMethodNotAllowed,
+		resp.StatusCode == http.StatusUnsupportedMediaType:
+		result.Status = probeUnsupported
+	default:
+		result.Status = probeError
+	}
+	return result
+}
+
+// probeCollector probes the OTLP endpoints the configured backends export to
+// and logs a capability report.
+func (f *Factory) probeCollector(ctx context.Context) collectorCapabilities {
+	var caps collectorCapabilities
+	client := &http.Client{}
+	switch normalizeAPMType(f.config.ApmType.Value) {
+	case OTLP:
+		caps.Traces = probeOTLPEndpoint(ctx, client, f.config.ApmURL.Value)
+	case Jaeger:
+		caps.Traces = probeOTLPEndpoint(ctx, client, jaegerTracesURL(f.config.ApmURL.Value))
+	}
+	if normalizeMetricsType(f.config.MetricsType.Value) == OTLPMetrics {
+		caps.Metrics = probeOTLPEndpoint(ctx, client, f.metricsURL())
+	}
+	f.providers.logger.Info("Collector capabilities probed",
+		slog.Any("traces", caps.Traces.logValue()),
+		slog.Any("metrics", caps.Metrics.logValue()),
+	)
+	return caps
+}
@@ -0,0 +1,49 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// contextFieldsHandler adds the fields that the functions given with
+// WithContextFields extract from the context of each record. It sits
+// outermost, so the fields are taken from the context of the code that
+// logged even when records are written asynchronously, and the apmHandler
+// copies them to the span with the rest of the record.
+type contextFieldsHandler struct {
+	slog.Handler
+	fields []func(context.Context) []slog.Attr
+}
+
+func (h contextFieldsHandler) Handle(ctx context.Context, r slog.Record) error {
+	for _, fields := range h.fields {
+		r.AddAttrs(fields(ctx)...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextFieldsHandler) wantsSource(level slog.Level) bool {
+	return forwardsSource(h.Handler, level)
+}
+
+func (h contextFieldsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextFieldsHandler{h.Handler.WithAttrs(attrs), h.fields}
+}
+
+func (h contextFieldsHandler) WithGroup(name string) slog.Handler {
+	return contextFieldsHandler{h.Handler.WithGroup(name), h.fields}
+}
+
+// contextSpanAttributes returns the fields extracted from ctx as span
+// attributes.
+func contextSpanAttributes(ctx context.Context, fields []func(context.Context) []slog.Attr) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, f := range fields {
+		for _, a := range f(ctx) {
+			attrs = appendOtelAttributes(attrs, "", a)
+		}
+	}
+	return attrs
+}
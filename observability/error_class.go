@@ -0,0 +1,177 @@
+package observability
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrorCategory says whose fault an error is, so error rates can be broken
+// down by the party that has to act on them.
+type ErrorCategory string
+
+const (
+	// ErrorClient is an error caused by the caller, such as invalid input
+	// or a missing resource.
+	ErrorClient ErrorCategory = "client"
+	// ErrorDependency is an error of a service or resource the service
+	// depends on, such as a database or another API.
+	ErrorDependency ErrorCategory = "dependency"
+	// ErrorInternal is a bug or failure in the service itself.
+	ErrorInternal ErrorCategory = "internal"
+)
+
+// Attribute keys under which a classified error is recorded.
+const (
+	ErrorCodeKey      = "error.code"
+	ErrorCategoryKey  = "error.category"
+	ErrorRetryableKey = "error.retryable"
+)
+
+// Attribute keys under which the chain of a wrapped or joined error is
+// recorded, as lists in the order errors.Is walks the chain: the error
+// itself first, then each wrapped error depth-first.
+const (
+	ErrorChainTypesKey    = "error.chain.types"
+	ErrorChainMessagesKey = "error.chain.messages"
+)
+
+// maxErrorChain bounds the errors recorded from one chain.
+const maxErrorChain = 16
+
+// ObsError classifies an error with a category, a stable code, and whether
+// the operation can be retried. When an ObsError is found in the chain of an
+// error logged at error level, passed to Span.EndWith, or recorded on a
+// span, its classification is added to the log record and the span as the
+// error.category, error.code, and error.retryable attributes, so dashboards
+// can break down error rates by class:
+//
+//	if err != nil {
+//		return observability.DependencyError("inventory_unavailable", err)
+//	}
+type ObsError struct {
+	// Err is the classified error. It may be nil when Code says it all.
+	Err error
+	// Category says whose fault the error is.
+	Category ErrorCategory
+	// Code identifies the error, such as "payment_declined". Keep it stable
+	// and low-cardinality: it is meant to be grouped by.
+	Code string
+	// Retryable reports whether retrying the operation may succeed.
+	Retryable bool
+}
+
+// Error returns the message of the classified error, or the code if there
+// is none.
+func (e *ObsError) Error() string {
+	if e.Err == nil {
+		return e.Code
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the classified error.
+func (e *ObsError) Unwrap() error {
+	return e.Err
+}
+
+// ClientError classifies err as the caller's fault, which retrying does not
+// fix.
+func ClientError(code string, err error) *ObsError {
+	return &ObsError{Err: err, Category: ErrorClient, Code: code}
+}
+
+// DependencyError classifies err as a failure of a dependency, which
+// retrying may fix.
+func DependencyError(code string, err error) *ObsError {
+	return &ObsError{Err: err, Category: ErrorDependency, Code: code, Retryable: true}
+}
+
+// InternalError classifies err as a failure of the service itself, which
+// retrying does not fix.
+func InternalError(code string, err error) *ObsError {
+	return &ObsError{Err: err, Category: ErrorInternal, Code: code}
+}
+
+// ClassifyError returns the classification of the first ObsError in the
+// chain of err, and whether there is one.
+func ClassifyError(err error) (*ObsError, bool) {
+	var e *ObsError
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
+
+// IsRetryable reports whether err is classified as retryable.
+func IsRetryable(err error) bool {
+	e, ok := ClassifyError(err)
+	return ok && e.Retryable
+}
+
+// errorAttributes returns the classification, the origin, and the chain of
+// err as span attributes, or nil if err has none of them.
+func errorAttributes(err error) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if traceID, spanID, ok := ErrorOrigin(err); ok {
+		attrs = append(attrs, attribute.String(ErrorTraceIDKey, traceID))
+		if spanID != "" {
+			attrs = append(attrs, attribute.String(ErrorSpanIDKey, spanID))
+		}
+	}
+	if e, ok := ClassifyError(err); ok {
+		if e.Category != "" {
+			attrs = append(attrs, attribute.String(ErrorCategoryKey, string(e.Category)))
+		}
+		if e.Code != "" {
+			attrs = append(attrs, attribute.String(ErrorCodeKey, e.Code))
+		}
+		attrs = append(attrs, attribute.Bool(ErrorRetryableKey, e.Retryable))
+	}
+	if types, messages := errorChain(err); len(types) > 1 {
+		attrs = append(attrs,
+			attribute.StringSlice(ErrorChainTypesKey, types),
+			attribute.StringSlice(ErrorChainMessagesKey, messages),
+		)
+	}
+	return attrs
+}
+
+// errorChain returns the types and messages of err and the errors it wraps,
+// following both Unwrap() error and Unwrap() []error, such as the errors
+// joined by errors.Join.
+func errorChain(err error) (types, messages []string) {
+	var walk func(error)
+	walk = func(err error) {
+		if err == nil || len(types) == maxErrorChain {
+			return
+		}
+		types = append(types, fmt.Sprintf("%T", err))
+		messages = append(messages, err.Error())
+		switch u := err.(type) {
+		case interface{ Unwrap() error }:
+			walk(u.Unwrap())
+		case interface{ Unwrap() []error }:
+			for _, e := range u.Unwrap() {
+				walk(e)
+			}
+		}
+	}
+	walk(err)
+	return types, messages
+}
+
+// errorLogAttrs returns the attributes of errorAttributes as log attributes.
+func errorLogAttrs(err error) []slog.Attr {
+	attrs := errorAttributes(err)
+	if attrs == nil {
+		return nil
+	}
+	logAttrs := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		logAttrs[i] = slog.Any(string(a.Key), a.Value.AsInterface())
+	}
+	return logAttrs
+}
@@ -1,9 +1,12 @@
 package observability
 
 import (
+	"context"
+	"encoding/json"
 	"log/slog"
 	"net/http"
 	"os"
+	"strings"
 )
 
 // ErrorHandler provides convenience methods for handling errors in a standardized way.
@@ -16,22 +19,78 @@ func newErrorHandler(obs *Observability) *ErrorHandler {
 	return &ErrorHandler{obs: obs}
 }
 
-// HTTP logs an error and writes a standard HTTP error response.
+// Formats of the error responses written by ErrorHandler.HTTP.
+const (
+	errorFormatText    = "text"
+	errorFormatProblem = "problem+json"
+)
+
+// problemDetails is an RFC 7807 problem details object, extended with the
+// ID of the trace the error occurred in and its link in the trace viewer.
+type problemDetails struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	TraceID  string `json:"trace_id,omitempty"`
+	TraceURL string `json:"trace_url,omitempty"`
+}
+
+// HTTP logs an error and writes an HTTP error response: msg as plain text
+// by default, or an RFC 7807 application/problem+json body carrying msg as
+// its detail and the current trace ID, with WithErrorResponseFormat.
 func (h *ErrorHandler) HTTP(w http.ResponseWriter, msg string, statusCode int) {
 	h.obs.Log.Logc(slog.LevelError, 3, msg)
-	http.Error(w, msg, statusCode)
+	if !strings.EqualFold(h.obs.providers.errorFormat, errorFormatProblem) {
+		http.Error(w, msg, statusCode)
+		return
+	}
+
+	traceID, spanID := h.obs.providers.spans.TraceIDs(h.obs.ctx)
+	body, _ := json.Marshal(problemDetails{
+		Type:     "about:blank",
+		Title:    http.StatusText(statusCode),
+		Status:   statusCode,
+		Detail:   msg,
+		TraceID:  traceID,
+		TraceURL: traceURL(h.obs.providers.traceURLTemplate, traceID, spanID),
+	})
+	header := w.Header()
+	header.Del("Content-Length")
+	header.Set("Content-Type", "application/problem+json")
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(statusCode)
+	w.Write(append(body, '\n'))
 }
 
 // Record logs an error. The underlying logging handler will automatically
 // record the error on the current trace span and set its status to Error.
 // This is for recoverable errors that are returned up the call stack.
 func (h *ErrorHandler) Record(err error, msg string) {
-	h.obs.Log.Error(msg, "error", err)
+	h.obs.Log.Logc(slog.LevelError, 3, msg, "error", err)
 }
 
-// Fatal logs a fatal error and exits the application.
+// Fatal logs a fatal error and exits the application, or calls the handler
+// set with WithFatalHandler instead of exiting.
 // This is for unrecoverable errors during startup.
+//
+// Before exiting, Fatal ends the active span, which carries the error, and
+// flushes buffered logs, spans, and metrics for up to 5 seconds, so the
+// error and its trace reach the backend.
 func (h *ErrorHandler) Fatal(msg string, args ...any) {
 	h.obs.Log.Logc(slog.LevelError, 3, msg, args...)
+
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	defer cancel()
+	// The span is ended first, so the flush exports it along with the logs.
+	if span := h.obs.providers.spans.SpanFromContext(h.obs.ctx); span != nil {
+		span.End()
+	}
+	flushPipelines(ctx)
+
+	if fatal := h.obs.providers.fatal; fatal != nil {
+		fatal(msg, args...)
+		return
+	}
 	os.Exit(1)
 }
@@ -0,0 +1,28 @@
+package observability
+
+import (
+	"bytes"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestErrorHandlerSource(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(h *ErrorHandler)
+	}{
+		{name: "Record", log: func(h *ErrorHandler) { h.Record(errors.New("boom"), "failed") }},
+		{name: "HTTP", log: func(h *ErrorHandler) { h.HTTP(httptest.NewRecorder(), "failed", 500) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			tt.log(newSourceTestObservability(&buf).ErrorHandler)
+			if got := recordSource(t, &buf); !strings.HasPrefix(got, "github.com/app-obs/go/observability.TestErrorHandlerSource.") {
+				t.Errorf("source = %s, want the caller of %s", got, tt.name)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package observability
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Attribute keys under which a TracedError records the span it was created
+// in, when it is logged or recorded elsewhere.
+const (
+	ErrorTraceIDKey = "error.trace.id"
+	ErrorSpanIDKey  = "error.span.id"
+)
+
+// TracedError is an error that remembers the trace and span it was created
+// in. Errors often travel far, through queues, retries, and goroutines,
+// before they are logged; when a TracedError is found in the chain of an
+// error logged at error level or recorded on a span, its origin is added as
+// the error.trace.id and error.span.id attributes, so the log links back to
+// the span where the error occurred.
+type TracedError struct {
+	// Err is the error that occurred.
+	Err error
+	// TraceID and SpanID identify the span the error was created in. They
+	// are empty if there was no active span.
+	TraceID string
+	SpanID  string
+}
+
+// Error returns the message of the wrapped error.
+func (e *TracedError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *TracedError) Unwrap() error {
+	return e.Err
+}
+
+// Errorf formats an error as fmt.Errorf does, including %w wrapping, and
+// records the active span of the Observability in it; see TracedError.
+func (o *Observability) Errorf(format string, args ...any) error {
+	return o.newTracedError(fmt.Errorf(format, args...))
+}
+
+// WrapError records the active span of the Observability in err; see
+// TracedError. It returns nil for a nil err, and err itself if its chain
+// already records the span it originated in.
+func (o *Observability) WrapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, _, ok := ErrorOrigin(err); ok {
+		return err
+	}
+	return o.newTracedError(err)
+}
+
+func (o *Observability) newTracedError(err error) error {
+	traceID, spanID := o.providers.spans.TraceIDs(o.ctx)
+	return &TracedError{Err: err, TraceID: traceID, SpanID: spanID}
+}
+
+// ErrorOrigin returns the trace and span IDs recorded by the first
+// TracedError in the chain of err that has them, and whether there is one.
+func ErrorOrigin(err error) (traceID, spanID string, ok bool) {
+	for err != nil {
+		var e *TracedError
+		if !errors.As(err, &e) {
+			return "", "", false
+		}
+		if e.TraceID != "" {
+			return e.TraceID, e.SpanID, true
+		}
+		err = e.Err
+	}
+	return "", "", false
+}
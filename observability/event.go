@@ -0,0 +1,80 @@
+package observability
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventNameKey is the attribute under which the records and measurements
+// of Observability.Event carry the name of the event.
+const EventNameKey = "event.name"
+
+// EventOption configures an event recorded with Observability.Event.
+type EventOption func(*eventConfig)
+
+type eventConfig struct {
+	counter string
+}
+
+// CountEvent makes Observability.Event also add 1 to the counter named
+// counter, created through Metrics, with the event's name as the event.name
+// attribute. The event's own attributes are not added to the measurement,
+// since they are often unique to one event.
+func CountEvent(counter string) EventOption {
+	return func(c *eventConfig) {
+		c.counter = counter
+	}
+}
+
+// spanEventRecordedKey marks the context of a log record whose span event
+// has been recorded already, so the log handler does not add it again.
+type spanEventRecordedKey struct{}
+
+// Event records a business event, such as "order_placed", in one call: as
+// an info log record with name as its message, as an event named name on
+// the active span, and, with CountEvent, as a counter increment. Both the
+// record and the span event carry attrs, and the record carries name as
+// event.name.
+//
+//	obs.Event("order_placed", observability.SpanAttributes{
+//		"order.id":    order.ID,
+//		"order.total": order.Total,
+//	}, observability.CountEvent("orders.placed"))
+func (o *Observability) Event(name string, attrs SpanAttributes, opts ...EventOption) {
+	var cfg eventConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	// Sorted, so the attributes come out in a stable order.
+	keys := slices.Sorted(maps.Keys(attrs))
+
+	if span := o.providers.spans.SpanFromContext(o.ctx); span != nil && span.IsRecording() {
+		spanAttrs := attrs.appendAttributes(make([]attribute.KeyValue, 0, len(keys)), "")
+		slices.SortFunc(spanAttrs, func(a, b attribute.KeyValue) int {
+			return cmp.Compare(a.Key, b.Key)
+		})
+		span.AddEvent(name, trace.WithAttributes(spanAttrs...))
+	}
+
+	ctx := context.WithValue(o.ctx, spanEventRecordedKey{}, true)
+	if logger := o.Log.logger; logger.Enabled(ctx, slog.LevelInfo) {
+		r := slog.NewRecord(time.Now(), slog.LevelInfo, name, o.Log.callerPC(slog.LevelInfo, 2))
+		r.AddAttrs(slog.String(EventNameKey, name))
+		for _, k := range keys {
+			r.AddAttrs(slog.Any(k, attrs[k]))
+		}
+		_ = logger.Handler().Handle(ctx, r)
+	}
+
+	if cfg.counter != "" {
+		o.Metrics.Inc(cfg.counter, attribute.String(EventNameKey, name))
+	}
+}
@@ -0,0 +1,139 @@
+package observability
+
+import (
+	"context"
+	"expvar"
+	"sync"
+)
+
+const (
+	statusRunning  = "running"
+	statusFailed   = "failed"
+	statusDisabled = "disabled"
+	statusStopped  = "stopped"
+	statusPassed   = "passed"
+)
+
+// componentStatus describes the lifecycle state of one pipeline component.
+type componentStatus struct {
+	Type   string `json:"type"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// factoryState is the machine-readable snapshot published through expvar.
+type factoryState struct {
+	Config     map[string]configValue     `json:"config"`
+	Logs       logPipelineState           `json:"logs"`
+	Pipeline   pipelineState              `json:"pipeline"`
+	Components map[string]componentStatus `json:"components"`
+}
+
+type configValue struct {
+	Value  any          `json:"value"`
+	Source configSource `json:"source"`
+}
+
+type logPipelineState struct {
+	Async         bool   `json:"async"`
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+	Dropped       uint64 `json:"dropped"`
+}
+
+var (
+	expvarOnce      sync.Once
+	expvarFactories sync.Map // service name -> *Factory
+)
+
+// publishExpvar exposes the factory's state under the "observability" expvar,
+// keyed by service name so several factories in one process stay distinct.
+func publishExpvar(f *Factory) {
+	expvarFactories.Store(f.config.ServiceName.Value, f)
+	expvarOnce.Do(func() {
+		expvar.Publish("observability", expvar.Func(func() any {
+			states := make(map[string]factoryState)
+			expvarFactories.Range(func(name, f any) bool {
+				states[name.(string)] = f.(*Factory).state()
+				return true
+			})
+			return states
+		}))
+	})
+}
+
+// state takes a snapshot of the factory's configuration and pipeline state.
+func (f *Factory) state() factoryState {
+	entries := f.config.entries()
+	state := factoryState{
+		Config:     make(map[string]configValue, len(entries)),
+		Components: make(map[string]componentStatus),
+	}
+	for _, e := range entries {
+		state.Config[e.Name] = configValue{Value: e.Value, Source: e.Source}
+	}
+	if level := f.logLevel.Level(); level != f.config.LogLevel.Value {
+		state.Config["log_level"] = configValue{Value: level, Source: sourceRuntime}
+	}
+
+	state.Pipeline = f.stats.state()
+	if f.asyncLogs != nil {
+		state.Logs.Async = true
+		state.Logs.QueueDepth, state.Logs.QueueCapacity, state.Logs.Dropped = f.asyncLogs.stats()
+	}
+
+	f.statusMu.Lock()
+	for name, s := range f.status {
+		state.Components[name] = s
+	}
+	f.statusMu.Unlock()
+
+	return state
+}
+
+// setStatus records the state of a pipeline component.
+func (f *Factory) setStatus(component, componentType, status string, err error) {
+	s := componentStatus{Type: componentType, Status: status}
+	if err != nil {
+		s.Status = statusFailed
+		s.Error = err.Error()
+	}
+	f.statusMu.Lock()
+	f.status[component] = s
+	f.statusMu.Unlock()
+}
+
+// track wraps a component's Shutdowner so its shutdown outcome is recorded.
+func (f *Factory) track(component string, s Shutdowner) Shutdowner {
+	return &trackedShutdowner{Shutdowner: s, factory: f, component: component}
+}
+
+// trackedShutdowner marks its component as stopped (or failed) once shut down.
+type trackedShutdowner struct {
+	Shutdowner
+	factory   *Factory
+	component string
+}
+
+// Shutdown shuts down the wrapped component and records the outcome.
+func (t *trackedShutdowner) Shutdown(ctx context.Context) error {
+	err := t.Shutdowner.Shutdown(ctx)
+	t.factory.statusMu.Lock()
+	s := t.factory.status[t.component]
+	t.factory.statusMu.Unlock()
+	t.factory.setStatus(t.component, s.Type, statusStopped, err)
+	return err
+}
+
+// ShutdownOrLog implements the Shutdowner interface.
+func (t *trackedShutdowner) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(t, msg)
+}
+
+// logType names the log pipeline mode for status reporting.
+func logType(async bool) string {
+	if async {
+		return "async"
+	}
+	return "sync"
+}
@@ -4,15 +4,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"os"
+	"regexp"
+	"slices"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // configSource represents the origin of a configuration value.
@@ -24,6 +29,8 @@ const (
 	sourceEnv         configSource = "env"
 	sourceHardcoded   configSource = "hardcoded"
 	sourceCalculation configSource = "calculation"
+	sourceBuildInfo   configSource = "build_info"
+	sourceRuntime     configSource = "runtime"
 )
 
 // setting represents a single configuration value and its source.
@@ -34,17 +41,164 @@ type setting[T any] struct {
 
 // factoryConfig holds the static configuration for the observability system.
 type factoryConfig struct {
-	ServiceName      setting[string]
-	ServiceApp       setting[string]
-	ServiceEnv       setting[string]
-	ApmType          setting[string]
-	MetricsType      setting[string]
-	ApmURL           setting[string]
-	LogSource        setting[bool]
-	SampleRate       setting[float64]
-	LogLevel         setting[slog.Level]
-	TraceLogLevel    setting[slog.Level]
-	AsynchronousLogs setting[bool]
+	ServiceName       setting[string]
+	ServiceApp        setting[string]
+	ServiceEnv        setting[string]
+	ServiceVersion    setting[string]
+	ApmType           setting[string]
+	MetricsType       setting[string]
+	ApmURL            setting[string]
+	StdoutFormat      setting[string]
+	FileRotation      setting[FileRotation]
+	MetricsURL        setting[string]
+	ProfilingURL      setting[string]
+	LogSource         setting[bool]
+	LogSourceLevel    setting[slog.Level]
+	SampleRate        setting[float64]
+	LogLevel          setting[slog.Level]
+	TraceLogLevel     setting[slog.Level]
+	AsynchronousLogs  setting[bool]
+	AsyncLogWorkers   setting[int]
+	AsyncLogOrdered   setting[bool]
+	SpanLimits        setting[SpanLimits]
+	AttrValueLimit    setting[int]
+	AttributeFilter   setting[*AttributeFilter]
+	SpanFilter        setting[SpanFilter]
+	Expvar            setting[bool]
+	AdminAddr         setting[string]
+	GlobalProviders   setting[bool]
+	SetSlogDefault    setting[bool]
+	LogHandler        setting[func(slog.Handler) slog.Handler]
+	SpanCompression   setting[time.Duration]
+	AdaptiveSampling  setting[int]
+	TailSampling      setting[*TailSampling]
+	SpanMetrics       setting[bool]
+	IDGenerator       setting[IDGenerator]
+	XRay              setting[bool]
+	DDPropagation     setting[bool]
+	TraceStateEntry   setting[string]
+	BodyCapture       setting[BodyCapture]
+	URLScrubbing      setting[URLScrubbing]
+	RoutePattern      setting[func(*http.Request) string]
+	LegacyHTTPAttrs   setting[bool]
+	SpanNameFormatter setting[func(*http.Request) string]
+	IgnoredPaths      setting[[]string]
+	TraceIDHeader     setting[string]
+	RequestIDHeader   setting[string]
+	AccessLog         setting[bool]
+	AccessLogLevel    setting[slog.Level]
+	SlowRequest       setting[time.Duration]
+	LogRoutes         setting[[]LogRoute]
+	LoggerLevels      setting[map[string]slog.Level]
+	LogMetrics        setting[bool]
+	LogMetricPatterns setting[[]LogMetricPattern]
+	StackTraces       setting[bool]
+	ContextFields     setting[[]func(context.Context) []slog.Attr]
+	BaggageFields     setting[[]string]
+	LogSchema         setting[string]
+	LogOutput         setting[string]
+	LogSinks          setting[[]LogSink]
+	LokiURL           setting[string]
+	ResourceDetection setting[bool]
+	ResourceDetectors setting[[]ResourceDetector]
+	MetricAttributes  setting[[]attribute.KeyValue]
+	MetricPrefix      setting[string]
+	ErrorFormat       setting[string]
+	FatalHandler      setting[func(string, ...any)]
+	TraceURLTemplate  setting[string]
+	AuditWriter       setting[io.Writer]
+	AuditFile         setting[string]
+	MetricTemporality setting[string]
+	CollectorProbe    setting[bool]
+	ExportError       setting[func(error)]
+	SelfTest          setting[bool]
+	Serverless        setting[bool]
+}
+
+// configEntry is a single configuration value flattened for reporting.
+type configEntry struct {
+	Name   string
+	Value  any
+	Source configSource
+}
+
+// entries lists every configuration value in a stable order, for reporting
+// through logs and expvar.
+func (c *factoryConfig) entries() []configEntry {
+	return []configEntry{
+		{"service_name", c.ServiceName.Value, c.ServiceName.Source},
+		{"service_app", c.ServiceApp.Value, c.ServiceApp.Source},
+		{"service_env", c.ServiceEnv.Value, c.ServiceEnv.Source},
+		{"service_version", c.ServiceVersion.Value, c.ServiceVersion.Source},
+		{"apm_type", c.ApmType.Value, c.ApmType.Source},
+		{"metrics_type", c.MetricsType.Value, c.MetricsType.Source},
+		{"apm_url", redactedURL(c.ApmURL.Value), c.ApmURL.Source},
+		{"stdout_trace_format", c.StdoutFormat.Value, c.StdoutFormat.Source},
+		{"trace_file_rotation", c.FileRotation.Value, c.FileRotation.Source},
+		{"metrics_url", redactedURL(c.MetricsURL.Value), c.MetricsURL.Source},
+		{"profiling_url", redactedURL(c.ProfilingURL.Value), c.ProfilingURL.Source},
+		{"log_source", c.LogSource.Value, c.LogSource.Source},
+		{"log_source_level", c.LogSourceLevel.Value, c.LogSourceLevel.Source},
+		{"sample_rate", c.SampleRate.Value, c.SampleRate.Source},
+		{"log_level", c.LogLevel.Value, c.LogLevel.Source},
+		{"trace_log_level", c.TraceLogLevel.Value, c.TraceLogLevel.Source},
+		{"async_logs", c.AsynchronousLogs.Value, c.AsynchronousLogs.Source},
+		{"async_log_workers", c.AsyncLogWorkers.Value, c.AsyncLogWorkers.Source},
+		{"async_log_ordered", c.AsyncLogOrdered.Value, c.AsyncLogOrdered.Source},
+		{"span_limits", c.SpanLimits.Value, c.SpanLimits.Source},
+		{"attribute_value_limit", c.AttrValueLimit.Value, c.AttrValueLimit.Source},
+		{"attribute_filter", c.AttributeFilter.Value != nil, c.AttributeFilter.Source},
+		{"custom_span_filter", c.SpanFilter.Value != nil, c.SpanFilter.Source},
+		{"expvar", c.Expvar.Value, c.Expvar.Source},
+		{"admin_addr", c.AdminAddr.Value, c.AdminAddr.Source},
+		{"global_providers", c.GlobalProviders.Value, c.GlobalProviders.Source},
+		{"set_slog_default", c.SetSlogDefault.Value, c.SetSlogDefault.Source},
+		{"custom_log_handler", c.LogHandler.Value != nil, c.LogHandler.Source},
+		{"span_compression", c.SpanCompression.Value.String(), c.SpanCompression.Source},
+		{"adaptive_sampling", c.AdaptiveSampling.Value, c.AdaptiveSampling.Source},
+		{"tail_sampling", c.TailSampling.Value != nil, c.TailSampling.Source},
+		{"span_metrics", c.SpanMetrics.Value, c.SpanMetrics.Source},
+		{"custom_id_generator", c.IDGenerator.Value != nil, c.IDGenerator.Source},
+		{"xray_compatibility", c.XRay.Value, c.XRay.Source},
+		{"datadog_propagation", c.DDPropagation.Value, c.DDPropagation.Source},
+		{"tracestate_entry", c.TraceStateEntry.Value, c.TraceStateEntry.Source},
+		{"body_capture", c.BodyCapture.Value, c.BodyCapture.Source},
+		{"url_scrubbing", c.URLScrubbing.Value, c.URLScrubbing.Source},
+		{"custom_route_pattern", c.RoutePattern.Value != nil, c.RoutePattern.Source},
+		{"custom_span_name_formatter", c.SpanNameFormatter.Value != nil, c.SpanNameFormatter.Source},
+		{"legacy_http_attributes", c.LegacyHTTPAttrs.Value, c.LegacyHTTPAttrs.Source},
+		{"ignored_paths", c.IgnoredPaths.Value, c.IgnoredPaths.Source},
+		{"trace_id_header", c.TraceIDHeader.Value, c.TraceIDHeader.Source},
+		{"request_id_header", c.RequestIDHeader.Value, c.RequestIDHeader.Source},
+		{"access_log", c.AccessLog.Value, c.AccessLog.Source},
+		{"access_log_level", c.AccessLogLevel.Value, c.AccessLogLevel.Source},
+		{"slow_request_threshold", c.SlowRequest.Value.String(), c.SlowRequest.Source},
+		{"log_routes", len(c.LogRoutes.Value), c.LogRoutes.Source},
+		{"logger_levels", c.LoggerLevels.Value, c.LoggerLevels.Source},
+		{"log_metrics", c.LogMetrics.Value, c.LogMetrics.Source},
+		{"log_metric_patterns", len(c.LogMetricPatterns.Value), c.LogMetricPatterns.Source},
+		{"error_stack_traces", c.StackTraces.Value, c.StackTraces.Source},
+		{"context_fields", len(c.ContextFields.Value), c.ContextFields.Source},
+		{"baggage_fields", c.BaggageFields.Value, c.BaggageFields.Source},
+		{"log_schema", c.LogSchema.Value, c.LogSchema.Source},
+		{"log_output", c.LogOutput.Value, c.LogOutput.Source},
+		{"log_sinks", len(c.LogSinks.Value), c.LogSinks.Source},
+		{"loki_url", redactedURL(c.LokiURL.Value), c.LokiURL.Source},
+		{"resource_detection", c.ResourceDetection.Value, c.ResourceDetection.Source},
+		{"resource_detectors", len(c.ResourceDetectors.Value), c.ResourceDetectors.Source},
+		{"metric_attributes", len(c.MetricAttributes.Value), c.MetricAttributes.Source},
+		{"metric_prefix", c.MetricPrefix.Value, c.MetricPrefix.Source},
+		{"error_response_format", c.ErrorFormat.Value, c.ErrorFormat.Source},
+		{"custom_fatal_handler", c.FatalHandler.Value != nil, c.FatalHandler.Source},
+		{"trace_url_template", c.TraceURLTemplate.Value, c.TraceURLTemplate.Source},
+		{"custom_audit_writer", c.AuditWriter.Value != nil, c.AuditWriter.Source},
+		{"audit_file", c.AuditFile.Value, c.AuditFile.Source},
+		{"metric_temporality", c.MetricTemporality.Value, c.MetricTemporality.Source},
+		{"collector_probe", c.CollectorProbe.Value, c.CollectorProbe.Source},
+		{"custom_export_error_handler", c.ExportError.Value != nil, c.ExportError.Source},
+		{"self_test", c.SelfTest.Value, c.SelfTest.Source},
+		{"serverless_mode", c.Serverless.Value, c.Serverless.Source},
+	}
 }
 
 // Option is a function that configures a `factoryConfig`.
@@ -71,6 +225,14 @@ func WithServiceEnv(env string) Option {
 	}
 }
 
+// WithServiceVersion sets the service version; by default it is read from the
+// binary's build information.
+func WithServiceVersion(version string) Option {
+	return func(c *factoryConfig) {
+		c.ServiceVersion = setting[string]{Value: version, Source: sourceOption}
+	}
+}
+
 // WithApmType sets the desired APM backend.
 func WithApmType(apmType string) Option {
 	return func(c *factoryConfig) {
@@ -92,6 +254,40 @@ func WithApmURL(url string) Option {
 	}
 }
 
+// WithStdoutTraceFormat sets how the "stdout" APM type writes spans: "pretty"
+// (the default) or "json".
+func WithStdoutTraceFormat(format string) Option {
+	return func(c *factoryConfig) {
+		c.StdoutFormat = setting[string]{Value: format, Source: sourceOption}
+	}
+}
+
+// WithTraceFileRotation sets the size at which the "file" APM type rotates its
+// span file and how many rotated files it keeps.
+func WithTraceFileRotation(maxBytes int64, maxBackups int) Option {
+	return func(c *factoryConfig) {
+		c.FileRotation = setting[FileRotation]{
+			Value:  FileRotation{MaxBytes: maxBytes, MaxBackups: maxBackups},
+			Source: sourceOption,
+		}
+	}
+}
+
+// WithMetricsURL sets the endpoint URL for metrics, which default to the APM URL.
+func WithMetricsURL(url string) Option {
+	return func(c *factoryConfig) {
+		c.MetricsURL = setting[string]{Value: url, Source: sourceOption}
+	}
+}
+
+// WithProfilingURL enables continuous CPU profiling, pushed to the Pyroscope
+// server at url.
+func WithProfilingURL(url string) Option {
+	return func(c *factoryConfig) {
+		c.ProfilingURL = setting[string]{Value: url, Source: sourceOption}
+	}
+}
+
 // WithLogSource enables or disables the automatic addition of source file and line number to logs.
 func WithLogSource(enabled bool) Option {
 	return func(c *factoryConfig) {
@@ -99,6 +295,14 @@ func WithLogSource(enabled bool) Option {
 	}
 }
 
+// WithLogSourceLevel sets the minimum level of the records that carry their
+// source location when WithLogSource is enabled.
+func WithLogSourceLevel(level slog.Level) Option {
+	return func(c *factoryConfig) {
+		c.LogSourceLevel = setting[slog.Level]{Value: level, Source: sourceOption}
+	}
+}
+
 // WithSampleRate sets the trace sampling rate.
 func WithSampleRate(rate float64) Option {
 	return func(c *factoryConfig) {
@@ -131,6 +335,7 @@ func WithTraceLogLevel(level slog.Level) Option {
 //   - Performance: Greatly reduces logging overhead in the application's main goroutine.
 //   - Reliability: In case of a sudden application crash or if the buffer fills up
 //     (see OBS_ASYNC_LOG_BUFFER_SIZE), some recent log messages may be lost.
+//     ErrorHandler.Fatal and LogFatal flush the buffer before exiting.
 //
 // Use this option for high-throughput services where performance is critical and
 // the potential loss of a small number of recent logs during a crash is an
@@ -141,25 +346,564 @@ func WithAsynchronousLogging(enabled bool) Option {
 	}
 }
 
+// WithAsyncLogWorkers sets the number of goroutines that write asynchronously
+// logged records.
+func WithAsyncLogWorkers(n int) Option {
+	return func(c *factoryConfig) {
+		c.AsyncLogWorkers = setting[int]{Value: n, Source: sourceOption}
+	}
+}
+
+// WithAsyncLogOrdered keeps each logger's records in order when several async
+// log workers run.
+func WithAsyncLogOrdered(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.AsyncLogOrdered = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithLogHandler replaces the default JSON handler with the one wrap returns;
+// trace correlation is still applied on top of it.
+func WithLogHandler(wrap func(base slog.Handler) slog.Handler) Option {
+	return func(c *factoryConfig) {
+		c.LogHandler = setting[func(slog.Handler) slog.Handler]{Value: wrap, Source: sourceOption}
+	}
+}
+
+// WithLogRoute adds a rule that sends the log records matching route to its sink.
+func WithLogRoute(route LogRoute) Option {
+	return func(c *factoryConfig) {
+		c.LogRoutes = setting[[]LogRoute]{Value: append(c.LogRoutes.Value, route), Source: sourceOption}
+	}
+}
+
+// WithLoggerLevels sets the minimum levels of the loggers returned by Log.Named,
+// by dotted name prefix.
+func WithLoggerLevels(levels map[string]slog.Level) Option {
+	return func(c *factoryConfig) {
+		c.LoggerLevels = setting[map[string]slog.Level]{Value: levels, Source: sourceOption}
+	}
+}
+
+// WithLogMetrics counts log records by level as the log.records counter.
+func WithLogMetrics(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.LogMetrics = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithLogMetricPattern counts the log records whose message matches pattern as
+// log.records.matched, labeled with name.
+func WithLogMetricPattern(name string, pattern *regexp.Regexp) Option {
+	return func(c *factoryConfig) {
+		c.LogMetricPatterns = setting[[]LogMetricPattern]{Value: append(c.LogMetricPatterns.Value, LogMetricPattern{Name: name, Pattern: pattern}), Source: sourceOption}
+		c.LogMetrics = setting[bool]{Value: true, Source: sourceOption}
+	}
+}
+
+// WithBaggageFields adds the W3C baggage members named by keys to log records
+// and spans.
+func WithBaggageFields(keys ...string) Option {
+	return func(c *factoryConfig) {
+		c.BaggageFields = setting[[]string]{Value: keys, Source: sourceOption}
+	}
+}
+
+// WithErrorStackTraces adds the stack trace to records logged at error level or
+// above, as exception.stacktrace.
+func WithErrorStackTraces(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.StackTraces = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithContextFields adds the attributes fields extracts from the context to log
+// records and new spans.
+func WithContextFields(fields func(ctx context.Context) []slog.Attr) Option {
+	return func(c *factoryConfig) {
+		c.ContextFields = setting[[]func(context.Context) []slog.Attr]{Value: append(c.ContextFields.Value, fields), Source: sourceOption}
+	}
+}
+
+// WithLogSchema sets the field names of JSON log records: "default" or "gcp".
+func WithLogSchema(schema string) Option {
+	return func(c *factoryConfig) {
+		c.LogSchema = setting[string]{Value: schema, Source: sourceOption}
+	}
+}
+
+// WithLogOutput sets where log records are written: "stdout", "syslog", or
+// "journald".
+func WithLogOutput(output string) Option {
+	return func(c *factoryConfig) {
+		c.LogOutput = setting[string]{Value: output, Source: sourceOption}
+	}
+}
+
+// WithLogSinks writes log records to several sinks, each with its own level and
+// format, instead of the log output.
+func WithLogSinks(sinks ...LogSink) Option {
+	return func(c *factoryConfig) {
+		c.LogSinks = setting[[]LogSink]{Value: sinks, Source: sourceOption}
+	}
+}
+
+// WithLokiURL also pushes log records to the Grafana Loki server at url.
+func WithLokiURL(url string) Option {
+	return func(c *factoryConfig) {
+		c.LokiURL = setting[string]{Value: url, Source: sourceOption}
+	}
+}
+
+// WithSpanLimits caps the attributes, events, and links of a span. Zero keeps
+// the default, set by OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT,
+// OTEL_SPAN_EVENT_COUNT_LIMIT, or OTEL_SPAN_LINK_COUNT_LIMIT.
+func WithSpanLimits(maxAttributes, maxEvents, maxLinks int) Option {
+	return func(c *factoryConfig) {
+		c.SpanLimits = setting[SpanLimits]{
+			Value:  SpanLimits{MaxAttributes: maxAttributes, MaxEvents: maxEvents, MaxLinks: maxLinks},
+			Source: sourceOption,
+		}
+	}
+}
+
+// WithAttributeValueLimit truncates string attribute values longer than n bytes.
+func WithAttributeValueLimit(n int) Option {
+	return func(c *factoryConfig) {
+		c.AttrValueLimit = setting[int]{Value: n, Source: sourceOption}
+	}
+}
+
+// WithAttributeFilter drops the span attributes that filter does not keep before
+// export.
+func WithAttributeFilter(filter AttributeFilter) Option {
+	return func(c *factoryConfig) {
+		c.AttributeFilter = setting[*AttributeFilter]{Value: &filter, Source: sourceOption}
+	}
+}
+
+// WithSpanFilter drops the finished spans for which keep returns false before
+// export.
+func WithSpanFilter(keep SpanFilter) Option {
+	return func(c *factoryConfig) {
+		c.SpanFilter = setting[SpanFilter]{Value: keep, Source: sourceOption}
+	}
+}
+
+// WithAdaptiveSampling replaces the sample rate with a target of tracesPerMinute
+// sampled traces per minute per operation.
+func WithAdaptiveSampling(tracesPerMinute int) Option {
+	return func(c *factoryConfig) {
+		c.AdaptiveSampling = setting[int]{Value: tracesPerMinute, Source: sourceOption}
+	}
+}
+
+// WithTailSampling decides which traces to export once they end rather than
+// when they start.
+func WithTailSampling(cfg TailSampling) Option {
+	return func(c *factoryConfig) {
+		c.TailSampling = setting[*TailSampling]{Value: &cfg, Source: sourceOption}
+	}
+}
+
+// WithSpanMetrics derives call and duration metrics from every finished span.
+func WithSpanMetrics(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.SpanMetrics = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithSpanCompression merges identical consecutive sibling spans that each took
+// at most maxDuration into one span.
+func WithSpanCompression(maxDuration time.Duration) Option {
+	return func(c *factoryConfig) {
+		c.SpanCompression = setting[time.Duration]{Value: maxDuration, Source: sourceOption}
+	}
+}
+
+// WithIDGenerator sets the generator of new trace and span IDs.
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(c *factoryConfig) {
+		c.IDGenerator = setting[IDGenerator]{Value: gen, Source: sourceOption}
+	}
+}
+
+// WithXRayCompatibility generates X-Ray trace IDs and propagates the
+// X-Amzn-Trace-Id header.
+func WithXRayCompatibility(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.XRay = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithDatadogPropagation propagates Datadog's trace headers as well as W3C trace
+// context.
+func WithDatadogPropagation(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.DDPropagation = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithTraceStateEntry adds key=value to the W3C tracestate of the service's
+// spans.
+func WithTraceStateEntry(key, value string) Option {
+	return func(c *factoryConfig) {
+		c.TraceStateEntry = setting[string]{Value: key + "=" + value, Source: sourceOption}
+	}
+}
+
+// WithBodyCapture makes Middleware record allowlisted JSON body fields on the
+// span; see BodyCapture.
+func WithBodyCapture(capture BodyCapture) Option {
+	return func(c *factoryConfig) {
+		c.BodyCapture = setting[BodyCapture]{Value: capture, Source: sourceOption}
+	}
+}
+
+// WithURLScrubbing sets how request URLs recorded on spans are scrubbed.
+func WithURLScrubbing(scrubbing URLScrubbing) Option {
+	return func(c *factoryConfig) {
+		c.URLScrubbing = setting[URLScrubbing]{Value: scrubbing, Source: sourceOption}
+	}
+}
+
+// WithRoutePattern sets how Middleware finds the route that matched a request,
+// which names the root span.
+func WithRoutePattern(pattern func(r *http.Request) string) Option {
+	return func(c *factoryConfig) {
+		c.RoutePattern = setting[func(*http.Request) string]{Value: pattern, Source: sourceOption}
+	}
+}
+
+// WithSpanNameFormatter sets how the root span of a request is named.
+func WithSpanNameFormatter(format func(r *http.Request) string) Option {
+	return func(c *factoryConfig) {
+		c.SpanNameFormatter = setting[func(*http.Request) string]{Value: format, Source: sourceOption}
+	}
+}
+
+// WithLegacyHTTPAttributes also records the pre-v1.20 HTTP semantic convention
+// attributes.
+func WithLegacyHTTPAttributes(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.LegacyHTTPAttrs = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithIgnoredPaths excludes requests to the given paths or path.Match patterns
+// from HTTP instrumentation.
+func WithIgnoredPaths(paths ...string) Option {
+	return func(c *factoryConfig) {
+		c.IgnoredPaths = setting[[]string]{Value: paths, Source: sourceOption}
+	}
+}
+
+// DefaultTraceIDHeader is the conventional response header for
+// WithTraceIDHeader.
+const DefaultTraceIDHeader = "X-Trace-Id"
+
+// WithTraceIDHeader makes Middleware return the trace ID in the named response
+// header.
+func WithTraceIDHeader(name string) Option {
+	return func(c *factoryConfig) {
+		c.TraceIDHeader = setting[string]{Value: name, Source: sourceOption}
+	}
+}
+
+// DefaultRequestIDHeader is the conventional request and response header for
+// WithRequestIDHeader.
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// WithRequestIDHeader takes request IDs from the named header, or generates them,
+// and returns them in it.
+func WithRequestIDHeader(name string) Option {
+	return func(c *factoryConfig) {
+		c.RequestIDHeader = setting[string]{Value: name, Source: sourceOption}
+	}
+}
+
+// WithAccessLog makes Middleware log one record per request.
+func WithAccessLog(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.AccessLog = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithAccessLogLevel sets the level of access log records.
+func WithAccessLogLevel(level slog.Level) Option {
+	return func(c *factoryConfig) {
+		c.AccessLogLevel = setting[slog.Level]{Value: level, Source: sourceOption}
+	}
+}
+
+// WithSlowRequestThreshold makes Middleware flag, log, and count requests that
+// take longer than d.
+func WithSlowRequestThreshold(d time.Duration) Option {
+	return func(c *factoryConfig) {
+		c.SlowRequest = setting[time.Duration]{Value: d, Source: sourceOption}
+	}
+}
+
+// WithResourceDetection adds host and Kubernetes attributes to traces and
+// metrics.
+func WithResourceDetection(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.ResourceDetection = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithResourceDetectors enables resource detection with the given detectors.
+func WithResourceDetectors(detectors ...ResourceDetector) Option {
+	return func(c *factoryConfig) {
+		c.ResourceDetection = setting[bool]{Value: true, Source: sourceOption}
+		c.ResourceDetectors = setting[[]ResourceDetector]{Value: detectors, Source: sourceOption}
+	}
+}
+
+// WithMetricAttributes adds attrs to the resource of every exported metric.
+func WithMetricAttributes(attrs ...attribute.KeyValue) Option {
+	return func(c *factoryConfig) {
+		c.MetricAttributes = setting[[]attribute.KeyValue]{Value: attrs, Source: sourceOption}
+	}
+}
+
+// WithMetricPrefix prepends prefix to the names of the instruments created
+// through Metrics.
+func WithMetricPrefix(prefix string) Option {
+	return func(c *factoryConfig) {
+		c.MetricPrefix = setting[string]{Value: prefix, Source: sourceOption}
+	}
+}
+
+// WithErrorResponseFormat sets the format of ErrorHandler.HTTP responses: "text"
+// or "problem+json".
+func WithErrorResponseFormat(format string) Option {
+	return func(c *factoryConfig) {
+		c.ErrorFormat = setting[string]{Value: format, Source: sourceOption}
+	}
+}
+
+// WithTraceURLTemplate sets the link to a trace in the trace viewer, with
+// {traceID} and {spanID} placeholders.
+func WithTraceURLTemplate(template string) Option {
+	return func(c *factoryConfig) {
+		c.TraceURLTemplate = setting[string]{Value: template, Source: sourceOption}
+	}
+}
+
+// WithAuditLog sets the destination of audit records.
+func WithAuditLog(w io.Writer) Option {
+	return func(c *factoryConfig) {
+		c.AuditWriter = setting[io.Writer]{Value: w, Source: sourceOption}
+	}
+}
+
+// WithAuditFile appends audit records to the file at path.
+func WithAuditFile(path string) Option {
+	return func(c *factoryConfig) {
+		c.AuditFile = setting[string]{Value: path, Source: sourceOption}
+	}
+}
+
+// WithFatalHandler replaces the os.Exit(1) with which Fatal ends the process.
+func WithFatalHandler(handler func(msg string, args ...any)) Option {
+	return func(c *factoryConfig) {
+		c.FatalHandler = setting[func(string, ...any)]{Value: handler, Source: sourceOption}
+	}
+}
+
+// WithMetricTemporality sets the temporality of exported metrics: "cumulative"
+// or "delta".
+func WithMetricTemporality(temporality string) Option {
+	return func(c *factoryConfig) {
+		c.MetricTemporality = setting[string]{Value: temporality, Source: sourceOption}
+	}
+}
+
+// WithCollectorProbe makes Setup disable the OTLP signals the collector rejects.
+func WithCollectorProbe(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.CollectorProbe = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithExportErrorHandler sets a function called with every failed trace or
+// metrics export error.
+func WithExportErrorHandler(handler func(error)) Option {
+	return func(c *factoryConfig) {
+		c.ExportError = setting[func(error)]{Value: handler, Source: sourceOption}
+	}
+}
+
+// WithSelfTest makes Setup finish with SelfTest.
+func WithSelfTest(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.SelfTest = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithServerlessMode fits the factory to functions that are frozen between
+// invocations, such as AWS Lambda.
+func WithServerlessMode(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.Serverless = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithExpvar publishes the factory's configuration and state as the
+// "observability" expvar.
+func WithExpvar(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.Expvar = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithAdminServer serves AdminHandler, including /debug/pprof/, on addr.
+func WithAdminServer(addr string) Option {
+	return func(c *factoryConfig) {
+		c.AdminAddr = setting[string]{Value: addr, Source: sourceOption}
+	}
+}
+
+// WithGlobalProviders controls whether Setup installs the OpenTelemetry globals.
+func WithGlobalProviders(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.GlobalProviders = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
+// WithSetSlogDefault controls whether Setup installs the logger as the slog
+// default.
+func WithSetSlogDefault(enabled bool) Option {
+	return func(c *factoryConfig) {
+		c.SetSlogDefault = setting[bool]{Value: enabled, Source: sourceOption}
+	}
+}
+
 // Factory is responsible for creating Observability instances.
 type Factory struct {
 	config factoryConfig
+
+	// asyncLogs is the root asynchronous log handler, if async logging is enabled.
+	asyncLogs *asyncHandler
+
+	// logLevel is the minimum level of the default log output. It starts at
+	// the configured level and can be changed at runtime through the
+	// admin endpoints.
+	logLevel *slog.LevelVar
+
+	statusMu sync.Mutex
+	status   map[string]componentStatus
+
+	// stats is the pipeline's self-telemetry: lost log records, spans, and
+	// failed exports.
+	stats pipelineStats
+
+	// spanMetrics derives metrics from finished spans, with WithSpanMetrics.
+	spanMetrics spanMetrics
+
+	// logMetrics counts log records, with WithLogMetrics.
+	logMetrics logMetrics
+
+	// slowRequests counts slow requests, with WithSlowRequestThreshold.
+	slowRequests metric.Int64Counter
+
+	// shutdowner is the composite returned by Setup. It holds the telemetry
+	// components followed by those added with RegisterShutdowner.
+	shutdowner *compositeShutdowner
+
+	// resource holds the build attributes and those found by resource
+	// detection in Setup.
+	resource []attribute.KeyValue
+
+	// build describes the running binary, as recorded by the Go toolchain.
+	build buildInfo
+
+	// faas is set when requests are serverless invocations, whose root spans
+	// report cold starts: with WithServerlessMode, or on a platform that
+	// ServerlessDetector recognizes.
+	faas bool
+
+	// providers are the logger, span factory, and meter provider set up by
+	// Setup, through which every Observability the factory creates reports.
+	providers providers
 }
 
 // NewFactory creates a new observability factory using functional options.
 func NewFactory(opts ...Option) *Factory {
 	config := factoryConfig{
-		ServiceName:      setting[string]{Value: "unknown-service", Source: sourceDefault},
-		ServiceApp:       setting[string]{Value: "unknown-app", Source: sourceDefault},
-		ServiceEnv:       setting[string]{Value: "development", Source: sourceDefault},
-		ApmType:          setting[string]{Value: "none", Source: sourceDefault},
-		MetricsType:      setting[string]{Value: "none", Source: sourceDefault},
-		ApmURL:           setting[string]{Value: "", Source: sourceDefault},
-		LogSource:        setting[bool]{Value: true, Source: sourceDefault},
-		SampleRate:       setting[float64]{Value: 1.0, Source: sourceDefault},
-		LogLevel:         setting[slog.Level]{Value: slog.LevelDebug, Source: sourceDefault},
-		TraceLogLevel:    setting[slog.Level]{Value: slog.LevelInfo, Source: sourceDefault},
-		AsynchronousLogs: setting[bool]{Value: false, Source: sourceDefault},
+		ServiceName:       setting[string]{Value: "unknown-service", Source: sourceDefault},
+		ServiceApp:        setting[string]{Value: "unknown-app", Source: sourceDefault},
+		ServiceEnv:        setting[string]{Value: "development", Source: sourceDefault},
+		ServiceVersion:    setting[string]{Value: "", Source: sourceDefault},
+		ApmType:           setting[string]{Value: "none", Source: sourceDefault},
+		MetricsType:       setting[string]{Value: "none", Source: sourceDefault},
+		ApmURL:            setting[string]{Value: "", Source: sourceDefault},
+		StdoutFormat:      setting[string]{Value: "pretty", Source: sourceDefault},
+		FileRotation:      setting[FileRotation]{Value: FileRotation{MaxBytes: defaultFileMaxBytes, MaxBackups: defaultFileMaxBackups}, Source: sourceDefault},
+		MetricsURL:        setting[string]{Value: "", Source: sourceDefault},
+		ProfilingURL:      setting[string]{Value: "", Source: sourceDefault},
+		LogSource:         setting[bool]{Value: true, Source: sourceDefault},
+		LogSourceLevel:    setting[slog.Level]{Value: slog.LevelDebug, Source: sourceDefault},
+		SampleRate:        setting[float64]{Value: 1.0, Source: sourceDefault},
+		LogLevel:          setting[slog.Level]{Value: slog.LevelDebug, Source: sourceDefault},
+		TraceLogLevel:     setting[slog.Level]{Value: slog.LevelInfo, Source: sourceDefault},
+		AsynchronousLogs:  setting[bool]{Value: false, Source: sourceDefault},
+		AsyncLogWorkers:   setting[int]{Value: 1, Source: sourceDefault},
+		AsyncLogOrdered:   setting[bool]{Value: false, Source: sourceDefault},
+		SpanLimits:        setting[SpanLimits]{Value: SpanLimits{}, Source: sourceDefault},
+		AttrValueLimit:    setting[int]{Value: 0, Source: sourceDefault},
+		AttributeFilter:   setting[*AttributeFilter]{Value: nil, Source: sourceDefault},
+		SpanFilter:        setting[SpanFilter]{Value: nil, Source: sourceDefault},
+		Expvar:            setting[bool]{Value: false, Source: sourceDefault},
+		AdminAddr:         setting[string]{Value: "", Source: sourceDefault},
+		GlobalProviders:   setting[bool]{Value: true, Source: sourceDefault},
+		SetSlogDefault:    setting[bool]{Value: true, Source: sourceDefault},
+		LogHandler:        setting[func(slog.Handler) slog.Handler]{Value: nil, Source: sourceDefault},
+		SpanCompression:   setting[time.Duration]{Value: 0, Source: sourceDefault},
+		AdaptiveSampling:  setting[int]{Value: 0, Source: sourceDefault},
+		TailSampling:      setting[*TailSampling]{Value: nil, Source: sourceDefault},
+		SpanMetrics:       setting[bool]{Value: false, Source: sourceDefault},
+		IDGenerator:       setting[IDGenerator]{Value: nil, Source: sourceDefault},
+		XRay:              setting[bool]{Value: false, Source: sourceDefault},
+		DDPropagation:     setting[bool]{Value: false, Source: sourceDefault},
+		TraceStateEntry:   setting[string]{Value: "", Source: sourceDefault},
+		BodyCapture:       setting[BodyCapture]{Value: BodyCapture{}, Source: sourceDefault},
+		URLScrubbing:      setting[URLScrubbing]{Value: URLScrubbing{}, Source: sourceDefault},
+		RoutePattern:      setting[func(*http.Request) string]{Value: nil, Source: sourceDefault},
+		SpanNameFormatter: setting[func(*http.Request) string]{Value: nil, Source: sourceDefault},
+		LegacyHTTPAttrs:   setting[bool]{Value: false, Source: sourceDefault},
+		IgnoredPaths:      setting[[]string]{Value: nil, Source: sourceDefault},
+		TraceIDHeader:     setting[string]{Value: "", Source: sourceDefault},
+		RequestIDHeader:   setting[string]{Value: "", Source: sourceDefault},
+		AccessLog:         setting[bool]{Value: false, Source: sourceDefault},
+		AccessLogLevel:    setting[slog.Level]{Value: slog.LevelInfo, Source: sourceDefault},
+		SlowRequest:       setting[time.Duration]{Value: 0, Source: sourceDefault},
+		LogRoutes:         setting[[]LogRoute]{Value: nil, Source: sourceDefault},
+		LoggerLevels:      setting[map[string]slog.Level]{Value: nil, Source: sourceDefault},
+		LogMetrics:        setting[bool]{Value: false, Source: sourceDefault},
+		LogMetricPatterns: setting[[]LogMetricPattern]{Value: nil, Source: sourceDefault},
+		StackTraces:       setting[bool]{Value: false, Source: sourceDefault},
+		ContextFields:     setting[[]func(context.Context) []slog.Attr]{Value: nil, Source: sourceDefault},
+		BaggageFields:     setting[[]string]{Value: nil, Source: sourceDefault},
+		LogSchema:         setting[string]{Value: logSchemaDefault, Source: sourceDefault},
+		LogOutput:         setting[string]{Value: logOutputStdout, Source: sourceDefault},
+		LogSinks:          setting[[]LogSink]{Value: nil, Source: sourceDefault},
+		LokiURL:           setting[string]{Value: "", Source: sourceDefault},
+		ResourceDetection: setting[bool]{Value: false, Source: sourceDefault},
+		ResourceDetectors: setting[[]ResourceDetector]{Value: nil, Source: sourceDefault},
+		MetricAttributes:  setting[[]attribute.KeyValue]{Value: nil, Source: sourceDefault},
+		MetricPrefix:      setting[string]{Value: "", Source: sourceDefault},
+		ErrorFormat:       setting[string]{Value: errorFormatText, Source: sourceDefault},
+		FatalHandler:      setting[func(string, ...any)]{Value: nil, Source: sourceDefault},
+		TraceURLTemplate:  setting[string]{Value: "", Source: sourceDefault},
+		AuditWriter:       setting[io.Writer]{Value: nil, Source: sourceDefault},
+		AuditFile:         setting[string]{Value: "", Source: sourceDefault},
+		MetricTemporality: setting[string]{Value: "cumulative", Source: sourceDefault},
+		CollectorProbe:    setting[bool]{Value: false, Source: sourceDefault},
+		ExportError:       setting[func(error)]{Value: nil, Source: sourceDefault},
+		SelfTest:          setting[bool]{Value: false, Source: sourceDefault},
+		Serverless:        setting[bool]{Value: false, Source: sourceDefault},
 	}
 
 	for _, opt := range opts {
@@ -176,6 +920,9 @@ func NewFactory(opts ...Option) *Factory {
 	if val := os.Getenv("OBS_ENVIRONMENT"); val != "" && config.ServiceEnv.Source == sourceDefault {
 		config.ServiceEnv = setting[string]{Value: val, Source: sourceEnv}
 	}
+	if val := os.Getenv("OBS_SERVICE_VERSION"); val != "" && config.ServiceVersion.Source == sourceDefault {
+		config.ServiceVersion = setting[string]{Value: val, Source: sourceEnv}
+	}
 	if val := os.Getenv("OBS_APM_TYPE"); val != "" && config.ApmType.Source == sourceDefault {
 		config.ApmType = setting[string]{Value: val, Source: sourceEnv}
 	}
@@ -185,11 +932,23 @@ func NewFactory(opts ...Option) *Factory {
 	if val := os.Getenv("OBS_APM_URL"); val != "" && config.ApmURL.Source == sourceDefault {
 		config.ApmURL = setting[string]{Value: val, Source: sourceEnv}
 	}
+	if val := os.Getenv("OBS_STDOUT_TRACE_FORMAT"); val != "" && config.StdoutFormat.Source == sourceDefault {
+		config.StdoutFormat = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_METRICS_URL"); val != "" && config.MetricsURL.Source == sourceDefault {
+		config.MetricsURL = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_PROFILING_URL"); val != "" && config.ProfilingURL.Source == sourceDefault {
+		config.ProfilingURL = setting[string]{Value: val, Source: sourceEnv}
+	}
 	if val := os.Getenv("OBS_LOG_SOURCE"); val != "" && config.LogSource.Source == sourceDefault {
 		if b, err := strconv.ParseBool(val); err == nil {
 			config.LogSource = setting[bool]{Value: b, Source: sourceEnv}
 		}
 	}
+	if val := os.Getenv("OBS_LOG_SOURCE_LEVEL"); val != "" && config.LogSourceLevel.Source == sourceDefault {
+		config.LogSourceLevel = setting[slog.Level]{Value: parseLogLevel(val), Source: sourceEnv}
+	}
 	if val := os.Getenv("OBS_SAMPLE_RATE"); val != "" && config.SampleRate.Source == sourceDefault {
 		if f, err := strconv.ParseFloat(val, 64); err == nil {
 			config.SampleRate = setting[float64]{Value: f, Source: sourceEnv}
@@ -206,56 +965,381 @@ func NewFactory(opts ...Option) *Factory {
 			config.AsynchronousLogs = setting[bool]{Value: b, Source: sourceEnv}
 		}
 	}
+	if val := os.Getenv("OBS_ATTRIBUTE_VALUE_LIMIT"); val != "" && config.AttrValueLimit.Source == sourceDefault {
+		if n, err := strconv.Atoi(val); err == nil {
+			config.AttrValueLimit = setting[int]{Value: n, Source: sourceEnv}
+		}
+	}
+	if allow, deny := os.Getenv("OBS_ATTRIBUTE_ALLOW"), os.Getenv("OBS_ATTRIBUTE_DENY"); (allow != "" || deny != "") && config.AttributeFilter.Source == sourceDefault {
+		filter := AttributeFilter{Allow: parseAttributePatterns(allow), Deny: parseAttributePatterns(deny)}
+		config.AttributeFilter = setting[*AttributeFilter]{Value: &filter, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_ASYNC_LOG_WORKERS"); val != "" && config.AsyncLogWorkers.Source == sourceDefault {
+		if n, err := strconv.Atoi(val); err == nil {
+			config.AsyncLogWorkers = setting[int]{Value: n, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_ASYNC_LOG_ORDERED"); val != "" && config.AsyncLogOrdered.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.AsyncLogOrdered = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_LOGGER_LEVELS"); val != "" && config.LoggerLevels.Source == sourceDefault {
+		config.LoggerLevels = setting[map[string]slog.Level]{Value: parseLoggerLevels(val), Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_BAGGAGE_FIELDS"); val != "" && config.BaggageFields.Source == sourceDefault {
+		config.BaggageFields = setting[[]string]{Value: parseBaggageFields(val), Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_ERROR_STACK_TRACES"); val != "" && config.StackTraces.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.StackTraces = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_LOG_SCHEMA"); val != "" && config.LogSchema.Source == sourceDefault {
+		config.LogSchema = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_LOG_OUTPUT"); val != "" && config.LogOutput.Source == sourceDefault {
+		config.LogOutput = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_LOKI_URL"); val != "" && config.LokiURL.Source == sourceDefault {
+		config.LokiURL = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_SPAN_COMPRESSION"); val != "" && config.SpanCompression.Source == sourceDefault {
+		if d, err := time.ParseDuration(val); err == nil {
+			config.SpanCompression = setting[time.Duration]{Value: d, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_ADAPTIVE_SAMPLING"); val != "" && config.AdaptiveSampling.Source == sourceDefault {
+		if n, err := strconv.Atoi(val); err == nil {
+			config.AdaptiveSampling = setting[int]{Value: n, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_TAIL_SAMPLING_LATENCY"); val != "" && config.TailSampling.Source == sourceDefault {
+		if d, err := time.ParseDuration(val); err == nil {
+			config.TailSampling = setting[*TailSampling]{Value: &TailSampling{Latency: d}, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_SPAN_METRICS"); val != "" && config.SpanMetrics.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.SpanMetrics = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_LOG_METRICS"); val != "" && config.LogMetrics.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.LogMetrics = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_XRAY_COMPATIBILITY"); val != "" && config.XRay.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.XRay = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_TRACESTATE_ENTRY"); val != "" && config.TraceStateEntry.Source == sourceDefault {
+		config.TraceStateEntry = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_DATADOG_PROPAGATION"); val != "" && config.DDPropagation.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.DDPropagation = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_EXPVAR"); val != "" && config.Expvar.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.Expvar = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_ADMIN_ADDR"); val != "" && config.AdminAddr.Source == sourceDefault {
+		config.AdminAddr = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_SERVERLESS_MODE"); val != "" && config.Serverless.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.Serverless = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_SELF_TEST"); val != "" && config.SelfTest.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.SelfTest = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_RESOURCE_DETECTION"); val != "" && config.ResourceDetection.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.ResourceDetection = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_GLOBAL_PROVIDERS"); val != "" && config.GlobalProviders.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.GlobalProviders = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_SET_SLOG_DEFAULT"); val != "" && config.SetSlogDefault.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.SetSlogDefault = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_COLLECTOR_PROBE"); val != "" && config.CollectorProbe.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.CollectorProbe = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_TRACE_ID_HEADER"); val != "" && config.TraceIDHeader.Source == sourceDefault {
+		config.TraceIDHeader = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_REQUEST_ID_HEADER"); val != "" && config.RequestIDHeader.Source == sourceDefault {
+		config.RequestIDHeader = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_ACCESS_LOG"); val != "" && config.AccessLog.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.AccessLog = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_ACCESS_LOG_LEVEL"); val != "" && config.AccessLogLevel.Source == sourceDefault {
+		config.AccessLogLevel = setting[slog.Level]{Value: parseLogLevel(val), Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_SLOW_REQUEST_THRESHOLD"); val != "" && config.SlowRequest.Source == sourceDefault {
+		if d, err := time.ParseDuration(val); err == nil {
+			config.SlowRequest = setting[time.Duration]{Value: d, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_LEGACY_HTTP_ATTRIBUTES"); val != "" && config.LegacyHTTPAttrs.Source == sourceDefault {
+		if b, err := strconv.ParseBool(val); err == nil {
+			config.LegacyHTTPAttrs = setting[bool]{Value: b, Source: sourceEnv}
+		}
+	}
+	if val := os.Getenv("OBS_IGNORED_PATHS"); val != "" && config.IgnoredPaths.Source == sourceDefault {
+		config.IgnoredPaths = setting[[]string]{Value: parseIgnoredPaths(val), Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_METRIC_TEMPORALITY"); val != "" && config.MetricTemporality.Source == sourceDefault {
+		config.MetricTemporality = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_METRIC_ATTRIBUTES"); val != "" && config.MetricAttributes.Source == sourceDefault {
+		config.MetricAttributes = setting[[]attribute.KeyValue]{Value: parseMetricAttributes(val), Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_METRIC_PREFIX"); val != "" && config.MetricPrefix.Source == sourceDefault {
+		config.MetricPrefix = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_ERROR_RESPONSE_FORMAT"); val != "" && config.ErrorFormat.Source == sourceDefault {
+		config.ErrorFormat = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_TRACE_URL_TEMPLATE"); val != "" && config.TraceURLTemplate.Source == sourceDefault {
+		config.TraceURLTemplate = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_AUDIT_FILE"); val != "" && config.AuditFile.Source == sourceDefault {
+		config.AuditFile = setting[string]{Value: val, Source: sourceEnv}
+	}
+	if val := os.Getenv("OBS_RESOURCE_DETECTORS"); val != "" && config.ResourceDetectors.Source == sourceDefault {
+		config.ResourceDetectors = setting[[]ResourceDetector]{Value: parseResourceDetectors(val), Source: sourceEnv}
+		if config.ResourceDetection.Source == sourceDefault {
+			config.ResourceDetection = setting[bool]{Value: true, Source: sourceEnv}
+		}
+	}
+
+	// Fall back to the version the Go toolchain stamped into the binary.
+	build := readBuildInfo()
+	if config.ServiceVersion.Source == sourceDefault {
+		if v := build.serviceVersion(); v != "" {
+			config.ServiceVersion = setting[string]{Value: v, Source: sourceBuildInfo}
+		}
+	}
 
-	return &Factory{config: config}
+	p := defaultProviders(normalizeAPMType(config.ApmType.Value))
+	p.metricPrefix = config.MetricPrefix.Value
+	p.errorFormat = config.ErrorFormat.Value
+	p.fatal = config.FatalHandler.Value
+	p.traceURLTemplate = config.TraceURLTemplate.Value
+	p.loggerLevels = config.LoggerLevels.Value
+	p.audit = newAuditLog(os.Stdout)
+	if config.AuditWriter.Value != nil {
+		p.audit = newAuditLog(config.AuditWriter.Value)
+	}
+	logLevel := new(slog.LevelVar)
+	logLevel.Set(config.LogLevel.Value)
+	f := &Factory{
+		config:     config,
+		logLevel:   logLevel,
+		status:     make(map[string]componentStatus),
+		shutdowner: &compositeShutdowner{},
+		build:      build,
+		providers:  p,
+		faas:       config.Serverless.Value || detectServerless() != nil,
+	}
+	f.stats.onExportError = f.reportExportError
+	f.providers.contextFields = f.contextFields()
+	return f
 }
 
 // logSettings logs the final configuration values and their sources.
 func (f *Factory) logSettings() {
-	slog.Info("Observability settings initialized",
-		slog.Group("settings",
-			slog.String("service_name", fmt.Sprintf("%s (source: %s)", f.config.ServiceName.Value, f.config.ServiceName.Source)),
-			slog.String("service_app", fmt.Sprintf("%s (source: %s)", f.config.ServiceApp.Value, f.config.ServiceApp.Source)),
-			slog.String("service_env", fmt.Sprintf("%s (source: %s)", f.config.ServiceEnv.Value, f.config.ServiceEnv.Source)),
-			slog.String("apm_type", fmt.Sprintf("%s (source: %s)", f.config.ApmType.Value, f.config.ApmType.Source)),
-			slog.String("metrics_type", fmt.Sprintf("%s (source: %s)", f.config.MetricsType.Value, f.config.MetricsType.Source)),
-			slog.String("apm_url", fmt.Sprintf("%s (source: %s)", f.config.ApmURL.Value, f.config.ApmURL.Source)),
-			slog.String("log_source", fmt.Sprintf("%t (source: %s)", f.config.LogSource.Value, f.config.LogSource.Source)),
-			slog.String("sample_rate", fmt.Sprintf("%f (source: %s)", f.config.SampleRate.Value, f.config.SampleRate.Source)),
-			slog.String("log_level", fmt.Sprintf("%s (source: %s)", f.config.LogLevel.Value, f.config.LogLevel.Source)),
-			slog.String("trace_log_level", fmt.Sprintf("%s (source: %s)", f.config.TraceLogLevel.Value, f.config.TraceLogLevel.Source)),
-			slog.String("async_logs", fmt.Sprintf("%t (source: %s)", f.config.AsynchronousLogs.Value, f.config.AsynchronousLogs.Source)),
-		),
-	)
+	entries := f.config.entries()
+	attrs := make([]any, 0, len(entries))
+	for _, e := range entries {
+		attrs = append(attrs, slog.String(e.Name, fmt.Sprintf("%v (source: %s)", e.Value, e.Source)))
+	}
+	f.providers.logger.Info("Observability settings initialized", slog.Group("settings", attrs...))
 }
 
 // Setup initializes all observability components.
 func (f *Factory) Setup(ctx context.Context) (Shutdowner, error) {
 	var shutdowners []Shutdowner
 
-	logShutdowner := f.setupLogging()
-	shutdowners = append(shutdowners, logShutdowner)
+	logShutdowner, err := f.setupLogging(ctx)
+	if err != nil {
+		f.setStatus("logging", logType(f.config.AsynchronousLogs.Value), statusFailed, err)
+		return nil, fmt.Errorf("failed to setup logging: %w", err)
+	}
+	shutdowners = append(shutdowners, f.track("logging", logShutdowner))
+	f.setStatus("logging", logType(f.config.AsynchronousLogs.Value), statusRunning, nil)
 
 	// Log settings after logger is initialized
 	f.logSettings()
 
-	traceShutdowner, err := f.setupTracing(ctx)
-	if err != nil {
-		(&compositeShutdowner{shutdowners: shutdowners}).Shutdown(ctx)
-		return nil, fmt.Errorf("failed to setup tracing: %w", err)
+	if f.config.AuditWriter.Value == nil && f.config.AuditFile.Value != "" {
+		audit, err := f.setupAudit()
+		if err != nil {
+			f.setStatus("audit", "file", statusFailed, err)
+			(&compositeShutdowner{shutdowners: shutdowners}).Shutdown(ctx)
+			return nil, fmt.Errorf("failed to setup audit log: %w", err)
+		}
+		shutdowners = append(shutdowners, f.track("audit", audit))
+		f.setStatus("audit", "file", statusRunning, nil)
+	}
+
+	if f.config.GlobalProviders.Value {
+		otel.SetErrorHandler(otel.ErrorHandlerFunc(f.handleOTelError))
+	}
+
+	if f.config.Expvar.Value {
+		publishExpvar(f)
+	}
+
+	if addr := f.config.AdminAddr.Value; addr != "" {
+		admin, err := f.startAdminServer(addr)
+		if err != nil {
+			f.setStatus("admin", "http", statusFailed, err)
+			(&compositeShutdowner{shutdowners: shutdowners}).Shutdown(ctx)
+			return nil, fmt.Errorf("failed to setup admin server: %w", err)
+		}
+		shutdowners = append(shutdowners, f.track("admin", admin))
+		f.setStatus("admin", "http", statusRunning, nil)
+	}
+
+	f.resource = f.build.attributes()
+	if f.config.ResourceDetection.Value {
+		detectors := f.config.ResourceDetectors.Value
+		if detectors == nil {
+			detectors = defaultResourceDetectors()
+		}
+		f.resource = append(f.resource, detectResource(ctx, f.providers.logger, detectors)...)
 	}
-	shutdowners = append(shutdowners, traceShutdowner)
 
-	if normalizeMetricsType(f.config.MetricsType.Value) == OTLPMetrics {
+	// Signals the collector reports it does not accept are disabled
+	// rather than failing on every export.
+	var caps collectorCapabilities
+	if f.config.CollectorProbe.Value {
+		caps = f.probeCollector(ctx)
+	}
+
+	apmType := string(normalizeAPMType(f.config.ApmType.Value))
+	if caps.Traces.unsupported() {
+		f.setStatus("tracing", apmType, statusDisabled, fmt.Errorf("collector does not accept traces: %s", caps.Traces.Detail))
+	} else {
+		traceShutdowner, err := f.setupTracing(ctx)
+		if err != nil {
+			f.setStatus("tracing", apmType, statusFailed, err)
+			(&compositeShutdowner{shutdowners: shutdowners}).Shutdown(ctx)
+			return nil, fmt.Errorf("failed to setup tracing: %w", err)
+		}
+		shutdowners = append(shutdowners, f.track("tracing", traceShutdowner))
+		f.setStatus("tracing", apmType, statusRunning, nil)
+	}
+
+	metricsType := string(normalizeMetricsType(f.config.MetricsType.Value))
+	if caps.Metrics.unsupported() {
+		f.setStatus("metrics", metricsType, statusDisabled, fmt.Errorf("collector does not accept metrics: %s", caps.Metrics.Detail))
+	} else if normalizeMetricsType(f.config.MetricsType.Value) != NoneMetrics {
 		metricsShutdowner, err := f.setupMetrics(ctx)
 		if err != nil {
+			f.setStatus("metrics", metricsType, statusFailed, err)
 			(&compositeShutdowner{shutdowners: shutdowners}).Shutdown(ctx)
 			return nil, fmt.Errorf("failed to setup metrics: %w", err)
 		}
-		shutdowners = append(shutdowners, metricsShutdowner)
+		shutdowners = append(shutdowners, f.track("metrics", metricsShutdowner))
+		f.setStatus("metrics", metricsType, statusRunning, nil)
+	} else {
+		f.setStatus("metrics", metricsType, statusDisabled, nil)
+	}
+
+	if f.config.ProfilingURL.Value != "" {
+		p, err := startProfiler(profilingConfig{
+			ServiceName:    f.config.ServiceName.Value,
+			ServiceApp:     f.config.ServiceApp.Value,
+			ServiceEnv:     f.config.ServiceEnv.Value,
+			ServiceVersion: f.config.ServiceVersion.Value,
+			URL:            f.config.ProfilingURL.Value,
+		}, f.providers.logger)
+		if err != nil {
+			f.setStatus("profiling", "pyroscope", statusFailed, err)
+			(&compositeShutdowner{shutdowners: shutdowners}).Shutdown(ctx)
+			return nil, fmt.Errorf("failed to setup profiling: %w", err)
+		}
+		shutdowners = append(shutdowners, f.track("profiling", p))
+		f.setStatus("profiling", "pyroscope", statusRunning, nil)
+	}
+
+	// Telemetry goes first so that it shuts down last, after the
+	// application components registered with RegisterShutdowner.
+	f.shutdowner.prepend(shutdowners...)
+	registerPipeline(f.shutdowner)
+
+	if f.config.SelfTest.Value {
+		f.runSelfTest(ctx)
+	}
+	return f.shutdowner, nil
+}
+
+// RegisterShutdowner adds an application component, such as a database pool
+// or a queue consumer, to the Shutdowner returned by Setup, so one call shuts
+// down the whole service. Registered components are shut down in reverse
+// order of registration, all before the telemetry pipeline, so their final
+// logs and spans are still exported. They share the shutdown context and
+// deadline, their errors are joined into the returned error, and their
+// status is reported under name alongside logging, tracing, and metrics.
+// RegisterShutdowner may be called before or after Setup, but not once
+// shutdown has begun.
+func (f *Factory) RegisterShutdowner(name string, s Shutdowner) {
+	f.setStatus(name, "custom", statusRunning, nil)
+	f.shutdowner.add(f.track(name, s))
+}
+
+// ShutdownWith stops server and then the telemetry pipeline in the order that
+// preserves the final requests' telemetry: stop accepting connections and
+// wait for in-flight requests to finish, flush buffered spans, metrics, and
+// logs, and only then close the exporters and the log pipeline. It is safe to
+// also defer the Shutdowner returned by Setup; the pipeline shuts down once.
+func (f *Factory) ShutdownWith(ctx context.Context, server *http.Server) error {
+	var errs []error
+	if server != nil {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to shutdown HTTP server: %w", err))
+		}
+	}
+	if err := f.shutdowner.ForceFlush(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to flush telemetry: %w", err))
 	}
+	if err := f.shutdowner.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
 
-	return &compositeShutdowner{shutdowners: shutdowners}, nil
+// Flush writes the log records queued by asynchronous logging, and exports
+// the buffered spans and metrics, without shutting anything down, for
+// example before a serverless function freezes or at the end of a batch
+// job's step. It returns when everything buffered before the call has been
+// handed to its backend, or when ctx is done.
+func (f *Factory) Flush(ctx context.Context) error {
+	if err := f.shutdowner.ForceFlush(ctx); err != nil {
+		return fmt.Errorf("failed to flush telemetry: %w", err)
+	}
+	return nil
 }
 
 // SetupOrExit is a convenience wrapper around Setup.
@@ -267,42 +1351,275 @@ func (f *Factory) SetupOrExit(fatalMsg string) Shutdowner {
 	return shutdowner
 }
 
-func (f *Factory) setupLogging() Shutdowner {
-	_, shutdowner := initLogger(normalizeAPMType(f.config.ApmType.Value), f.config.LogSource.Value, f.config.LogLevel.Value, f.config.TraceLogLevel.Value, f.config.AsynchronousLogs.Value)
-	return shutdowner
+func (f *Factory) setupLogging(ctx context.Context) (Shutdowner, error) {
+	var gcp *gcpLogSchema
+	if strings.EqualFold(f.config.LogSchema.Value, logSchemaGCP) {
+		gcp = newGCPLogSchema(ctx)
+	}
+	outputs := &compositeShutdowner{}
+	sinks := slices.Clone(f.config.LogSinks.Value)
+	for i, s := range sinks {
+		if s.Handler == nil && s.Writer == nil && s.Path == "" {
+			outputs.Shutdown(ctx)
+			return nil, fmt.Errorf("log sink %s has no writer, path, or handler", s.Name)
+		}
+		if s.Handler != nil || s.Writer != nil {
+			continue
+		}
+		file, err := openRotatingFile(s.Path, s.Rotation)
+		if err != nil {
+			outputs.Shutdown(ctx)
+			return nil, fmt.Errorf("failed to open log sink %s: %w", s.Name, err)
+		}
+		sinks[i].Writer = file
+		outputs.add(&logFile{file})
+	}
+	var sink *sinkWriter
+	if output := f.config.LogOutput.Value; len(sinks) == 0 && !strings.EqualFold(output, logOutputStdout) {
+		s, err := openLogSink(output, f.config.ServiceName.Value)
+		if err != nil {
+			return nil, err
+		}
+		sink = &sinkWriter{sink: s}
+	}
+	var loki *lokiPusher
+	if u := f.config.LokiURL.Value; u != "" {
+		p, err := startLokiPusher(u, f.config.ServiceName.Value, f.config.ServiceApp.Value, f.config.ServiceEnv.Value, &f.stats)
+		if err != nil {
+			if sink != nil {
+				sink.Shutdown(ctx)
+			}
+			outputs.Shutdown(ctx)
+			return nil, err
+		}
+		loki = p
+	}
+	logger, shutdowner := initLogger(logConfig{
+		apmType:          normalizeAPMType(f.config.ApmType.Value),
+		level:            f.logLevel,
+		addSource:        f.config.LogSource.Value,
+		sourceLevel:      f.config.LogSourceLevel.Value,
+		traceLevel:       f.config.TraceLogLevel.Value,
+		async:            f.config.AsynchronousLogs.Value && !f.config.Serverless.Value,
+		asyncWorkers:     f.config.AsyncLogWorkers.Value,
+		asyncOrdered:     f.config.AsyncLogOrdered.Value,
+		wrap:             f.config.LogHandler.Value,
+		routes:           f.config.LogRoutes.Value,
+		stackTraces:      f.config.StackTraces.Value,
+		contextFields:    f.providers.contextFields,
+		traceURLTemplate: f.config.TraceURLTemplate.Value,
+		gcp:              gcp,
+		sink:             sink,
+		sinks:            sinks,
+		loki:             loki,
+		metrics:          f.loggingMetrics(),
+		setDefault:       f.config.SetSlogDefault.Value,
+		stats:            &f.stats,
+	})
+	f.providers.logger = logger
+	if h, ok := shutdowner.(*asyncHandler); ok {
+		f.asyncLogs = h
+	}
+	if sink == nil && loki == nil && len(outputs.shutdowners) == 0 {
+		return shutdowner, nil
+	}
+	// The outputs are closed after the queued records are written.
+	if loki != nil {
+		outputs.add(loki)
+	}
+	if sink != nil {
+		outputs.add(sink)
+	}
+	outputs.add(shutdowner)
+	return outputs, nil
+}
+
+// setupAudit opens the audit file and makes it the destination of audit
+// records.
+func (f *Factory) setupAudit() (Shutdowner, error) {
+	file, err := openAuditFile(f.config.AuditFile.Value)
+	if err != nil {
+		return nil, err
+	}
+	f.providers.audit.setWriter(file)
+	return file, nil
 }
 
 func (f *Factory) setupTracing(ctx context.Context) (Shutdowner, error) {
-	return setupTracing(ctx, f.config.ServiceName.Value, f.config.ServiceApp.Value, f.config.ServiceEnv.Value, f.config.ApmURL.Value, f.config.ApmType.Value, f.config.SampleRate.Value)
+	shutdowner, spans, err := setupTracing(ctx, f.config.ApmType.Value, TracingConfig{
+		ServiceName:        f.config.ServiceName.Value,
+		ServiceApp:         f.config.ServiceApp.Value,
+		ServiceEnv:         f.config.ServiceEnv.Value,
+		ServiceVersion:     f.config.ServiceVersion.Value,
+		ApmURL:             f.config.ApmURL.Value,
+		SampleRate:         f.config.SampleRate.Value,
+		SpanLimits:         f.config.SpanLimits.Value,
+		AttrValueLimit:     f.config.AttrValueLimit.Value,
+		AttributeFilter:    f.config.AttributeFilter.Value,
+		SpanFilter:         f.config.SpanFilter.Value,
+		SpanCompression:    f.config.SpanCompression.Value,
+		AdaptiveSampling:   f.config.AdaptiveSampling.Value,
+		TailSampling:       f.config.TailSampling.Value,
+		spanMetrics:        f.tracingSpanMetrics(),
+		IDGenerator:        f.config.IDGenerator.Value,
+		XRay:               f.config.XRay.Value,
+		DatadogPropagation: f.config.DDPropagation.Value,
+		TraceStateEntry:    f.config.TraceStateEntry.Value,
+		StdoutFormat:       f.config.StdoutFormat.Value,
+		FileRotation:       f.config.FileRotation.Value,
+		ResourceAttributes: f.resource,
+		Global:             f.config.GlobalProviders.Value,
+		Profiling:          f.config.ProfilingURL.Value != "",
+		stats:              &f.stats,
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.providers.spans = spans
+	return shutdowner, nil
 }
 
+// loggingMetrics returns the log metrics the logger counts records in, or
+// nil if log metrics are disabled.
+func (f *Factory) loggingMetrics() *logMetrics {
+	if !f.config.LogMetrics.Value {
+		return nil
+	}
+	f.logMetrics.patterns = f.config.LogMetricPatterns.Value
+	return &f.logMetrics
+}
+
+// tracingSpanMetrics returns the span metrics the tracer records finished
+// spans in, or nil if span metrics are disabled.
+func (f *Factory) tracingSpanMetrics() *spanMetrics {
+	if !f.config.SpanMetrics.Value {
+		return nil
+	}
+	return &f.spanMetrics
+}
+
+// setupMetrics installs the configured metrics backend and then starts the
+// runtime metrics collector, which reports through that backend.
 func (f *Factory) setupMetrics(ctx context.Context) (Shutdowner, error) {
-	return setupMetrics(ctx)
+	mp, providerShutdowner, err := setupMetricsProvider(ctx, f.config.MetricsType.Value, MetricsConfig{
+		ServiceName:        f.config.ServiceName.Value,
+		ServiceApp:         f.config.ServiceApp.Value,
+		ServiceEnv:         f.config.ServiceEnv.Value,
+		ServiceVersion:     f.config.ServiceVersion.Value,
+		URL:                f.metricsURL(),
+		Temporality:        f.config.MetricTemporality.Value,
+		ResourceAttributes: append(slices.Clip(f.resource), f.config.MetricAttributes.Value...),
+		stats:              &f.stats,
+	}, f.config.GlobalProviders.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := registerBuildInfo(mp.Meter("go-observability"), f.config.ServiceVersion.Value, f.build); err != nil {
+		providerShutdowner.Shutdown(ctx)
+		return nil, fmt.Errorf("failed to register build info metric: %w", err)
+	}
+	if err := f.registerPipelineMetrics(mp.Meter("go-observability")); err != nil {
+		providerShutdowner.Shutdown(ctx)
+		return nil, fmt.Errorf("failed to register pipeline metrics: %w", err)
+	}
+
+	if f.config.SpanMetrics.Value {
+		if err := f.spanMetrics.register(mp.Meter("go-observability")); err != nil {
+			providerShutdowner.Shutdown(ctx)
+			return nil, fmt.Errorf("failed to register span metrics: %w", err)
+		}
+	}
+	if f.config.LogMetrics.Value {
+		if err := f.logMetrics.register(mp.Meter("go-observability")); err != nil {
+			providerShutdowner.Shutdown(ctx)
+			return nil, fmt.Errorf("failed to register log metrics: %w", err)
+		}
+	}
+	if f.config.SlowRequest.Value > 0 {
+		counter, err := newSlowRequestCounter(mp.Meter("go-observability"))
+		if err != nil {
+			providerShutdowner.Shutdown(ctx)
+			return nil, fmt.Errorf("failed to register slow request metric: %w", err)
+		}
+		f.slowRequests = counter
+	}
+
+	runtimeShutdowner, err := setupMetrics(ctx, mp)
+	if err != nil {
+		providerShutdowner.Shutdown(ctx)
+		return nil, err
+	}
+	f.providers.meters = mp
+	f.providers.instruments = &instrumentCache{}
+
+	// Runtime metrics stop before the backend flushes and closes.
+	return &compositeShutdowner{shutdowners: []Shutdowner{providerShutdowner, runtimeShutdowner}}, nil
+}
+
+// metricsURL returns the endpoint metrics are exported to. DogStatsD does
+// not fall back to the APM URL, which is the address of the Agent's trace
+// intake rather than its DogStatsD listener.
+func (f *Factory) metricsURL() string {
+	if url := f.config.MetricsURL.Value; url != "" {
+		return url
+	}
+	if normalizeMetricsType(f.config.MetricsType.Value) == DogStatsDMetrics {
+		return ""
+	}
+	return f.config.ApmURL.Value
+}
+
+// Logger returns the factory's logger: JSON output with trace correlation,
+// routing, and async delivery as configured. Before Setup, it returns the
+// slog default.
+func (f *Factory) Logger() *slog.Logger {
+	return f.providers.logger
+}
+
+// Handler returns the handler behind Logger, for applications that build
+// their own slog.Logger or combine it with other handlers.
+func (f *Factory) Handler() slog.Handler {
+	return f.providers.logger.Handler()
 }
 
 // NewBackgroundObservability creates an Observability instance with a background context.
 func (f *Factory) NewBackgroundObservability(ctx context.Context) *Observability {
-	return NewObservability(ctx, f.config.ServiceName.Value, f.config.ApmType.Value, f.config.LogSource.Value, f.config.LogLevel.Value, f.config.TraceLogLevel.Value, f.config.MetricsType.Value == "otlp")
+	return f.newObservability(ctx)
 }
 
-// StartSpanFromRequest instruments an incoming HTTP request.
+// newObservability creates an Observability instance reporting through the
+// factory's providers.
+func (f *Factory) newObservability(ctx context.Context) *Observability {
+	return newObservability(ctx, f.config.ServiceName.Value, normalizeAPMType(f.config.ApmType.Value), f.providers)
+}
+
+// StartSpanFromRequest instruments an incoming HTTP request: it continues
+// the trace of the caller, if any, with a server span that carries the
+// request's attributes as the HTTP semantic conventions name them
+// (http.request.method, url.path, server.address, user_agent.original,
+// network.peer.address, ...); see WithLegacyHTTPAttributes for the keys
+// used before them.
 func (f *Factory) StartSpanFromRequest(r *http.Request, customAttrs ...SpanAttributes) (*http.Request, context.Context, Span, *Observability) {
-	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
-	obs := NewObservability(ctx, f.config.ServiceName.Value, f.config.ApmType.Value, f.config.LogSource.Value, f.config.LogLevel.Value, f.config.TraceLogLevel.Value, f.config.MetricsType.Value == "otlp")
+	ctx := f.providers.spans.Extract(r.Context(), r.Header)
+	if f.ignored(r.URL.Path) {
+		obs := f.newObservability(ctx)
+		ctx = ctxWithObs(ctx, obs)
+		return r.WithContext(ctx), ctx, &noOpSpan{}, obs
+	}
+	if header := f.config.RequestIDHeader.Value; header != "" {
+		ctx = WithRequestID(ctx, requestID(r.Header.Get(header)))
+	}
+	obs := f.newObservability(ctx)
 
-	ctx, obs, span := obs.StartSpanWith(r.URL.Path,
-		attribute.String("http.method", r.Method),
-		attribute.String("http.url", r.URL.String()),
-		attribute.String("http.target", r.URL.RequestURI()),
-		attribute.String("http.host", r.Host),
-		attribute.String("http.scheme", r.URL.Scheme),
-	)
+	ctx, obs, span := obs.startServerSpan(f.spanName(r), f.requestAttributes(r)...)
+	if f.faas {
+		span.SetAttributes(attribute.Bool(FaaSColdStartKey, f.coldStart(ctx)))
+	}
 
 	if len(customAttrs) > 0 {
 		for _, attrs := range customAttrs {
-			for k, v := range attrs {
-				span.SetAttributes(ToAttribute(k, v))
-			}
+			span.SetAttributes(attrs.appendAttributes(nil, "")...)
 		}
 	}
 
@@ -312,6 +1629,16 @@ func (f *Factory) StartSpanFromRequest(r *http.Request, customAttrs ...SpanAttri
 	return r, ctx, span, obs
 }
 
+// spanName returns the name of the root span for r.
+func (f *Factory) spanName(r *http.Request) string {
+	if format := f.config.SpanNameFormatter.Value; format != nil {
+		if name := format(r); name != "" {
+			return name
+		}
+	}
+	return r.URL.Path
+}
+
 func parseLogLevel(levelStr string) slog.Level {
 	switch levelStr {
 	case "debug":
@@ -327,21 +1654,64 @@ func parseLogLevel(levelStr string) slog.Level {
 	}
 }
 
+// compositeShutdowner shuts down its components in reverse order of
+// registration, like deferred calls, so the log pipeline set up first is
+// closed last and still captures errors from the others. Shutdown runs once;
+// later calls return the first result.
 type compositeShutdowner struct {
+	mu          sync.Mutex
 	shutdowners []Shutdowner
+	once        sync.Once
+	err         error
+}
+
+// add appends s, so it is shut down before the components already present.
+func (cs *compositeShutdowner) add(s Shutdowner) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.shutdowners = append(cs.shutdowners, s)
+}
+
+// prepend inserts ss at the front, so they are shut down after the
+// components already present.
+func (cs *compositeShutdowner) prepend(ss ...Shutdowner) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	cs.shutdowners = append(ss, cs.shutdowners...)
+}
+
+// snapshot returns the current components.
+func (cs *compositeShutdowner) snapshot() []Shutdowner {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	return append([]Shutdowner(nil), cs.shutdowners...)
 }
 
 func (cs *compositeShutdowner) Shutdown(ctx context.Context) error {
+	cs.once.Do(func() {
+		unregisterPipeline(cs)
+		shutdowners := cs.snapshot()
+		var errs []error
+		for i := len(shutdowners) - 1; i >= 0; i-- {
+			if err := shutdowners[i].Shutdown(ctx); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		cs.err = errors.Join(errs...)
+	})
+	return cs.err
+}
+
+// ForceFlush flushes every component that buffers telemetry, without
+// shutting anything down.
+func (cs *compositeShutdowner) ForceFlush(ctx context.Context) error {
 	var errs []error
-	for _, s := range cs.shutdowners {
-		if err := s.Shutdown(ctx); err != nil {
+	for _, s := range cs.snapshot() {
+		if err := forceFlush(ctx, s); err != nil {
 			errs = append(errs, err)
 		}
 	}
-	if len(errs) > 0 {
-		return errors.Join(errs...)
-	}
-	return nil
+	return errors.Join(errs...)
 }
 
 func (cs *compositeShutdowner) ShutdownOrLog(msg string) {
@@ -349,7 +1719,7 @@ func (cs *compositeShutdowner) ShutdownOrLog(msg string) {
 }
 
 func shutdownWithDefaultTimeout(s Shutdowner, msg string) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), defaultShutdownTimeout)
 	defer cancel()
 
 	if err := s.Shutdown(ctx); err != nil {
@@ -0,0 +1,52 @@
+package observability
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// fatalFlushTimeout bounds the flush of buffered telemetry before a fatal
+// exit, so a stuck exporter cannot keep a crashing process alive.
+const fatalFlushTimeout = 5 * time.Second
+
+// livePipelines are the telemetry pipelines of the factories that have been
+// set up and not shut down yet, which are flushed before a fatal exit.
+var livePipelines = struct {
+	mu  sync.Mutex
+	set map[*compositeShutdowner]struct{}
+}{set: make(map[*compositeShutdowner]struct{})}
+
+func registerPipeline(cs *compositeShutdowner) {
+	livePipelines.mu.Lock()
+	defer livePipelines.mu.Unlock()
+	livePipelines.set[cs] = struct{}{}
+}
+
+func unregisterPipeline(cs *compositeShutdowner) {
+	livePipelines.mu.Lock()
+	defer livePipelines.mu.Unlock()
+	delete(livePipelines.set, cs)
+}
+
+// flushPipelines flushes the logs, spans, and metrics buffered by every live
+// pipeline. Errors are ignored: the process is about to exit, and there is
+// nowhere left to report them.
+func flushPipelines(ctx context.Context) {
+	livePipelines.mu.Lock()
+	pipelines := make([]*compositeShutdowner, 0, len(livePipelines.set))
+	for cs := range livePipelines.set {
+		pipelines = append(pipelines, cs)
+	}
+	livePipelines.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for _, cs := range pipelines {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cs.ForceFlush(ctx)
+		}()
+	}
+	wg.Wait()
+}
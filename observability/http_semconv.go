@@ -0,0 +1,93 @@
+package observability
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// knownHTTPMethods are the methods recorded as http.request.method as they
+// are; the HTTP semantic conventions record others as _OTHER, so that
+// arbitrary methods do not blow up the cardinality of the attribute.
+var knownHTTPMethods = map[string]bool{
+	http.MethodConnect: true,
+	http.MethodDelete:  true,
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPatch:   true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodTrace:   true,
+}
+
+// requestAttributes describes r, as the root span of the request records
+// it, following the current HTTP semantic conventions and, with
+// WithLegacyHTTPAttributes, the keys used before them too. Secrets are
+// removed from the URL first.
+func (f *Factory) requestAttributes(r *http.Request) []attribute.KeyValue {
+	url, target := f.config.URLScrubbing.Value.scrub(r.URL)
+	attrs := make([]attribute.KeyValue, 0, 16)
+
+	if knownHTTPMethods[r.Method] {
+		attrs = append(attrs, attribute.String("http.request.method", r.Method))
+	} else {
+		attrs = append(attrs,
+			attribute.String("http.request.method", "_OTHER"),
+			attribute.String("http.request.method_original", r.Method),
+		)
+	}
+	attrs = append(attrs, attribute.String("url.path", r.URL.Path))
+	if _, query, ok := strings.Cut(target, "?"); ok && query != "" {
+		attrs = append(attrs, attribute.String("url.query", query))
+	}
+	scheme := r.URL.Scheme
+	if scheme == "" {
+		scheme = "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+	}
+	attrs = append(attrs, attribute.String("url.scheme", scheme))
+	attrs = appendHostPort(attrs, "server.address", "server.port", r.Host)
+	attrs = appendHostPort(attrs, "network.peer.address", "network.peer.port", r.RemoteAddr)
+	if r.ProtoMajor >= 2 {
+		attrs = append(attrs, attribute.String("network.protocol.version", strconv.Itoa(r.ProtoMajor)))
+	} else if r.ProtoMajor == 1 {
+		attrs = append(attrs, attribute.String("network.protocol.version", "1."+strconv.Itoa(r.ProtoMinor)))
+	}
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, attribute.String("user_agent.original", ua))
+	}
+
+	if f.config.LegacyHTTPAttrs.Value {
+		attrs = append(attrs,
+			attribute.String("http.method", r.Method),
+			attribute.String("http.url", url),
+			attribute.String("http.target", target),
+			attribute.String("http.host", r.Host),
+			attribute.String("http.scheme", r.URL.Scheme),
+		)
+	}
+	return attrs
+}
+
+// appendHostPort appends the host and, if there is one, the port of
+// hostport as the hostKey and portKey attributes.
+func appendHostPort(attrs []attribute.KeyValue, hostKey, portKey, hostport string) []attribute.KeyValue {
+	if hostport == "" {
+		return attrs
+	}
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		return append(attrs, attribute.String(hostKey, hostport))
+	}
+	attrs = append(attrs, attribute.String(hostKey, host))
+	if n, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, attribute.Int(portKey, n))
+	}
+	return attrs
+}
@@ -0,0 +1,54 @@
+package observability
+
+import (
+	"context"
+	"encoding/binary"
+	"math/rand/v2"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// IDGenerator creates trace and span IDs for new spans. It has the same method
+// set as the OpenTelemetry SDK's IDGenerator, so SDK-compatible generators can
+// be passed to WithIDGenerator directly. Implementations must be safe for
+// concurrent use.
+type IDGenerator interface {
+	// NewIDs returns a trace ID and span ID for a new root span.
+	NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID)
+	// NewSpanID returns a span ID for a new child span of traceID.
+	NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID
+}
+
+// TimeOrderedIDGenerator returns an IDGenerator whose trace IDs start with
+// the current Unix time in seconds (big-endian, 4 bytes) followed by 12
+// random bytes. IDs sort by creation time, which lets some storage backends
+// answer time-range queries efficiently, and remain valid W3C trace IDs.
+// The random low-order bytes keep ratio-based sampling uniform. Span IDs are
+// fully random.
+func TimeOrderedIDGenerator() IDGenerator {
+	return timeOrderedIDGenerator{}
+}
+
+type timeOrderedIDGenerator struct{}
+
+func (timeOrderedIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	var tid trace.TraceID
+	binary.BigEndian.PutUint32(tid[0:4], uint32(time.Now().Unix()))
+	binary.BigEndian.PutUint32(tid[4:8], rand.Uint32())
+	binary.BigEndian.PutUint64(tid[8:16], rand.Uint64())
+	return tid, randomSpanID()
+}
+
+func (timeOrderedIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	return randomSpanID()
+}
+
+// randomSpanID returns a random, non-zero span ID.
+func randomSpanID() trace.SpanID {
+	var sid trace.SpanID
+	for !sid.IsValid() {
+		binary.BigEndian.PutUint64(sid[:], rand.Uint64())
+	}
+	return sid
+}
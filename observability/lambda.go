@@ -0,0 +1,98 @@
+package observability
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// serverlessFlushTimeout bounds FlushAfterInvoke, so an unreachable backend
+// does not hold up the next invocation for long.
+const serverlessFlushTimeout = 2 * time.Second
+
+// FlushAfterInvoke exports the telemetry buffered during a serverless
+// invocation before the runtime freezes the function, which stops the
+// background exporters until the next invocation, or for good. Call it when
+// the invocation's work is done; LambdaHandler does so itself. It flushes
+// even after ctx is canceled or past its deadline, for at most 2 seconds.
+func (f *Factory) FlushAfterInvoke(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), serverlessFlushTimeout)
+	defer cancel()
+	return f.Flush(ctx)
+}
+
+// LambdaHandler wraps an AWS Lambda handler, of the form lambda.Start
+// accepts, so each invocation runs in a server span named after the function
+// and continues the trace of the X-Ray trace header the runtime passes in,
+// when X-Ray propagation is enabled (see WithXRayCompatibility). The span
+// carries the function's name, version, and memory, whether the invocation
+// is a cold start, and the region, as the FaaS semantic conventions name
+// them; cold starts are counted in the faas.coldstarts metric too. The
+// invocation's Observability is available via ObsFromCtx. When the handler
+// returns, an error it returns is recorded on the span, and the telemetry is
+// flushed with FlushAfterInvoke. A panic is recorded, flushed, and
+// re-panicked for the runtime to report.
+//
+//	lambda.Start(observability.LambdaHandler(f, handle))
+func LambdaHandler[In, Out any](f *Factory, handler func(context.Context, In) (Out, error)) func(context.Context, In) (Out, error) {
+	return func(ctx context.Context, event In) (out Out, err error) {
+		ctx, obs, span := f.startInvocation(ctx)
+		defer func() {
+			if v := recover(); v != nil {
+				obs.recordPanic(v)
+				span.End()
+				f.flushInvocation(obs)
+				panic(v)
+			}
+			span.EndWith(&err)
+			f.flushInvocation(obs)
+		}()
+		return handler(ctx, event)
+	}
+}
+
+// startInvocation starts the root span of a Lambda invocation.
+func (f *Factory) startInvocation(ctx context.Context) (context.Context, *Observability, Span) {
+	// The Go runtime passes the invocation's trace header in the context,
+	// and older runtimes in the environment.
+	traceHeader, _ := ctx.Value("x-amzn-trace-id").(string)
+	if traceHeader == "" {
+		traceHeader = os.Getenv("_X_AMZN_TRACE_ID")
+	}
+	if traceHeader != "" {
+		ctx = f.providers.spans.Extract(ctx, http.Header{"X-Amzn-Trace-Id": {traceHeader}})
+	}
+
+	name := os.Getenv("AWS_LAMBDA_FUNCTION_NAME")
+	attrs := []attribute.KeyValue{
+		attribute.String("cloud.provider", "aws"),
+		attribute.String("faas.name", name),
+		attribute.Bool(FaaSColdStartKey, f.coldStart(ctx)),
+	}
+	if region := os.Getenv("AWS_REGION"); region != "" {
+		attrs = append(attrs, attribute.String("cloud.region", region))
+	}
+	if version := os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"); version != "" {
+		attrs = append(attrs, attribute.String("faas.version", version))
+	}
+	if mb, err := strconv.Atoi(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE")); err == nil {
+		attrs = append(attrs, attribute.Int64("faas.max_memory", int64(mb)<<20))
+	}
+	if name == "" {
+		name = "lambda.invoke"
+	}
+
+	ctx, obs, span := f.newObservability(ctx).startServerSpan(name, attrs...)
+	return ctxWithObs(ctx, obs), obs, span
+}
+
+// flushInvocation flushes the invocation's telemetry, logging a failure.
+func (f *Factory) flushInvocation(obs *Observability) {
+	if err := f.FlushAfterInvoke(obs.Context()); err != nil {
+		obs.Log.Warn("Failed to flush telemetry after invocation", "error", err)
+	}
+}
@@ -0,0 +1,82 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// defaultShutdownTimeout bounds a shutdown that has no deadline of its own.
+const defaultShutdownTimeout = 10 * time.Second
+
+// Run manages the lifecycle of a service. It calls Setup, then runs fn with a
+// context that is canceled when the process receives SIGINT or SIGTERM or
+// when ctx is done. Once fn returns, Run flushes buffered telemetry and shuts
+// down the components registered with RegisterShutdowner and then the
+// telemetry pipeline, within a 10 second deadline.
+//
+// fn should return once its context is canceled, after stopping its own
+// work, so that its final logs and spans are still exported. While it stops,
+// a second signal terminates the process immediately.
+//
+// Run returns the Setup error, or the error from fn joined with any shutdown
+// error.
+func (f *Factory) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, err := f.Setup(ctx); err != nil {
+		return err
+	}
+
+	runCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-runCtx.Done():
+			if ctx.Err() == nil {
+				f.providers.logger.Info("Shutdown signal received, stopping")
+			}
+			// Restore the default behavior, so another signal kills the process.
+			stop()
+		case <-done:
+		}
+	}()
+
+	runErr := fn(runCtx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultShutdownTimeout)
+	defer cancel()
+	return errors.Join(runErr, f.ShutdownWith(shutdownCtx, nil))
+}
+
+// RunServer runs server under Run: it serves until the process receives
+// SIGINT or SIGTERM or ctx is done, then stops accepting connections and
+// waits up to 10 seconds for in-flight requests before shutting down the
+// rest of the service. It returns nil after a clean shutdown.
+func (f *Factory) RunServer(ctx context.Context, server *http.Server) error {
+	return f.Run(ctx, func(ctx context.Context) error {
+		errc := make(chan error, 1)
+		go func() {
+			f.providers.logger.Info("Server starting", "addr", server.Addr)
+			errc <- server.ListenAndServe()
+		}()
+
+		select {
+		case err := <-errc:
+			return fmt.Errorf("server failed: %w", err)
+		case <-ctx.Done():
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			return fmt.Errorf("failed to shutdown HTTP server: %w", err)
+		}
+		return nil
+	})
+}
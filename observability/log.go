@@ -4,32 +4,21 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"math/rand/v2"
 	"os"
 	"runtime"
-	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/trace"
 )
 
 var (
-	baseLogger *slog.Logger
-	initOnce   sync.Once
-
-	// slogAttrPool reduces allocations by reusing slices for slog attributes.
-	slogAttrPool = sync.Pool{
-		New: func() interface{} {
-			// Pre-allocate a slice with a reasonable capacity.
-			s := make([]slog.Attr, 0, 16)
-			return &s
-		},
-	}
-
 	// otelAttrPool reduces allocations by reusing slices for OpenTelemetry attributes.
 	otelAttrPool = sync.Pool{
 		New: func() interface{} {
@@ -40,29 +29,129 @@ var (
 	}
 )
 
-// initLogger initializes the global logger and sets it as the default.
-// It returns the logger and a shutdowner for graceful termination.
-func initLogger(apmType APMType, logSource bool, logLevel, traceLogLevel slog.Level, async bool) (*slog.Logger, Shutdowner) {
-	var shutdowner Shutdowner = &noOpShutdowner{}
-	initOnce.Do(func() {
-		jsonHandler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-			AddSource: logSource,
-			Level:     logLevel,
-		})
+// logConfig carries the settings initLogger builds a logger from.
+type logConfig struct {
+	apmType APMType
+	level   slog.Leveler
+
+	// addSource adds source locations to records at or above sourceLevel.
+	addSource   bool
+	sourceLevel slog.Level
+
+	// traceLevel is the lowest level of records attached to spans.
+	traceLevel slog.Level
+
+	// async writes records from a queue drained by asyncWorkers goroutines,
+	// in the order they were logged per logger if asyncOrdered is set.
+	async        bool
+	asyncWorkers int
+	asyncOrdered bool
+
+	// wrap, if set, receives the JSON base handler and its result is used in
+	// place of it, underneath the trace-correlating apmHandler. Routes, if
+	// any, are applied between the two.
+	wrap   func(slog.Handler) slog.Handler
+	routes []LogRoute
+
+	// stackTraces makes error records carry the stack trace of the code
+	// that logged them.
+	stackTraces bool
+
+	// contextFields add attributes derived from the context to every record.
+	contextFields []func(context.Context) []slog.Attr
+
+	// traceURLTemplate, if set, links error records to their trace.
+	traceURLTemplate string
+
+	// gcp, if set, writes records in the Cloud Logging format.
+	gcp *gcpLogSchema
 
-		var handler slog.Handler = newApmHandler(jsonHandler, apmType, traceLogLevel, logSource)
+	// sink, if set, is written to rather than standard output, and loki, if
+	// set, is pushed to as well. With sinks, whose Path has been opened as
+	// their Writer, records are written to each of them instead.
+	sink  *sinkWriter
+	sinks []LogSink
+	loki  *lokiPusher
 
-		if async {
-			asyncHandler := newAsyncHandler(handler)
-			handler = asyncHandler
-			shutdowner = asyncHandler
+	// metrics, if set, counts records by level and pattern.
+	metrics *logMetrics
+
+	// setDefault makes the logger the slog default.
+	setDefault bool
+
+	// stats counts the records the handlers fail to write.
+	stats *pipelineStats
+}
+
+// initLogger builds a logger as cfg says. It returns the logger and a
+// shutdowner for graceful termination.
+func initLogger(cfg logConfig) (*slog.Logger, Shutdowner) {
+	var shutdowner Shutdowner = &noOpShutdowner{}
+	opts := &slog.HandlerOptions{
+		AddSource: cfg.addSource,
+		Level:     cfg.level,
+	}
+	if cfg.gcp != nil {
+		opts.ReplaceAttr = cfg.gcp.replaceAttr
+	}
+	var handler slog.Handler
+	if len(cfg.sinks) > 0 {
+		var tee teeHandler
+		for _, s := range cfg.sinks {
+			tee.sinks = append(tee.sinks, newTeeSink(s, *opts))
 		}
+		if cfg.loki != nil {
+			tee.sinks = append(tee.sinks, newTeeSink(LogSink{Name: "loki", Writer: cfg.loki}, *opts))
+		}
+		handler = tee
+	} else {
+		var out io.Writer = os.Stdout
+		if cfg.sink != nil {
+			out = cfg.sink
+		}
+		if cfg.loki != nil {
+			// Loki comes first: queuing never fails, so a failing output
+			// does not keep records from it.
+			out = io.MultiWriter(cfg.loki, out)
+		}
+		handler = slog.NewJSONHandler(out, opts)
+		if cfg.sink != nil {
+			handler = sinkHandler{handler, cfg.sink}
+		}
+	}
+	if cfg.wrap != nil {
+		handler = cfg.wrap(handler)
+	}
+	if len(cfg.routes) > 0 {
+		handler = newRouteHandler(handler, cfg.routes)
+	}
+
+	apm := newApmHandler(handler, cfg.apmType, cfg.traceLevel, cfg.addSource, cfg.sourceLevel)
+	apm.stats = cfg.stats
+	apm.traceURLTemplate = cfg.traceURLTemplate
+	apm.gcp = cfg.gcp
+	handler = apm
+
+	if cfg.async {
+		asyncHandler := newAsyncHandler(handler, cfg.asyncWorkers, cfg.asyncOrdered)
+		handler = asyncHandler
+		shutdowner = asyncHandler
+	}
+	if cfg.metrics != nil {
+		handler = logMetricsHandler{handler, cfg.metrics}
+	}
+	if cfg.stackTraces {
+		handler = stackHandler{handler}
+	}
+	if len(cfg.contextFields) > 0 {
+		handler = contextFieldsHandler{handler, cfg.contextFields}
+	}
 
-		logger := slog.New(handler)
+	logger := slog.New(handler)
+	if cfg.setDefault {
 		slog.SetDefault(logger)
-		baseLogger = logger
-	})
-	return baseLogger, shutdowner
+	}
+	return logger, shutdowner
 }
 
 // Log wraps the slog logger.
@@ -72,10 +161,10 @@ type Log struct {
 }
 
 // newLog creates a new Log instance.
-func newLog(obs *Observability, baseLogger *slog.Logger) *Log {
+func newLog(obs *Observability) *Log {
 	return &Log{
 		obs:    obs,
-		logger: baseLogger,
+		logger: obs.providers.logger,
 	}
 }
 
@@ -84,19 +173,38 @@ func (l *Log) getCtx() context.Context {
 }
 
 // Logc is the centralized logging function. It accepts a call depth
-// to ensure the log source is reported correctly, even from wrappers.
+// to ensure the log source is reported correctly, even from wrappers:
+// depth is the number of stack frames to skip, as for runtime.Callers,
+// so 3 reports the caller of the function that calls Logc. The stack is
+// only walked if the handler records the source for level.
 func (l *Log) Logc(level slog.Level, depth int, msg string, args ...any) {
 	ctx := l.getCtx()
 	if !l.logger.Enabled(ctx, level) {
 		return
 	}
-	// The slog.Handler is responsible for adding the source location.
-	// We pass a zero PC here to avoid the overhead of runtime.Callers.
-	r := slog.NewRecord(time.Now(), level, msg, 0)
+	l.handle(ctx, level, l.callerPC(level, depth), msg, args...)
+}
+
+// handle writes a record whose source is at pc, once the caller has checked
+// that level is enabled.
+func (l *Log) handle(ctx context.Context, level slog.Level, pc uintptr, msg string, args ...any) {
+	r := slog.NewRecord(time.Now(), level, msg, pc)
 	r.Add(args...)
 	_ = l.logger.Handler().Handle(ctx, r)
 }
 
+// callerPC returns the program counter that runtime.Callers(depth) would
+// report in its caller, or zero if the handler does not record the source
+// for level.
+func (l *Log) callerPC(level slog.Level, depth int) uintptr {
+	if !forwardsSource(l.logger.Handler(), level) {
+		return 0
+	}
+	var pcs [1]uintptr
+	runtime.Callers(depth+1, pcs[:])
+	return pcs[0]
+}
+
 func (l *Log) Debug(msg string, args ...any) {
 	l.Logc(slog.LevelDebug, 3, msg, args...)
 }
@@ -115,13 +223,13 @@ func (l *Log) Error(msg string, args ...any) {
 
 // LogWithAttrs provides a more performant logging method for high-frequency calls.
 // It accepts a pre-built slice of slog.Attr to avoid the overhead of parsing variadic arguments.
-// The call depth is fixed to 3, which assumes this method is not wrapped.
+// The source location is its immediate caller, which assumes this method is not wrapped.
 func (l *Log) LogWithAttrs(level slog.Level, msg string, attrs ...slog.Attr) {
 	ctx := l.getCtx()
 	if !l.logger.Enabled(ctx, level) {
 		return
 	}
-	r := slog.NewRecord(time.Now(), level, msg, 0)
+	r := slog.NewRecord(time.Now(), level, msg, l.callerPC(level, 2))
 	r.AddAttrs(attrs...)
 	_ = l.logger.Handler().Handle(ctx, r)
 }
@@ -145,12 +253,14 @@ func (l *Log) Println(v ...any) {
 	l.Logc(slog.LevelDebug, 3, fmt.Sprint(v...))
 }
 
-// Fatalf formats a message, logs it as a fatal error, and exits the application.
+// Fatalf formats a message, logs it as a fatal error, and exits the application
+// (see WithFatalHandler).
 func (l *Log) Fatalf(format string, v ...any) {
 	l.obs.ErrorHandler.Fatal(fmt.Sprintf(format, v...))
 }
 
-// Fatal logs a message as a fatal error and exits the application.
+// Fatal logs a message as a fatal error and exits the application (see
+// WithFatalHandler).
 func (l *Log) Fatal(v ...any) {
 	l.obs.ErrorHandler.Fatal(fmt.Sprint(v...))
 }
@@ -175,7 +285,8 @@ func (l *Log) Panic(v ...any) {
 // terminates the application with a non-zero exit code.
 //
 // This function is safe to call even before the observability factory
-// has been initialized.
+// has been initialized. Once factories have been set up, it first flushes
+// their buffered logs, spans, and metrics for up to 5 seconds.
 func LogFatal(msg string, args ...any) {
 	// Create a minimal, reliable logger on the fly.
 	handler := slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
@@ -184,6 +295,10 @@ func LogFatal(msg string, args ...any) {
 	})
 	logger := slog.New(handler)
 
+	ctx, cancel := context.WithTimeout(context.Background(), fatalFlushTimeout)
+	flushPipelines(ctx)
+	cancel()
+
 	// Log the error and exit.
 	logger.Error(msg, args...)
 	os.Exit(1)
@@ -203,151 +318,192 @@ func LogShutdownError(msg string, err error) {
 
 // --- apmHandler for slog integration ---
 
+// sourcer is implemented by handlers that tell Log whether a record at a
+// given level needs its source location, so the stack is walked only then.
+type sourcer interface {
+	wantsSource(level slog.Level) bool
+}
+
+// forwardsSource reports whether h is a sourcer that wants the source of
+// records at level. Wrapping handlers implement wantsSource with it.
+func forwardsSource(h slog.Handler, level slog.Level) bool {
+	s, ok := h.(sourcer)
+	return ok && s.wantsSource(level)
+}
+
+// Attribute keys under which records also carry their trace with the Datadog
+// APM type, so Datadog correlates them with traces without a remapper.
+const (
+	DatadogTraceIDKey = "dd.trace_id"
+	DatadogSpanIDKey  = "dd.span_id"
+)
+
 type apmHandler struct {
 	slog.Handler
-	attrs         []slog.Attr
-	apmType       APMType
+	// attrs are the attributes given with WithAttrs, converted for spans.
+	attrs []attribute.KeyValue
+	// groups is the prefix of the groups opened with WithGroup, such as
+	// "request.", which the keys of span attributes are qualified with.
+	groups        string
+	spans         SpanFactory
 	traceLogLevel slog.Level
 	addSource     bool
+	sourceLevel   slog.Level
+
+	// traceURLTemplate, if set, links error records to their trace.
+	traceURLTemplate string
+
+	// gcp, if set, adds the trace in the Cloud Logging fields instead of as
+	// trace.id and span.id.
+	gcp *gcpLogSchema
+
+	// datadog adds the trace as dd.trace_id and dd.span_id too, which
+	// Datadog correlates logs with.
+	datadog bool
+
+	// stats, if set, counts the records the base handler fails to write.
+	stats *pipelineStats
 }
 
-func newApmHandler(baseHandler slog.Handler, apmType APMType, traceLogLevel slog.Level, addSource bool) *apmHandler {
+func newApmHandler(baseHandler slog.Handler, apmType APMType, traceLogLevel slog.Level, addSource bool, sourceLevel slog.Level) *apmHandler {
 	return &apmHandler{
 		Handler:       baseHandler,
-		apmType:       apmType,
+		spans:         spanFactoryFor(apmType),
 		traceLogLevel: traceLogLevel,
 		addSource:     addSource,
+		sourceLevel:   sourceLevel,
+		datadog:       apmType == Datadog || apmType == DatadogOTel,
 	}
 }
 
+func (h *apmHandler) wantsSource(level slog.Level) bool {
+	return h.addSource && level >= h.sourceLevel
+}
+
 func (h *apmHandler) Handle(ctx context.Context, r slog.Record) error {
-	// Add source location if enabled.
-	if h.addSource {
-		var pcs [1]uintptr
-		runtime.Callers(4, pcs[:]) // skip [Callers, Handle, logc, Info/Debug/etc.]
-		r.PC = pcs[0]
+	// slog.Logger always sets the PC; clear it below the source level so
+	// the base handler leaves the source out.
+	if !h.wantsSource(r.Level) {
+		r.PC = 0
 	}
 
 	// Add trace and span IDs to the record's attributes
-	traceID, spanID := h.getTraceSpanID(ctx)
-	if traceID != "" {
-		r.AddAttrs(slog.String("trace.id", traceID))
-	}
-	if spanID != "" {
-		r.AddAttrs(slog.String("span.id", spanID))
-	}
-
-	// Only attach to spans if the level is high enough.
-	if r.Level >= h.traceLogLevel {
-		// Use a pooled slice for attributes to reduce allocations.
-		slogAttrsPtr := slogAttrPool.Get().(*[]slog.Attr)
-		defer func() {
-			// Reset the slice length and return it to the pool.
-			*slogAttrsPtr = (*slogAttrsPtr)[:0]
-			slogAttrPool.Put(slogAttrsPtr)
-		}()
-		slogAttrs := *slogAttrsPtr
-
-		slogAttrs = append(slogAttrs, h.attrs...)
+	traceID, spanID := h.spans.TraceIDs(ctx)
+	if h.gcp != nil {
+		h.gcp.addTrace(ctx, &r, h.spans, traceID, spanID)
+	} else {
+		if traceID != "" {
+			r.AddAttrs(slog.String("trace.id", traceID))
+			if h.datadog {
+				r.AddAttrs(slog.String(DatadogTraceIDKey, traceID))
+			}
+		}
+		if spanID != "" {
+			r.AddAttrs(slog.String("span.id", spanID))
+			if h.datadog {
+				r.AddAttrs(slog.String(DatadogSpanIDKey, spanID))
+			}
+		}
+	}
+	if md, ok := ctx.Value(requestMetadataKey{}).(requestMetadata); ok {
+		md.addToRecord(&r)
+	}
+	if id := WorkflowFrom(ctx); id != "" {
+		r.AddAttrs(slog.String(WorkflowIDKey, id))
+	}
+	if r.Level >= slog.LevelError {
+		if url := traceURL(h.traceURLTemplate, traceID, spanID); url != "" {
+			r.AddAttrs(slog.String(TraceURLKey, url))
+		}
+		var loggedErr error
 		r.Attrs(func(a slog.Attr) bool {
-			slogAttrs = append(slogAttrs, a)
+			if a.Key == "error" {
+				loggedErr, _ = a.Value.Any().(error)
+				return false
+			}
 			return true
 		})
+		r.AddAttrs(errorLogAttrs(loggedErr)...)
+	}
 
-		switch h.apmType {
-		case OTLP:
-			h.handleOTLP(ctx, r, slogAttrs)
-		case Datadog:
-			h.handleDatadog(ctx, r, slogAttrs)
-		case None:
-			// Do nothing
+	// Only attach to spans if the level is high enough and the span is
+	// recording; otherwise no attributes are copied at all. Records of
+	// Observability.Event have recorded their span event already.
+	if r.Level >= h.traceLogLevel && ctx.Value(spanEventRecordedKey{}) == nil {
+		if span := h.spans.SpanFromContext(ctx); span != nil && span.IsRecording() {
+			h.handleSpan(span, r)
 		}
 	}
 
-	return h.Handler.Handle(ctx, r)
-}
-
-func (h *apmHandler) getTraceSpanID(ctx context.Context) (traceID, spanID string) {
-	if h.apmType == None {
-		return "", ""
-	}
-	if h.apmType == OTLP {
-		span := trace.SpanFromContext(ctx)
-		if span.SpanContext().HasTraceID() {
-			traceID = span.SpanContext().TraceID().String()
-		}
-		if span.SpanContext().HasSpanID() {
-			spanID = span.SpanContext().SpanID().String()
-		}
-	} else if h.apmType == Datadog {
-		if ddSpan, ok := tracer.SpanFromContext(ctx); ok {
-			traceID = strconv.FormatUint(ddSpan.Context().TraceID(), 10)
-			spanID = strconv.FormatUint(ddSpan.Context().SpanID(), 10)
+	if err := h.Handler.Handle(ctx, r); err != nil {
+		if h.stats != nil {
+			h.stats.logErrors.Add(1)
 		}
+		return err
 	}
-	return
+	return nil
 }
 
-func (h *apmHandler) handleOTLP(ctx context.Context, r slog.Record, slogAttrs []slog.Attr) {
-	span := trace.SpanFromContext(ctx)
-	if !span.IsRecording() {
-		return
-	}
-
-	// Use a pooled slice for OTel attributes.
+// handleSpan attaches the record to span: errors are recorded and set the
+// span status, anything else becomes a span event.
+func (h *apmHandler) handleSpan(span Span, r slog.Record) {
+	// Use a pooled slice for OTel attributes to reduce allocations.
 	otelAttrsPtr := otelAttrPool.Get().(*[]attribute.KeyValue)
-	defer func() {
-		*otelAttrsPtr = (*otelAttrsPtr)[:0]
-		otelAttrPool.Put(otelAttrsPtr)
-	}()
 	otelAttrs := *otelAttrsPtr
 
-	for _, a := range slogAttrs {
-		otelAttrs = append(otelAttrs, toOtelAttribute(a))
-	}
+	otelAttrs = append(otelAttrs, h.attrs...)
+	var loggedErr error
+	r.Attrs(func(a slog.Attr) bool {
+		otelAttrs = appendOtelAttributes(otelAttrs, h.groups, a)
+		if loggedErr == nil && a.Key == "error" {
+			loggedErr, _ = a.Value.Any().(error)
+		}
+		return true
+	})
 
 	if r.Level >= slog.LevelError {
-		err := extractError(r)
-		span.RecordError(err, trace.WithAttributes(otelAttrs...))
+		if loggedErr == nil {
+			loggedErr = errors.New(r.Message)
+		}
+		span.RecordError(loggedErr, trace.WithAttributes(otelAttrs...))
 		span.SetStatus(codes.Error, r.Message)
 	} else {
 		span.AddEvent(r.Message, trace.WithAttributes(otelAttrs...))
 	}
-}
 
-func (h *apmHandler) handleDatadog(ctx context.Context, r slog.Record, attrs []slog.Attr) {
-	if ddSpan, ok := tracer.SpanFromContext(ctx); ok {
-		for _, a := range attrs {
-			ddSpan.SetTag(a.Key, a.Value.String())
+	// Drop references to attribute values and return the slice to the pool,
+	// keeping any capacity it grew.
+	clear(otelAttrs)
+	*otelAttrsPtr = otelAttrs[:0]
+	otelAttrPool.Put(otelAttrsPtr)
+}
+
+// appendOtelAttributes appends a to attrs as span attributes, with its key
+// qualified by prefix. LogValuer values are resolved, and groups are
+// flattened into one attribute per member, with the group names joined to
+// the keys with dots, as the JSON output nests them; inline groups, which
+// have no key, add no name. Empty attributes are skipped, as slog does.
+func appendOtelAttributes(attrs []attribute.KeyValue, prefix string, a slog.Attr) []attribute.KeyValue {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
 		}
-
-		if r.Level >= slog.LevelError {
-			err := extractError(r)
-			ddSpan.SetTag("error", err)
-		} else {
-			ddSpan.SetTag("event", r.Message)
+		for _, member := range a.Value.Group() {
+			attrs = appendOtelAttributes(attrs, prefix, member)
 		}
+		return attrs
 	}
-}
-
-// extractError finds and returns an error from a slog record, or creates a new one.
-func extractError(r slog.Record) error {
-	var loggedErr error
-	r.Attrs(func(attr slog.Attr) bool {
-		if attr.Key == "error" {
-			if errVal, ok := attr.Value.Any().(error); ok {
-				loggedErr = errVal
-				return false // stop iterating
-			}
-		}
-		return true
-	})
-	if loggedErr == nil {
-		loggedErr = errors.New(r.Message)
+	if a.Equal(slog.Attr{}) {
+		return attrs
 	}
-	return loggedErr
+	a.Key = prefix + a.Key
+	return append(attrs, toOtelAttribute(a))
 }
 
+// toOtelAttribute converts a resolved attribute that is not a group to a
+// span attribute. Slices of strings, integers, floats, and booleans keep
+// their type; other values are recorded as strings.
 func toOtelAttribute(a slog.Attr) attribute.KeyValue {
 	switch a.Value.Kind() {
 	case slog.KindString:
@@ -360,32 +516,63 @@ func toOtelAttribute(a slog.Attr) attribute.KeyValue {
 		return attribute.Float64(a.Key, a.Value.Float64())
 	case slog.KindBool:
 		return attribute.Bool(a.Key, a.Value.Bool())
+	case slog.KindAny:
+		switch v := a.Value.Any().(type) {
+		case []string:
+			return attribute.StringSlice(a.Key, v)
+		case []int:
+			return attribute.IntSlice(a.Key, v)
+		case []int64:
+			return attribute.Int64Slice(a.Key, v)
+		case []float64:
+			return attribute.Float64Slice(a.Key, v)
+		case []bool:
+			return attribute.BoolSlice(a.Key, v)
+		}
+		return attribute.String(a.Key, a.Value.String())
 	default:
 		return attribute.String(a.Key, a.Value.String())
 	}
 }
 
 func (h *apmHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	newAttrs := make([]slog.Attr, len(h.attrs)+len(attrs))
+	newAttrs := make([]attribute.KeyValue, len(h.attrs), len(h.attrs)+len(attrs))
 	copy(newAttrs, h.attrs)
-	copy(newAttrs[len(h.attrs):], attrs)
+	for _, a := range attrs {
+		newAttrs = appendOtelAttributes(newAttrs, h.groups, a)
+	}
 
 	return &apmHandler{
-		Handler:       h.Handler.WithAttrs(attrs),
-		attrs:         newAttrs,
-		apmType:       h.apmType,
-		traceLogLevel: h.traceLogLevel,
-		addSource:     h.addSource,
+		Handler:          h.Handler.WithAttrs(attrs),
+		attrs:            newAttrs,
+		groups:           h.groups,
+		spans:            h.spans,
+		traceLogLevel:    h.traceLogLevel,
+		addSource:        h.addSource,
+		sourceLevel:      h.sourceLevel,
+		stats:            h.stats,
+		traceURLTemplate: h.traceURLTemplate,
+		gcp:              h.gcp,
+		datadog:          h.datadog,
 	}
 }
 
 func (h *apmHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
 	return &apmHandler{
-		Handler:       h.Handler.WithGroup(name),
-		attrs:         h.attrs,
-		apmType:       h.apmType,
-		traceLogLevel: h.traceLogLevel,
-		addSource:     h.addSource,
+		Handler:          h.Handler.WithGroup(name),
+		attrs:            h.attrs,
+		groups:           h.groups + name + ".",
+		spans:            h.spans,
+		traceLogLevel:    h.traceLogLevel,
+		addSource:        h.addSource,
+		sourceLevel:      h.sourceLevel,
+		stats:            h.stats,
+		traceURLTemplate: h.traceURLTemplate,
+		gcp:              h.gcp,
+		datadog:          h.datadog,
 	}
 }
 
@@ -397,36 +584,149 @@ func (h *apmHandler) Enabled(ctx context.Context, level slog.Level) bool {
 
 const defaultAsyncBufferSize = 10000
 
+// asyncDropReportInterval is how often the records dropped from a full
+// queue since the last report are logged.
+const asyncDropReportInterval = 10 * time.Second
+
+// asyncQueue is the buffer and drain goroutines shared by an asyncHandler
+// and every handler derived from it with WithAttrs or WithGroup. The buffer
+// is split into shards, each drained by a goroutine of its own.
+type asyncQueue struct {
+	shards  []chan asyncRecord
+	wg      sync.WaitGroup
+	dropped atomic.Uint64
+
+	// ordered sends the records of each handler to one shard, so they are
+	// written in the order they were logged, spreading the handlers across
+	// the shards in turn, using next. Otherwise each record goes to a random
+	// shard.
+	ordered bool
+	next    atomic.Uint64
+
+	// mu guards closed, so Handle and ForceFlush do not send on the closed
+	// channels.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// asyncRecord is a queued record together with the handler that writes it
+// and the context it was logged with, detached from its cancelation, or, if
+// flushed is set, a marker closed once the records queued before it have
+// been written.
+type asyncRecord struct {
+	handler slog.Handler
+	ctx     context.Context
+	record  slog.Record
+	flushed chan struct{}
+}
+
 type asyncHandler struct {
 	underlying slog.Handler
-	records    chan slog.Record
-	wg         sync.WaitGroup
-}
+	queue      *asyncQueue
+	// shard is where the handler's records go when the queue is ordered.
+	shard int
+}
+
+// newAsyncHandler starts writing the records logged through it to
+// underlying with the given number of drain goroutines, at least one, which
+// share the queue's capacity.
+func newAsyncHandler(underlying slog.Handler, workers int, ordered bool) *asyncHandler {
+	workers = max(workers, 1)
+	q := &asyncQueue{
+		shards:  make([]chan asyncRecord, workers),
+		ordered: ordered,
+	}
+	for i := range q.shards {
+		q.shards[i] = make(chan asyncRecord, (defaultAsyncBufferSize+workers-1)/workers)
+	}
 
-func newAsyncHandler(underlying slog.Handler) *asyncHandler {
-	h := &asyncHandler{
+	q.wg.Add(workers)
+	for i, shard := range q.shards {
+		// The first worker reports the records the queue dropped.
+		go q.drain(shard, underlying, i == 0)
+	}
+
+	return &asyncHandler{
 		underlying: underlying,
-		records:    make(chan slog.Record, defaultAsyncBufferSize),
+		queue:      q,
 	}
+}
 
-	h.wg.Add(1)
-	go func() {
-		defer h.wg.Done()
-		for record := range h.records {
-			_ = h.underlying.Handle(context.Background(), record)
+// drain writes the records of shard until it is closed and, with report,
+// logs the dropped records through handler.
+func (q *asyncQueue) drain(shard chan asyncRecord, handler slog.Handler, report bool) {
+	defer q.wg.Done()
+	var tick <-chan time.Time
+	if report {
+		ticker := time.NewTicker(asyncDropReportInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	var reported uint64
+	for {
+		select {
+		case ar, ok := <-shard:
+			if !ok {
+				if report {
+					q.reportDrops(handler, &reported)
+				}
+				return
+			}
+			if ar.flushed != nil {
+				close(ar.flushed)
+				continue
+			}
+			_ = ar.handler.Handle(ar.ctx, ar.record)
+		case <-tick:
+			q.reportDrops(handler, &reported)
 		}
-	}()
+	}
+}
+
+// reportDrops logs a warning through handler if records were dropped since
+// the count in reported, which it then updates.
+func (q *asyncQueue) reportDrops(handler slog.Handler, reported *uint64) {
+	n := q.dropped.Load()
+	if n == *reported {
+		return
+	}
+	r := slog.NewRecord(time.Now(), slog.LevelWarn, "Asynchronous logging dropped records because its queue was full", 0)
+	r.AddAttrs(slog.Uint64("dropped", n-*reported), slog.Uint64("dropped_total", n))
+	_ = handler.Handle(context.Background(), r)
+	*reported = n
+}
 
-	return h
+// nextShard returns the shard a new handler sends its records to when the
+// queue is ordered, spreading handlers across the shards.
+func (q *asyncQueue) nextShard() int {
+	if len(q.shards) == 1 {
+		return 0
+	}
+	return int(q.next.Add(1) % uint64(len(q.shards)))
 }
 
 func (h *asyncHandler) Handle(ctx context.Context, r slog.Record) error {
 	recordCopy := r.Clone()
+	q := h.queue
+	shard := h.shard
+	if !q.ordered && len(q.shards) > 1 {
+		shard = rand.IntN(len(q.shards))
+	}
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	if q.closed {
+		// Records logged after shutdown are dropped.
+		q.dropped.Add(1)
+		return nil
+	}
+	// The handlers below read the trace and request metadata from the
+	// context, so it goes along with the record, even once canceled.
 	select {
-	case h.records <- recordCopy:
+	case q.shards[shard] <- asyncRecord{handler: h.underlying, ctx: context.WithoutCancel(ctx), record: recordCopy}:
 		// Log sent successfully.
 	default:
 		// Channel is full, drop the log.
+		q.dropped.Add(1)
 	}
 	return nil
 }
@@ -435,17 +735,65 @@ func (h *asyncHandler) Enabled(ctx context.Context, level slog.Level) bool {
 	return h.underlying.Enabled(ctx, level)
 }
 
+func (h *asyncHandler) wantsSource(level slog.Level) bool {
+	return forwardsSource(h.underlying, level)
+}
+
 func (h *asyncHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return newAsyncHandler(h.underlying.WithAttrs(attrs))
+	return &asyncHandler{underlying: h.underlying.WithAttrs(attrs), queue: h.queue, shard: h.queue.nextShard()}
 }
 
 func (h *asyncHandler) WithGroup(name string) slog.Handler {
-	return newAsyncHandler(h.underlying.WithGroup(name))
+	return &asyncHandler{underlying: h.underlying.WithGroup(name), queue: h.queue, shard: h.queue.nextShard()}
+}
+
+// stats reports the current queue depth, its capacity, and how many records
+// have been dropped because the queue was full.
+func (h *asyncHandler) stats() (depth, capacity int, dropped uint64) {
+	for _, shard := range h.queue.shards {
+		depth += len(shard)
+		capacity += cap(shard)
+	}
+	return depth, capacity, h.queue.dropped.Load()
+}
+
+// ForceFlush waits until the records queued so far have been written.
+func (h *asyncHandler) ForceFlush(ctx context.Context) error {
+	h.queue.mu.RLock()
+	if h.queue.closed {
+		h.queue.mu.RUnlock()
+		return nil
+	}
+	markers := make([]chan struct{}, len(h.queue.shards))
+	for i, shard := range h.queue.shards {
+		markers[i] = make(chan struct{})
+		select {
+		case shard <- asyncRecord{flushed: markers[i]}:
+		case <-ctx.Done():
+			h.queue.mu.RUnlock()
+			return ctx.Err()
+		}
+	}
+	h.queue.mu.RUnlock()
+
+	for _, flushed := range markers {
+		select {
+		case <-flushed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
 }
 
 func (h *asyncHandler) Shutdown(ctx context.Context) error {
-	close(h.records)
-	h.wg.Wait()
+	h.queue.mu.Lock()
+	h.queue.closed = true
+	for _, shard := range h.queue.shards {
+		close(shard)
+	}
+	h.queue.mu.Unlock()
+	h.queue.wg.Wait()
 	return nil
 }
 
@@ -456,4 +804,4 @@ func (h *asyncHandler) ShutdownOrLog(msg string) {
 	if err := h.Shutdown(context.Background()); err != nil {
 		LogShutdownError(msg, err)
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,111 @@
+package observability
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// benchSpan is a Span that accepts everything and records nothing, so the
+// benchmarks measure the handler rather than an APM backend.
+type benchSpan struct{ recording bool }
+
+func (s benchSpan) End()                                    {}
+func (s benchSpan) EndWith(*error)                          {}
+func (s benchSpan) AddEvent(string, ...trace.EventOption)   {}
+func (s benchSpan) RecordError(error, ...trace.EventOption) {}
+func (s benchSpan) SetStatus(codes.Code, string)            {}
+func (s benchSpan) SetAttributes(...attribute.KeyValue)     {}
+func (s benchSpan) IsRecording() bool                       { return s.recording }
+func (s benchSpan) Keep()                                   {}
+
+type benchSpanFactory struct{ span Span }
+
+func (f benchSpanFactory) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, f.span
+}
+func (f benchSpanFactory) SpanFromContext(context.Context) Span { return f.span }
+func (f benchSpanFactory) TraceIDs(context.Context) (string, string) {
+	return "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+}
+func (f benchSpanFactory) Inject(context.Context, http.Header) {}
+func (f benchSpanFactory) Extract(ctx context.Context, _ http.Header) context.Context {
+	return ctx
+}
+
+func benchmarkApmHandler(b *testing.B, span Span, level slog.Level) {
+	h := &apmHandler{
+		Handler:       slog.DiscardHandler,
+		spans:         benchSpanFactory{span: span},
+		traceLogLevel: slog.LevelInfo,
+		addSource:     true,
+	}
+	handler := h.WithAttrs([]slog.Attr{slog.String("component", "bench")})
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := slog.NewRecord(time.Time{}, level, "request handled", 0)
+		r.AddAttrs(
+			slog.String("method", "GET"),
+			slog.Int("status", 200),
+			slog.Bool("cached", false),
+			slog.Float64("latency_ms", 12.5),
+		)
+		_ = handler.Handle(ctx, r)
+	}
+}
+
+func BenchmarkApmHandlerNoSpan(b *testing.B) {
+	benchmarkApmHandler(b, nil, slog.LevelInfo)
+}
+
+func BenchmarkApmHandlerNonRecordingSpan(b *testing.B) {
+	benchmarkApmHandler(b, benchSpan{recording: false}, slog.LevelInfo)
+}
+
+func BenchmarkApmHandlerRecordingSpan(b *testing.B) {
+	benchmarkApmHandler(b, benchSpan{recording: true}, slog.LevelInfo)
+}
+
+func BenchmarkApmHandlerBelowTraceLevel(b *testing.B) {
+	benchmarkApmHandler(b, benchSpan{recording: true}, slog.LevelDebug)
+}
+
+func BenchmarkApmHandlerError(b *testing.B) {
+	benchmarkApmHandler(b, benchSpan{recording: true}, slog.LevelError)
+}
+
+func benchmarkAsyncHandler(b *testing.B, workers int) {
+	h := newAsyncHandler(slog.NewJSONHandler(io.Discard, nil), workers, false)
+	ctx := context.Background()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			r := slog.NewRecord(time.Now(), slog.LevelInfo, "request handled", 0)
+			r.AddAttrs(slog.String("method", "GET"), slog.Int("status", 200))
+			_ = h.Handle(ctx, r)
+		}
+	})
+	h.Shutdown(ctx)
+	_, _, dropped := h.stats()
+	b.ReportMetric(float64(dropped)/float64(b.N), "dropped/op")
+}
+
+func BenchmarkAsyncHandler1Worker(b *testing.B) {
+	benchmarkAsyncHandler(b, 1)
+}
+
+func BenchmarkAsyncHandler4Workers(b *testing.B) {
+	benchmarkAsyncHandler(b, 4)
+}
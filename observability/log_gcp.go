@@ -0,0 +1,123 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Log schemas, set with WithLogSchema.
+const (
+	logSchemaDefault = "default"
+	logSchemaGCP     = "gcp"
+)
+
+// Special fields of Google Cloud Logging structured logs, which the logging
+// agent moves from the JSON payload into the log entry.
+const (
+	gcpTraceKey          = "logging.googleapis.com/trace"
+	gcpSpanIDKey         = "logging.googleapis.com/spanId"
+	gcpTraceSampledKey   = "logging.googleapis.com/trace_sampled"
+	gcpSourceLocationKey = "logging.googleapis.com/sourceLocation"
+)
+
+// gcpProjectTimeout bounds the metadata server lookup of the project ID.
+const gcpProjectTimeout = time.Second
+
+// gcpLogSchema writes records in the format Google Cloud Logging expects,
+// so that they are correlated with their traces in the Google Cloud
+// console.
+type gcpLogSchema struct {
+	// project is the ID of the Google Cloud project, which trace names are
+	// qualified with, or "" if it is unknown.
+	project string
+}
+
+// newGCPLogSchema returns the Cloud Logging schema for the project named by
+// GOOGLE_CLOUD_PROJECT or, failing that, by the metadata server.
+func newGCPLogSchema(ctx context.Context) *gcpLogSchema {
+	if project := os.Getenv("GOOGLE_CLOUD_PROJECT"); project != "" {
+		return &gcpLogSchema{project: project}
+	}
+	ctx, cancel := context.WithTimeout(ctx, gcpProjectTimeout)
+	defer cancel()
+	project, err := fetchMetadata(ctx, http.MethodGet, metadataHost+"/computeMetadata/v1/project/project-id", map[string]string{"Metadata-Flavor": "Google"})
+	if err != nil {
+		return &gcpLogSchema{}
+	}
+	return &gcpLogSchema{project: strings.TrimSpace(string(project))}
+}
+
+// addTrace adds the trace of ctx to r as the Cloud Logging trace fields.
+func (s *gcpLogSchema) addTrace(ctx context.Context, r *slog.Record, spans SpanFactory, traceID, spanID string) {
+	if traceID == "" {
+		return
+	}
+	name := traceID
+	if s.project != "" {
+		name = "projects/" + s.project + "/traces/" + traceID
+	}
+	r.AddAttrs(slog.String(gcpTraceKey, name))
+	if spanID != "" {
+		r.AddAttrs(slog.String(gcpSpanIDKey, spanID))
+	}
+	r.AddAttrs(slog.Bool(gcpTraceSampledKey, traceSampled(ctx, spans)))
+}
+
+// traceSampled reports whether the trace of ctx is sampled: by the sampling
+// flag of its span context when there is one, and otherwise by whether its
+// span is recording.
+func traceSampled(ctx context.Context, spans SpanFactory) bool {
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		return sc.IsSampled()
+	}
+	span := spans.SpanFromContext(ctx)
+	return span != nil && span.IsRecording()
+}
+
+// replaceAttr renames the built-in attributes of the JSON handler to the
+// Cloud Logging fields: the level becomes the severity, the message is
+// written as message, and the source as the source location.
+func (s *gcpLogSchema) replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		level, _ := a.Value.Any().(slog.Level)
+		return slog.String("severity", gcpSeverity(level))
+	case slog.MessageKey:
+		a.Key = "message"
+	case slog.SourceKey:
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.Group(gcpSourceLocationKey,
+				slog.String("file", src.File),
+				slog.Int("line", src.Line),
+				slog.String("function", src.Function),
+			)
+		}
+	}
+	return a
+}
+
+// gcpSeverity maps a level to its Cloud Logging severity. Levels above
+// error, such as slog.LevelError+4, are critical.
+func gcpSeverity(level slog.Level) string {
+	switch {
+	case level > slog.LevelError:
+		return "CRITICAL"
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
@@ -0,0 +1,206 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Batching of the records pushed to Loki.
+const (
+	lokiPushInterval = time.Second
+	lokiMaxBatch     = 1000
+	lokiMaxQueue     = 10000
+)
+
+// lokiPusher batches the log records written to it and pushes them to a
+// Grafana Loki server through its push API, as a single stream labeled with
+// the service identity. Write never blocks on the network: records are
+// pushed every second, or as soon as a batch is full, and records beyond
+// the queue limit are dropped and counted.
+type lokiPusher struct {
+	endpoint string
+	user     *url.Userinfo
+	labels   map[string]string
+	client   *http.Client
+	stats    *pipelineStats
+
+	mu      sync.Mutex
+	entries [][2]string
+
+	// pushMu serializes pushes, so that ForceFlush returns only once the
+	// records written before it have been pushed.
+	pushMu sync.Mutex
+	full   chan struct{}
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// startLokiPusher starts pushing the records written to it to the Loki
+// server at rawURL, labeled with the service name, application, and
+// environment.
+func startLokiPusher(rawURL, serviceName, serviceApp, serviceEnv string, stats *pipelineStats) (*lokiPusher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Loki URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid Loki URL %q: scheme must be http or https", rawURL)
+	}
+	user := u.User
+	u.User = nil
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/loki/api/v1/push"
+
+	p := &lokiPusher{
+		endpoint: u.String(),
+		user:     user,
+		labels: map[string]string{
+			"service_name": serviceName,
+			"application":  serviceApp,
+			"environment":  serviceEnv,
+		},
+		client: &http.Client{Timeout: 10 * time.Second},
+		stats:  stats,
+		full:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// Write queues one record, as written by the JSON handler.
+func (p *lokiPusher) Write(b []byte) (int, error) {
+	line := string(bytes.TrimSuffix(b, []byte("\n")))
+	ts := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	p.mu.Lock()
+	if len(p.entries) >= lokiMaxQueue {
+		p.mu.Unlock()
+		p.stats.logsDropped.Add(1)
+		return len(b), nil
+	}
+	p.entries = append(p.entries, [2]string{ts, line})
+	full := len(p.entries) >= lokiMaxBatch
+	p.mu.Unlock()
+
+	if full {
+		select {
+		case p.full <- struct{}{}:
+		default:
+		}
+	}
+	return len(b), nil
+}
+
+func (p *lokiPusher) run() {
+	defer close(p.done)
+	ticker := time.NewTicker(lokiPushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		case <-p.full:
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), p.client.Timeout)
+		p.ForceFlush(ctx)
+		cancel()
+	}
+}
+
+// ForceFlush pushes the queued records, in batches of at most 1000. Records
+// whose push fails are dropped, and the failure is reported as a failed
+// export of logs.
+func (p *lokiPusher) ForceFlush(ctx context.Context) error {
+	p.pushMu.Lock()
+	defer p.pushMu.Unlock()
+
+	p.mu.Lock()
+	entries := p.entries
+	p.entries = nil
+	p.mu.Unlock()
+
+	var errs []error
+	for len(entries) > 0 {
+		n := min(len(entries), lokiMaxBatch)
+		start := time.Now()
+		err := p.push(ctx, entries[:n])
+		if err != nil {
+			p.stats.logsDropped.Add(uint64(n))
+			errs = append(errs, p.stats.recordExport(ctx, signalLogs, start, err))
+		} else {
+			p.stats.recordExport(ctx, signalLogs, start, nil)
+		}
+		entries = entries[n:]
+	}
+	return errors.Join(errs...)
+}
+
+// push sends one batch of entries to the push API.
+func (p *lokiPusher) push(ctx context.Context, entries [][2]string) error {
+	type stream struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	}
+	body, err := json.Marshal(struct {
+		Streams []stream `json:"streams"`
+	}{Streams: []stream{{Stream: p.labels, Values: entries}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if p.user != nil {
+		password, _ := p.user.Password()
+		req.SetBasicAuth(p.user.Username(), password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("Loki push failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// Shutdown stops the pusher and pushes the records queued so far.
+func (p *lokiPusher) Shutdown(ctx context.Context) error {
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		return fmt.Errorf("failed to shutdown Loki pusher: %w", ctx.Err())
+	}
+	if err := p.ForceFlush(ctx); err != nil {
+		return fmt.Errorf("failed to push logs to Loki: %w", err)
+	}
+	return nil
+}
+
+// ShutdownOrLog implements the Shutdowner interface.
+func (p *lokiPusher) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(p, msg)
+}
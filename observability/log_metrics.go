@@ -0,0 +1,122 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// LogMetricPattern counts the log records whose message matches Pattern, as
+// log.records.matched with a log.pattern attribute of Name, so that alerts
+// can fire on specific failures, such as "payment declined", without
+// instrumenting them.
+type LogMetricPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// logMetrics counts log records by level and by message pattern through the
+// metrics pipeline. Records logged before the instruments are registered
+// are not counted.
+type logMetrics struct {
+	patterns    []LogMetricPattern
+	instruments atomic.Pointer[logMetricInstruments]
+}
+
+type logMetricInstruments struct {
+	records metric.Int64Counter
+	matched metric.Int64Counter
+	// levels holds the attribute sets of the standard levels, indexed
+	// by logLevelIndex.
+	levels [4]metric.MeasurementOption
+	// patterns holds the attribute set of each pattern.
+	patterns []metric.MeasurementOption
+}
+
+// register creates the instruments in meter and starts counting.
+func (m *logMetrics) register(meter metric.Meter) error {
+	records, err := meter.Int64Counter("log.records", metric.WithDescription("Number of log records, by level"), metric.WithUnit("{record}"))
+	if err != nil {
+		return err
+	}
+	matched, err := meter.Int64Counter("log.records.matched", metric.WithDescription("Number of log records whose message matches a pattern, by pattern"), metric.WithUnit("{record}"))
+	if err != nil {
+		return err
+	}
+	instruments := &logMetricInstruments{records: records, matched: matched}
+	for i, level := range []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError} {
+		instruments.levels[i] = logLevelAttrs(level)
+	}
+	for _, p := range m.patterns {
+		instruments.patterns = append(instruments.patterns, metric.WithAttributeSet(attribute.NewSet(attribute.String("log.pattern", p.Name))))
+	}
+	m.instruments.Store(instruments)
+	return nil
+}
+
+func logLevelAttrs(level slog.Level) metric.MeasurementOption {
+	return metric.WithAttributeSet(attribute.NewSet(attribute.String("log.level", level.String())))
+}
+
+// logLevelIndex returns the index of level in logMetricInstruments.levels,
+// or -1 if it is not a standard level.
+func logLevelIndex(level slog.Level) int {
+	switch level {
+	case slog.LevelDebug:
+		return 0
+	case slog.LevelInfo:
+		return 1
+	case slog.LevelWarn:
+		return 2
+	case slog.LevelError:
+		return 3
+	}
+	return -1
+}
+
+// count counts r.
+func (m *logMetrics) count(ctx context.Context, r slog.Record) {
+	instruments := m.instruments.Load()
+	if instruments == nil {
+		return
+	}
+	if i := logLevelIndex(r.Level); i >= 0 {
+		instruments.records.Add(ctx, 1, instruments.levels[i])
+	} else {
+		instruments.records.Add(ctx, 1, logLevelAttrs(r.Level))
+	}
+	for i, p := range m.patterns {
+		if p.Pattern.MatchString(r.Message) {
+			instruments.matched.Add(ctx, 1, instruments.patterns[i])
+		}
+	}
+}
+
+// logMetricsHandler counts the records it handles in its logMetrics. It sits
+// in front of the asynchronous handler, so records dropped from a full
+// buffer are counted too.
+type logMetricsHandler struct {
+	slog.Handler
+	metrics *logMetrics
+}
+
+func (h logMetricsHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.metrics.count(ctx, r)
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h logMetricsHandler) wantsSource(level slog.Level) bool {
+	return forwardsSource(h.Handler, level)
+}
+
+func (h logMetricsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return logMetricsHandler{h.Handler.WithAttrs(attrs), h.metrics}
+}
+
+func (h logMetricsHandler) WithGroup(name string) slog.Handler {
+	return logMetricsHandler{h.Handler.WithGroup(name), h.metrics}
+}
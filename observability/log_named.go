@@ -0,0 +1,106 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+// LoggerNameKey is the attribute under which the records of a logger
+// returned by Log.Named carry its name.
+const LoggerNameKey = "logger"
+
+// Named returns a child logger for the subsystem name, such as "db", whose
+// records carry the name as the logger attribute. Naming a named logger
+// joins the names with a dot, so Named("db").Named("pool") is "db.pool".
+//
+// The minimum level of the child is the one WithLoggerLevels sets for the
+// longest dotted prefix of its name, so a level for "http" applies to
+// "http.client" too unless "http.client" has its own. Per-name levels can
+// only silence a logger further: records below the global log level are
+// dropped whatever the name.
+func (l *Log) Named(name string) *Log {
+	handler := l.logger.Handler()
+	if h, ok := handler.(namedHandler); ok {
+		handler, name = h.Handler, h.name+"."+name
+	}
+	named := namedHandler{Handler: handler, name: name}
+	if level, ok := loggerLevel(l.obs.providers.loggerLevels, name); ok {
+		named.level = level
+	}
+	return &Log{
+		obs:    l.obs,
+		logger: slog.New(named),
+	}
+}
+
+// namedHandler adds the name of a logger returned by Log.Named to its
+// records, and drops those below its level, if it has one. The name is
+// added when records are handled, rather than with WithAttrs, so that
+// naming a named logger replaces the name and level instead of adding to
+// them.
+type namedHandler struct {
+	slog.Handler
+	name  string
+	level slog.Leveler
+}
+
+func (h namedHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.level != nil && level < h.level.Level() {
+		return false
+	}
+	return h.Handler.Enabled(ctx, level)
+}
+
+func (h namedHandler) Handle(ctx context.Context, r slog.Record) error {
+	r.AddAttrs(slog.String(LoggerNameKey, h.name))
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h namedHandler) wantsSource(level slog.Level) bool {
+	return forwardsSource(h.Handler, level)
+}
+
+func (h namedHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return namedHandler{h.Handler.WithAttrs(attrs), h.name, h.level}
+}
+
+// WithGroup fixes the name outside the group, so it is not qualified by
+// it; a logger named after that gets a name of its own within the group.
+func (h namedHandler) WithGroup(name string) slog.Handler {
+	var handler slog.Handler = h.Handler.WithAttrs([]slog.Attr{slog.String(LoggerNameKey, h.name)}).WithGroup(name)
+	if h.level != nil {
+		handler = levelHandler{handler, h.level}
+	}
+	return handler
+}
+
+// loggerLevel returns the level of the longest dotted prefix of name in
+// levels, and whether there is one.
+func loggerLevel(levels map[string]slog.Level, name string) (slog.Level, bool) {
+	for {
+		if level, ok := levels[name]; ok {
+			return level, true
+		}
+		i := strings.LastIndexByte(name, '.')
+		if i < 0 {
+			return 0, false
+		}
+		name = name[:i]
+	}
+}
+
+// parseLoggerLevels parses the comma-separated name=level pairs of
+// OBS_LOGGER_LEVELS, such as "db=warn,http.client=error". Pairs without a
+// name are skipped.
+func parseLoggerLevels(val string) map[string]slog.Level {
+	levels := make(map[string]slog.Level)
+	for _, pair := range strings.Split(val, ",") {
+		name, level, _ := strings.Cut(pair, "=")
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		levels[name] = parseLogLevel(strings.TrimSpace(level))
+	}
+	return levels
+}
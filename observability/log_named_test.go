@@ -0,0 +1,87 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"testing"
+)
+
+func TestParseLoggerLevels(t *testing.T) {
+	tests := []struct {
+		val  string
+		want map[string]slog.Level
+	}{
+		{"", map[string]slog.Level{}},
+		{"db=warn", map[string]slog.Level{"db": slog.LevelWarn}},
+		{
+			"db=warn,http.client=error, cache = debug",
+			map[string]slog.Level{"db": slog.LevelWarn, "http.client": slog.LevelError, "cache": slog.LevelDebug},
+		},
+		{"db=verbose,queue", map[string]slog.Level{"db": slog.LevelInfo, "queue": slog.LevelInfo}},
+		{"=warn,,db=error,", map[string]slog.Level{"db": slog.LevelError}},
+		{"db=warn,db=error", map[string]slog.Level{"db": slog.LevelError}},
+	}
+	for _, tt := range tests {
+		if got := parseLoggerLevels(tt.val); !maps.Equal(got, tt.want) {
+			t.Errorf("parseLoggerLevels(%q) = %v, want %v", tt.val, got, tt.want)
+		}
+	}
+}
+
+func TestLoggerLevel(t *testing.T) {
+	levels := map[string]slog.Level{
+		"http":        slog.LevelWarn,
+		"http.client": slog.LevelError,
+		"db":          slog.LevelDebug,
+	}
+	tests := []struct {
+		name   string
+		want   slog.Level
+		wantOK bool
+	}{
+		{"http", slog.LevelWarn, true},
+		{"http.server", slog.LevelWarn, true},
+		{"http.client", slog.LevelError, true},
+		{"http.client.retry", slog.LevelError, true},
+		{"db.pool", slog.LevelDebug, true},
+		{"httpx", 0, false},
+		{"cache", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := loggerLevel(levels, tt.name)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("loggerLevel(%q) = %v, %v; want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestNamed(t *testing.T) {
+	var buf bytes.Buffer
+	obs := &Observability{ctx: context.Background()}
+	obs.providers.loggerLevels = map[string]slog.Level{"db": slog.LevelWarn, "db.pool": slog.LevelDebug}
+	log := &Log{obs: obs, logger: slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))}
+
+	tests := []struct {
+		log      *Log
+		level    slog.Level
+		wantName string
+	}{
+		{log.Named("db"), slog.LevelInfo, ""},
+		{log.Named("db"), slog.LevelWarn, "db"},
+		{log.Named("db").Named("pool"), slog.LevelDebug, "db.pool"},
+		{log.Named("http"), slog.LevelDebug, "http"},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		tt.log.Logc(tt.level, 2, "message")
+		var got map[string]any
+		json.Unmarshal(buf.Bytes(), &got)
+		if name, _ := got[LoggerNameKey].(string); name != tt.wantName {
+			t.Errorf("%v record: logger = %q, want %q", tt.level, name, tt.wantName)
+		}
+	}
+}
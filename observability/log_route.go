@@ -0,0 +1,160 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+)
+
+// LogRoute sends log records that carry specific attribute values to a
+// dedicated sink, for example to keep audit records in separate storage with
+// a longer retention. Routes are evaluated in the handler chain, after trace
+// correlation, so routed records carry trace and span IDs too.
+type LogRoute struct {
+	// Attrs lists the attribute values a record must carry, all of them, to
+	// match. Keys of attributes inside groups are qualified with the group
+	// names ("http.tenant"), and values are compared in their string form, so
+	// {"audit": "true"} matches slog.Bool("audit", true). Attributes added
+	// with Logger.With count as well. An empty Attrs matches every record.
+	Attrs map[string]string
+	// Sink receives the matching records that it is enabled for.
+	Sink slog.Handler
+	// Exclusive keeps matching records out of the default output.
+	Exclusive bool
+}
+
+// attrMatch is one key and value a route requires.
+type attrMatch struct {
+	key   string
+	value string
+}
+
+// routeState is a route as seen by one handler in a WithAttrs/WithGroup chain.
+type routeState struct {
+	exclusive bool
+	sink      slog.Handler
+	// pending lists the required attributes not already supplied by
+	// attributes added with WithAttrs.
+	pending []attrMatch
+}
+
+// routeHandler forwards each record to the sinks of the routes it matches and,
+// unless one of them is exclusive, to the default handler.
+type routeHandler struct {
+	base   slog.Handler
+	routes []routeState
+	// prefix qualifies attribute keys with the groups opened by WithGroup.
+	prefix string
+}
+
+func newRouteHandler(base slog.Handler, routes []LogRoute) *routeHandler {
+	h := &routeHandler{base: base, routes: make([]routeState, 0, len(routes))}
+	for _, route := range routes {
+		pending := make([]attrMatch, 0, len(route.Attrs))
+		for k, v := range route.Attrs {
+			pending = append(pending, attrMatch{key: k, value: v})
+		}
+		sort.Slice(pending, func(i, j int) bool { return pending[i].key < pending[j].key })
+		h.routes = append(h.routes, routeState{exclusive: route.Exclusive, sink: route.Sink, pending: pending})
+	}
+	return h
+}
+
+func (h *routeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.base.Enabled(ctx, level) {
+		return true
+	}
+	for _, rt := range h.routes {
+		if rt.sink.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *routeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	exclusive := false
+	for _, rt := range h.routes {
+		if !rt.sink.Enabled(ctx, r.Level) || !rt.matches(h.prefix, r) {
+			continue
+		}
+		if err := rt.sink.Handle(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+		exclusive = exclusive || rt.exclusive
+	}
+	if !exclusive && h.base.Enabled(ctx, r.Level) {
+		if err := h.base.Handle(ctx, r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *routeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	routes := make([]routeState, len(h.routes))
+	for i, rt := range h.routes {
+		var found uint64
+		for _, a := range attrs {
+			found |= matchAttr(rt.pending, h.prefix, a)
+		}
+		pending := make([]attrMatch, 0, len(rt.pending))
+		for j, m := range rt.pending {
+			if found&(1<<j) == 0 {
+				pending = append(pending, m)
+			}
+		}
+		routes[i] = routeState{exclusive: rt.exclusive, sink: rt.sink.WithAttrs(attrs), pending: pending}
+	}
+	return &routeHandler{base: h.base.WithAttrs(attrs), routes: routes, prefix: h.prefix}
+}
+
+func (h *routeHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	routes := make([]routeState, len(h.routes))
+	for i, rt := range h.routes {
+		routes[i] = routeState{exclusive: rt.exclusive, sink: rt.sink.WithGroup(name), pending: rt.pending}
+	}
+	return &routeHandler{base: h.base.WithGroup(name), routes: routes, prefix: h.prefix + name + "."}
+}
+
+// matches reports whether r carries every pending attribute of the route.
+func (rt *routeState) matches(prefix string, r slog.Record) bool {
+	if len(rt.pending) == 0 {
+		return true
+	}
+	all := uint64(1)<<len(rt.pending) - 1
+	var found uint64
+	r.Attrs(func(a slog.Attr) bool {
+		found |= matchAttr(rt.pending, prefix, a)
+		return found != all
+	})
+	return found == all
+}
+
+// matchAttr returns a bitmask of the entries in want that a satisfies,
+// descending into groups. Routes are limited to 64 required attributes.
+func matchAttr(want []attrMatch, prefix string, a slog.Attr) uint64 {
+	v := a.Value.Resolve()
+	if v.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		var found uint64
+		for _, ga := range v.Group() {
+			found |= matchAttr(want, prefix, ga)
+		}
+		return found
+	}
+	var found uint64
+	for i, m := range want {
+		if i < 64 && len(m.key) == len(prefix)+len(a.Key) && m.key[:len(prefix)] == prefix && m.key[len(prefix):] == a.Key && m.value == v.String() {
+			found |= 1 << i
+		}
+	}
+	return found
+}
@@ -0,0 +1,240 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log outputs, set with WithLogOutput.
+const (
+	logOutputStdout   = "stdout"
+	logOutputSyslog   = "syslog"
+	logOutputJournald = "journald"
+)
+
+// Sockets of the local syslog daemon, in the order they are tried, as
+// log/syslog does.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// journaldSocket is the socket of the systemd journal's native protocol.
+const journaldSocket = "/run/systemd/journal/socket"
+
+// logSink is a destination of log records that needs their level, such as
+// syslog, whose messages carry a priority.
+type logSink interface {
+	// write sends one record, without its trailing newline.
+	write(level slog.Level, record []byte) error
+	Close() error
+}
+
+// openLogSink connects to the sink of output, identifying the records as
+// coming from identifier.
+func openLogSink(output, identifier string) (logSink, error) {
+	switch strings.ToLower(output) {
+	case logOutputSyslog:
+		return dialSyslog(identifier)
+	case logOutputJournald:
+		return dialJournald(identifier)
+	}
+	return nil, fmt.Errorf("unknown log output %q; use \"stdout\", \"syslog\", or \"journald\"", output)
+}
+
+// syslogSeverity maps a level to its syslog severity, which the journal
+// uses as its priority too.
+func syslogSeverity(level slog.Level) int {
+	switch {
+	case level > slog.LevelError:
+		return 2 // crit
+	case level >= slog.LevelError:
+		return 3 // err
+	case level >= slog.LevelWarn:
+		return 4 // warning
+	case level >= slog.LevelInfo:
+		return 6 // info
+	default:
+		return 7 // debug
+	}
+}
+
+// sinkWriter is the output of the JSON handler when logs go to a logSink.
+// The JSON handler writes each record with a single Write, which
+// sinkWriter sends to the sink with the level that sinkHandler set for it.
+type sinkWriter struct {
+	mu    sync.Mutex
+	sink  logSink
+	level slog.Level
+}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	if w.sink == nil {
+		return 0, os.ErrClosed
+	}
+	if err := w.sink.write(w.level, bytes.TrimSuffix(p, []byte("\n"))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Shutdown closes the sink; records logged after it fail.
+func (w *sinkWriter) Shutdown(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.sink == nil {
+		return nil
+	}
+	err := w.sink.Close()
+	w.sink = nil
+	return err
+}
+
+// ShutdownOrLog implements the Shutdowner interface.
+func (w *sinkWriter) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(w, msg)
+}
+
+// sinkHandler holds its sinkWriter while the JSON handler writes a record,
+// so the writer knows the record's level.
+type sinkHandler struct {
+	slog.Handler
+	w *sinkWriter
+}
+
+func (h sinkHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.w.mu.Lock()
+	defer h.w.mu.Unlock()
+	h.w.level = r.Level
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h sinkHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return sinkHandler{h.Handler.WithAttrs(attrs), h.w}
+}
+
+func (h sinkHandler) WithGroup(name string) slog.Handler {
+	return sinkHandler{h.Handler.WithGroup(name), h.w}
+}
+
+// socketSink sends datagrams, or stream writes, to a local socket, and
+// reconnects once if sending fails, as it does when the daemon restarts.
+type socketSink struct {
+	addrs []string
+	conn  net.Conn
+}
+
+func (s *socketSink) dial() error {
+	var errs []error
+	for _, addr := range s.addrs {
+		for _, network := range []string{"unixgram", "unix"} {
+			conn, err := net.Dial(network, addr)
+			if err == nil {
+				s.conn = conn
+				return nil
+			}
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *socketSink) send(msg []byte) error {
+	if s.conn != nil {
+		if _, err := s.conn.Write(msg); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	if err := s.dial(); err != nil {
+		return err
+	}
+	_, err := s.conn.Write(msg)
+	return err
+}
+
+func (s *socketSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	return s.conn.Close()
+}
+
+// syslogSink writes records to the local syslog daemon, in the format of
+// log/syslog, with the user facility.
+type syslogSink struct {
+	socketSink
+	tag string
+	buf []byte
+}
+
+func dialSyslog(tag string) (*syslogSink, error) {
+	s := &syslogSink{socketSink: socketSink{addrs: syslogSockets}, tag: tag}
+	if err := s.dial(); err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return s, nil
+}
+
+func (s *syslogSink) write(level slog.Level, record []byte) error {
+	const facilityUser = 1
+	s.buf = append(s.buf[:0], '<')
+	s.buf = strconv.AppendInt(s.buf, facilityUser<<3|int64(syslogSeverity(level)), 10)
+	s.buf = append(s.buf, '>')
+	s.buf = time.Now().AppendFormat(s.buf, time.Stamp)
+	s.buf = append(s.buf, ' ')
+	s.buf = append(s.buf, s.tag...)
+	s.buf = append(s.buf, '[')
+	s.buf = strconv.AppendInt(s.buf, int64(os.Getpid()), 10)
+	s.buf = append(s.buf, "]: "...)
+	s.buf = append(s.buf, record...)
+	s.buf = append(s.buf, '\n')
+	return s.send(s.buf)
+}
+
+// journaldSink writes records to the systemd journal over its native
+// protocol, with the record as the MESSAGE field.
+type journaldSink struct {
+	socketSink
+	identifier string
+	buf        []byte
+}
+
+func dialJournald(identifier string) (*journaldSink, error) {
+	s := &journaldSink{socketSink: socketSink{addrs: []string{journaldSocket}}, identifier: identifier}
+	if err := s.dial(); err != nil {
+		return nil, fmt.Errorf("failed to connect to the systemd journal: %w", err)
+	}
+	return s, nil
+}
+
+func (s *journaldSink) write(level slog.Level, record []byte) error {
+	s.buf = appendJournalField(s.buf[:0], "PRIORITY", strconv.AppendInt(nil, int64(syslogSeverity(level)), 10))
+	s.buf = appendJournalField(s.buf, "SYSLOG_IDENTIFIER", []byte(s.identifier))
+	s.buf = appendJournalField(s.buf, "MESSAGE", record)
+	return s.send(s.buf)
+}
+
+// appendJournalField appends a field in the journal's native format: KEY=value
+// on a line, or, for values with newlines, the key on a line, followed by
+// the value's length as a little-endian uint64 and the value.
+func appendJournalField(b []byte, key string, value []byte) []byte {
+	b = append(b, key...)
+	if bytes.IndexByte(value, '\n') < 0 {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+	b = append(b, '\n')
+	b = binary.LittleEndian.AppendUint64(b, uint64(len(value)))
+	b = append(b, value...)
+	return append(b, '\n')
+}
@@ -0,0 +1,91 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// StackTraceKey is the attribute under which error records carry their stack
+// trace with WithErrorStackTraces, following the OpenTelemetry exception
+// semantic conventions.
+const StackTraceKey = "exception.stacktrace"
+
+// maxStackFrames bounds the frames in a captured stack trace.
+const maxStackFrames = 32
+
+// packagePrefix is the prefix of the function names in this package, whose
+// frames are trimmed from the top of captured stack traces.
+var packagePrefix = func() string {
+	pc, _, _, _ := runtime.Caller(0)
+	// The name is the package path, a dot, and the function name.
+	name := runtime.FuncForPC(pc).Name()
+	slash := strings.LastIndex(name, "/")
+	return name[:slash+strings.Index(name[slash:], ".")+1]
+}()
+
+// stackHandler adds the stack trace of the logging goroutine to records at
+// error level or above. It sits outermost, so the stack is captured on the
+// goroutine that logged even when records are written asynchronously, and
+// the apmHandler records it on the span with the error.
+type stackHandler struct {
+	slog.Handler
+}
+
+func (h stackHandler) Handle(ctx context.Context, r slog.Record) error {
+	if r.Level >= slog.LevelError {
+		r.AddAttrs(slog.String(StackTraceKey, stackTrace()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h stackHandler) wantsSource(level slog.Level) bool {
+	return forwardsSource(h.Handler, level)
+}
+
+func (h stackHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return stackHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h stackHandler) WithGroup(name string) slog.Handler {
+	return stackHandler{h.Handler.WithGroup(name)}
+}
+
+// stackTrace formats the stack of the calling goroutine as runtime/debug.Stack
+// does, without the frames of log/slog and this package above the code that
+// logged, and with at most maxStackFrames frames.
+func stackTrace() string {
+	var pcs [maxStackFrames + 16]uintptr
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var b strings.Builder
+	trimming := true
+	count := 0
+	for {
+		frame, more := frames.Next()
+		if trimming && (strings.HasPrefix(frame.Function, "log/slog.") || strings.HasPrefix(frame.Function, packagePrefix)) {
+			if !more {
+				break
+			}
+			continue
+		}
+		trimming = false
+		if frame.Function == "runtime.goexit" || count == maxStackFrames {
+			break
+		}
+		b.WriteString(frame.Function)
+		b.WriteString("\n\t")
+		b.WriteString(frame.File)
+		b.WriteByte(':')
+		b.WriteString(strconv.Itoa(frame.Line))
+		b.WriteByte('\n')
+		count++
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
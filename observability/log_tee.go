@@ -0,0 +1,165 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// Formats of LogSink records.
+const (
+	logFormatJSON = "json"
+	logFormatText = "text"
+)
+
+// LogSink is one of several destinations log records are written to at
+// once with WithLogSinks, with its own level and format. Set one of
+// Writer, Path, and Handler.
+type LogSink struct {
+	// Name identifies the sink in the errors it returns.
+	Name string
+	// Writer receives the records, such as os.Stdout.
+	Writer io.Writer
+	// Path is a file the records are appended to, rotated as Rotation
+	// says. Setup opens it and shutdown closes it.
+	Path     string
+	Rotation FileRotation
+	// Handler receives the records instead of being written by the
+	// factory, such as a bridge to an OpenTelemetry logs SDK. Format does
+	// not apply to it.
+	Handler slog.Handler
+	// Level is the minimum level of the records written to the sink. If
+	// nil, the factory's log level applies.
+	Level slog.Leveler
+	// Format is how Writer and Path records are written: "json" (the
+	// default), in the factory's log schema, or "text" for slog's
+	// key=value format.
+	Format string
+}
+
+// teeSink is a LogSink resolved to the handler that writes its records.
+type teeSink struct {
+	name    string
+	handler slog.Handler
+}
+
+// newTeeSink returns the handler of sink, which writes to sink.Writer if
+// sink has no handler of its own. Records are written with opts, except
+// for the level of the sink.
+func newTeeSink(sink LogSink, opts slog.HandlerOptions) teeSink {
+	if sink.Level != nil {
+		opts.Level = sink.Level
+	}
+	var handler slog.Handler
+	switch {
+	case sink.Handler != nil:
+		handler = sink.Handler
+		if sink.Level != nil {
+			handler = levelHandler{handler, sink.Level}
+		}
+	case strings.EqualFold(sink.Format, logFormatText):
+		// The Cloud Logging field names only make sense in JSON.
+		opts.ReplaceAttr = nil
+		handler = slog.NewTextHandler(sink.Writer, &opts)
+	default:
+		handler = slog.NewJSONHandler(sink.Writer, &opts)
+	}
+	return teeSink{name: sink.Name, handler: handler}
+}
+
+// teeHandler writes each record to every sink enabled for its level. The
+// sinks fail independently: a sink that returns an error, or panics, does
+// not keep the record from the others, and its error is returned once all
+// have been tried.
+type teeHandler struct {
+	sinks []teeSink
+}
+
+func (h teeHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, s := range h.sinks {
+		if s.handler.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (h teeHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, s := range h.sinks {
+		if !s.handler.Enabled(ctx, r.Level) {
+			continue
+		}
+		// Each sink gets its own copy, so attributes one adds are not
+		// seen by the next.
+		if err := s.handle(ctx, r.Clone()); err != nil {
+			errs = append(errs, fmt.Errorf("log sink %s: %w", s.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// handle writes r to the sink, turning a panic into an error.
+func (s teeSink) handle(ctx context.Context, r slog.Record) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return s.handler.Handle(ctx, r)
+}
+
+func (h teeHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	sinks := make([]teeSink, len(h.sinks))
+	for i, s := range h.sinks {
+		sinks[i] = teeSink{name: s.name, handler: s.handler.WithAttrs(attrs)}
+	}
+	return teeHandler{sinks}
+}
+
+func (h teeHandler) WithGroup(name string) slog.Handler {
+	sinks := make([]teeSink, len(h.sinks))
+	for i, s := range h.sinks {
+		sinks[i] = teeSink{name: s.name, handler: s.handler.WithGroup(name)}
+	}
+	return teeHandler{sinks}
+}
+
+// logFile is a LogSink file, closed at shutdown.
+type logFile struct {
+	file *rotatingFile
+}
+
+func (f *logFile) Shutdown(ctx context.Context) error {
+	return errors.Join(f.file.Sync(), f.file.Close())
+}
+
+// ShutdownOrLog implements the Shutdowner interface.
+func (f *logFile) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(f, msg)
+}
+
+// levelHandler raises the minimum level of a handler.
+type levelHandler struct {
+	slog.Handler
+	level slog.Leveler
+}
+
+func (h levelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.level.Level() && h.Handler.Enabled(ctx, level)
+}
+
+func (h levelHandler) wantsSource(level slog.Level) bool {
+	return forwardsSource(h.Handler, level)
+}
+
+func (h levelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return levelHandler{h.Handler.WithAttrs(attrs), h.level}
+}
+
+func (h levelHandler) WithGroup(name string) slog.Handler {
+	return levelHandler{h.Handler.WithGroup(name), h.level}
+}
@@ -0,0 +1,107 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextSpanFactory reports the IDs of the span context in ctx, so tests
+// see whether the context reached the handler.
+type contextSpanFactory struct{}
+
+func (contextSpanFactory) Start(ctx context.Context, _ string) (context.Context, Span) {
+	return ctx, benchSpan{}
+}
+func (contextSpanFactory) SpanFromContext(context.Context) Span { return nil }
+func (contextSpanFactory) TraceIDs(ctx context.Context) (string, string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return "", ""
+	}
+	return sc.TraceID().String(), sc.SpanID().String()
+}
+func (contextSpanFactory) Inject(context.Context, http.Header) {}
+func (contextSpanFactory) Extract(ctx context.Context, _ http.Header) context.Context {
+	return ctx
+}
+
+func TestAsyncLoggingKeepsContextFields(t *testing.T) {
+	var buf bytes.Buffer
+	apm := &apmHandler{
+		Handler:       slog.NewJSONHandler(&buf, nil),
+		spans:         contextSpanFactory{},
+		traceLogLevel: slog.LevelError,
+	}
+	async := newAsyncHandler(apm, 2, false)
+
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithUser(ctx, "user-1")
+	ctx = AdoptWorkflow(ctx, "wf-1")
+	ctx, cancel := context.WithCancel(ctx)
+	// The request may be over before the record is written.
+	cancel()
+
+	slog.New(async).InfoContext(ctx, "handled")
+	flushCtx, done := context.WithTimeout(context.Background(), time.Second)
+	defer done()
+	if err := async.ForceFlush(flushCtx); err != nil {
+		t.Fatal(err)
+	}
+	async.ShutdownOrLog("shutdown")
+
+	var got map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	want := map[string]string{
+		"trace.id":    "4bf92f3577b34da6a3ce929d0e0e4736",
+		"span.id":     "00f067aa0ba902b7",
+		RequestIDKey:  "req-1",
+		UserIDKey:     "user-1",
+		WorkflowIDKey: "wf-1",
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %v, want %q", k, got[k], v)
+		}
+	}
+}
+
+// newSourceTestObservability returns an Observability whose records, with
+// their source, are written to buf as JSON.
+func newSourceTestObservability(buf *bytes.Buffer) *Observability {
+	p := defaultProviders(None)
+	p.logger = slog.New(&apmHandler{
+		Handler:       slog.NewJSONHandler(buf, &slog.HandlerOptions{AddSource: true}),
+		spans:         contextSpanFactory{},
+		traceLogLevel: slog.LevelError,
+		addSource:     true,
+	})
+	return newObservability(context.Background(), "test", None, p)
+}
+
+// recordSource decodes the source function of the JSON record in buf.
+func recordSource(t *testing.T, buf *bytes.Buffer) string {
+	t.Helper()
+	var record struct {
+		Source slog.Source `json:"source"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("decoding %q: %v", buf.String(), err)
+	}
+	return record.Source.Function
+}
@@ -0,0 +1,135 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Attribute keys used for request metadata on spans and logs.
+const (
+	UserIDKey    = "user.id"
+	TenantIDKey  = "tenant.id"
+	SessionIDKey = "session.id"
+	RequestIDKey = "request.id"
+)
+
+// requestMetadataKey is the private context key for requestMetadata.
+type requestMetadataKey struct{}
+
+// requestMetadata holds the well-known identifiers of the current request.
+// It is stored in the context as a single value so that reading it costs one
+// lookup per span or log record.
+type requestMetadata struct {
+	user    string
+	tenant  string
+	session string
+	request string
+}
+
+// WithUser returns a copy of ctx carrying the user ID. The ID is added as a
+// "user.id" attribute to the active span, to spans started from the returned
+// context, and to every log record written through its Observability.
+func WithUser(ctx context.Context, id string) context.Context {
+	return withMetadata(ctx, attribute.String(UserIDKey, id), func(md *requestMetadata) { md.user = id })
+}
+
+// UserFrom returns the user ID stored by WithUser, or "" if there is none.
+func UserFrom(ctx context.Context) string {
+	return metadataFrom(ctx).user
+}
+
+// WithTenant returns a copy of ctx carrying the tenant ID, recorded as a
+// "tenant.id" attribute on spans and logs like WithUser.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return withMetadata(ctx, attribute.String(TenantIDKey, id), func(md *requestMetadata) { md.tenant = id })
+}
+
+// TenantFrom returns the tenant ID stored by WithTenant, or "" if there is none.
+func TenantFrom(ctx context.Context) string {
+	return metadataFrom(ctx).tenant
+}
+
+// WithSession returns a copy of ctx carrying the session ID, recorded as a
+// "session.id" attribute on spans and logs like WithUser.
+func WithSession(ctx context.Context, id string) context.Context {
+	return withMetadata(ctx, attribute.String(SessionIDKey, id), func(md *requestMetadata) { md.session = id })
+}
+
+// SessionFrom returns the session ID stored by WithSession, or "" if there is none.
+func SessionFrom(ctx context.Context) string {
+	return metadataFrom(ctx).session
+}
+
+// WithRequestID returns a copy of ctx carrying the request ID, recorded as a
+// "request.id" attribute on spans and logs like WithUser.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return withMetadata(ctx, attribute.String(RequestIDKey, id), func(md *requestMetadata) { md.request = id })
+}
+
+// RequestIDFrom returns the request ID stored by WithRequestID, or "" if there is none.
+func RequestIDFrom(ctx context.Context) string {
+	return metadataFrom(ctx).request
+}
+
+func metadataFrom(ctx context.Context) requestMetadata {
+	md, _ := ctx.Value(requestMetadataKey{}).(requestMetadata)
+	return md
+}
+
+// withMetadata stores an updated copy of the request metadata in ctx and tags
+// the active span with attr.
+func withMetadata(ctx context.Context, attr attribute.KeyValue, update func(*requestMetadata)) context.Context {
+	md := metadataFrom(ctx)
+	update(&md)
+	return rebindObs(context.WithValue(ctx, requestMetadataKey{}, md), attr)
+}
+
+// rebindObs tags the active span in ctx with attr. If ctx carries an
+// Observability, it is replaced by one bound to ctx, so ObsFromCtx(ctx).Log
+// and spans started with StartSpanFromCtx see values added to ctx after the
+// Observability was created.
+func rebindObs(ctx context.Context, attr attribute.KeyValue) context.Context {
+	if obs, ok := ctx.Value(obsKey{}).(*Observability); ok {
+		if span := obs.Trace.spans.SpanFromContext(ctx); span != nil {
+			span.SetAttributes(attr)
+		}
+		ctx = ctxWithObs(ctx, obs.clone(ctx))
+	}
+	return ctx
+}
+
+// spanAttributes returns the metadata that is set, as span attributes.
+func (md requestMetadata) spanAttributes() []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	if md.user != "" {
+		attrs = append(attrs, attribute.String(UserIDKey, md.user))
+	}
+	if md.tenant != "" {
+		attrs = append(attrs, attribute.String(TenantIDKey, md.tenant))
+	}
+	if md.session != "" {
+		attrs = append(attrs, attribute.String(SessionIDKey, md.session))
+	}
+	if md.request != "" {
+		attrs = append(attrs, attribute.String(RequestIDKey, md.request))
+	}
+	return attrs
+}
+
+// addToRecord adds the metadata that is set to a log record.
+func (md requestMetadata) addToRecord(r *slog.Record) {
+	if md.user != "" {
+		r.AddAttrs(slog.String(UserIDKey, md.user))
+	}
+	if md.tenant != "" {
+		r.AddAttrs(slog.String(TenantIDKey, md.tenant))
+	}
+	if md.session != "" {
+		r.AddAttrs(slog.String(SessionIDKey, md.session))
+	}
+	if md.request != "" {
+		r.AddAttrs(slog.String(RequestIDKey, md.request))
+	}
+}
@@ -1,7 +1,9 @@
 package observability
 
 import (
-	"go.opentelemetry.io/otel"
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 )
 
@@ -15,11 +17,87 @@ type Metrics struct {
 func newMetrics(obs *Observability) *Metrics {
 	return &Metrics{
 		obs:   obs,
-		meter: otel.GetMeterProvider().Meter(obs.serviceName),
+		meter: obs.providers.meters.Meter(obs.serviceName),
 	}
 }
 
-// Counter creates a new float64 counter.
+// prefixed returns name with the WithMetricPrefix prefix.
+func (m *Metrics) prefixed(name string) string {
+	return m.obs.providers.metricPrefix + name
+}
+
+// Counter returns the float64 counter named name, creating it on first use.
+// Like the other synchronous instruments of Metrics, counters are cached by
+// name, so calling Counter on every request is cheap; options only take
+// effect when the instrument is created.
 func (m *Metrics) Counter(name string, opts ...metric.Float64CounterOption) (metric.Float64Counter, error) {
-	return m.meter.Float64Counter(name, opts...)
+	return cachedInstrument(m, "counter", name, func() (metric.Float64Counter, error) {
+		return m.meter.Float64Counter(m.prefixed(name), opts...)
+	})
+}
+
+// Histogram returns the float64 histogram named name, creating it on first
+// use, for distributions such as latencies or payload sizes. Pass
+// metric.WithUnit and metric.WithDescription to describe it, and
+// metric.WithExplicitBucketBoundaries to replace the default buckets.
+func (m *Metrics) Histogram(name string, opts ...metric.Float64HistogramOption) (metric.Float64Histogram, error) {
+	return cachedInstrument(m, "histogram", name, func() (metric.Float64Histogram, error) {
+		return m.meter.Float64Histogram(m.prefixed(name), opts...)
+	})
+}
+
+// Int64Histogram returns the int64 histogram named name, creating it on
+// first use, for distributions of whole numbers such as byte counts or batch
+// sizes.
+func (m *Metrics) Int64Histogram(name string, opts ...metric.Int64HistogramOption) (metric.Int64Histogram, error) {
+	return cachedInstrument(m, "int64_histogram", name, func() (metric.Int64Histogram, error) {
+		return m.meter.Int64Histogram(m.prefixed(name), opts...)
+	})
+}
+
+// UpDownCounter returns the int64 counter named name that can go up and
+// down, creating it on first use. It suits quantities such as active
+// sessions or in-flight jobs, which are tracked by adding and subtracting as
+// they change.
+func (m *Metrics) UpDownCounter(name string, opts ...metric.Int64UpDownCounterOption) (metric.Int64UpDownCounter, error) {
+	return cachedInstrument(m, "up_down_counter", name, func() (metric.Int64UpDownCounter, error) {
+		return m.meter.Int64UpDownCounter(m.prefixed(name), opts...)
+	})
+}
+
+// Gauge returns the float64 gauge named name, creating it on first use. A
+// gauge records the current value of something measured when it changes,
+// such as a configured pool size.
+func (m *Metrics) Gauge(name string, opts ...metric.Float64GaugeOption) (metric.Float64Gauge, error) {
+	return cachedInstrument(m, "gauge", name, func() (metric.Float64Gauge, error) {
+		return m.meter.Float64Gauge(m.prefixed(name), opts...)
+	})
+}
+
+// ObservableGauge reports the value returned by callback on every metric
+// collection, for state that is cheaper to read when needed than to track,
+// such as a queue's depth or a cache's size. callback must be safe for
+// concurrent use. Call Unregister on the returned registration to stop
+// reporting.
+func (m *Metrics) ObservableGauge(name string, callback func(ctx context.Context) float64, opts ...metric.Float64ObservableGaugeOption) (metric.Registration, error) {
+	gauge, err := m.meter.Float64ObservableGauge(m.prefixed(name), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return m.meter.RegisterCallback(func(ctx context.Context, o metric.Observer) error {
+		o.ObserveFloat64(gauge, callback(ctx))
+		return nil
+	}, gauge)
+}
+
+// Inc adds 1 to the counter named name, with attrs as the measurement's
+// attributes. It is shorthand for Counter(name) followed by Add, for hot
+// paths that count events; if the counter cannot be created, the event is
+// not counted.
+func (m *Metrics) Inc(name string, attrs ...attribute.KeyValue) {
+	counter, err := m.Counter(name)
+	if err != nil || counter == nil {
+		return
+	}
+	counter.Add(m.obs.ctx, 1, metric.WithAttributes(attrs...))
 }
@@ -0,0 +1,47 @@
+package observability
+
+import (
+	"sync"
+)
+
+// defaultInstruments caches the instruments of Observability instances that
+// report through the process-wide meter provider.
+var defaultInstruments = &instrumentCache{}
+
+// instrumentCache holds the instruments created through Metrics, so a hot
+// path can ask for an instrument by name on every call and get the one
+// created the first time. Each meter provider needs its own cache.
+type instrumentCache struct {
+	instruments sync.Map // instrumentKey -> instrumentEntry
+}
+
+type instrumentKey struct {
+	meter  string
+	prefix string
+	kind   string
+	name   string
+}
+
+type instrumentEntry struct {
+	instrument any
+	err        error
+}
+
+// cachedInstrument returns the instrument of the given kind and name from
+// m's cache, calling create only for the first request. Options passed to
+// later requests are ignored.
+func cachedInstrument[T any](m *Metrics, kind, name string, create func() (T, error)) (T, error) {
+	cache := m.obs.providers.instruments
+	if cache == nil {
+		return create()
+	}
+	key := instrumentKey{meter: m.obs.serviceName, prefix: m.obs.providers.metricPrefix, kind: kind, name: name}
+	if e, ok := cache.instruments.Load(key); ok {
+		entry := e.(instrumentEntry)
+		return entry.instrument.(T), entry.err
+	}
+	instrument, err := create()
+	e, _ := cache.instruments.LoadOrStore(key, instrumentEntry{instrument: instrument, err: err})
+	entry := e.(instrumentEntry)
+	return entry.instrument.(T), entry.err
+}
@@ -0,0 +1,56 @@
+package observability
+
+import (
+	"context"
+	"database/sql"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// ObserveDBPool reports the connection pool statistics of db on every metric
+// collection, following the OpenTelemetry database client semantic
+// conventions. All instruments carry a db.client.connection.pool.name
+// attribute set to name:
+//
+//   - db.client.connection.count: open connections, split by a
+//     db.client.connection.state attribute of "used" or "idle"
+//   - db.client.connection.max: the configured maximum of open connections
+//     (0 means unlimited)
+//   - db.client.connection.wait_count: total number of waits for a connection
+//   - db.client.connection.wait_time: total time spent waiting, in seconds
+//
+// Call Unregister on the returned registration when db is closed.
+func (m *Metrics) ObserveDBPool(name string, db *sql.DB) (metric.Registration, error) {
+	count, err := m.meter.Int64ObservableUpDownCounter("db.client.connection.count", metric.WithDescription("Number of connections that are currently in the state described by the state attribute"), metric.WithUnit("{connection}"))
+	if err != nil {
+		return nil, err
+	}
+	maxOpen, err := m.meter.Int64ObservableUpDownCounter("db.client.connection.max", metric.WithDescription("The maximum number of open connections allowed"), metric.WithUnit("{connection}"))
+	if err != nil {
+		return nil, err
+	}
+	waitCount, err := m.meter.Int64ObservableCounter("db.client.connection.wait_count", metric.WithDescription("The total number of connections waited for"), metric.WithUnit("{wait}"))
+	if err != nil {
+		return nil, err
+	}
+	waitTime, err := m.meter.Float64ObservableCounter("db.client.connection.wait_time", metric.WithDescription("The total time blocked waiting for a new connection"), metric.WithUnit("s"))
+	if err != nil {
+		return nil, err
+	}
+
+	pool := attribute.String("db.client.connection.pool.name", name)
+	poolAttrs := metric.WithAttributes(pool)
+	usedAttrs := metric.WithAttributes(pool, attribute.String("db.client.connection.state", "used"))
+	idleAttrs := metric.WithAttributes(pool, attribute.String("db.client.connection.state", "idle"))
+
+	return m.meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		stats := db.Stats()
+		o.ObserveInt64(count, int64(stats.InUse), usedAttrs)
+		o.ObserveInt64(count, int64(stats.Idle), idleAttrs)
+		o.ObserveInt64(maxOpen, int64(stats.MaxOpenConnections), poolAttrs)
+		o.ObserveInt64(waitCount, stats.WaitCount, poolAttrs)
+		o.ObserveFloat64(waitTime, stats.WaitDuration.Seconds(), poolAttrs)
+		return nil
+	}, count, maxOpen, waitCount, waitTime)
+}
@@ -0,0 +1,231 @@
+//go:build datadog || !(otlp || none)
+
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/datadog-go/v5/statsd"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// defaultDogStatsDAddr is the Agent's DogStatsD address used when neither the
+// metrics URL nor the Datadog environment variables name one.
+const defaultDogStatsDAddr = "localhost:8125"
+
+// dogstatsdMetricsProvider sends metrics to a Datadog Agent over DogStatsD.
+// Instruments are aggregated by the OpenTelemetry SDK and flushed to the
+// Agent on every collection, so the Metrics API works unchanged.
+type dogstatsdMetricsProvider struct{}
+
+// Setup creates the DogStatsD client and a MeterProvider exporting to it.
+// The service, environment, and version become the client's global tags,
+// along with the resource attributes.
+func (dogstatsdMetricsProvider) Setup(ctx context.Context, cfg MetricsConfig) (metric.MeterProvider, Shutdowner, error) {
+	addr := cfg.URL
+	if addr == "" && os.Getenv("DD_AGENT_HOST") == "" && os.Getenv("DD_DOGSTATSD_URL") == "" {
+		addr = defaultDogStatsDAddr
+	}
+	tags := []string{"service:" + cfg.ServiceName, "env:" + cfg.ServiceEnv}
+	if cfg.ServiceVersion != "" {
+		tags = append(tags, "version:"+cfg.ServiceVersion)
+	}
+	tags = append(tags, dogstatsdTags(attribute.NewSet(cfg.ResourceAttributes...))...)
+
+	client, err := statsd.New(addr, statsd.WithTags(tags))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create DogStatsD client: %w", err)
+	}
+
+	var exporter sdkmetric.Exporter = newDogStatsDExporter(client)
+	if cfg.stats != nil {
+		exporter = statsMetricExporter{Exporter: exporter, stats: cfg.stats}
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+	)
+	return mp, &dogstatsdShutdowner{provider: mp}, nil
+}
+
+// dogstatsdExporter is an sdkmetric.Exporter that sends each collection to
+// DogStatsD. Counters and histograms are collected as deltas and sent as
+// counts; everything else is sent as gauges. Histograms are sent as a
+// <name>.count count and <name>.avg, <name>.min, and <name>.max gauges of
+// the values recorded since the previous collection, since DogStatsD counts
+// cannot carry the fractional sums of durations in seconds.
+type dogstatsdExporter struct {
+	client *statsd.Client
+
+	// remainders carries the fractional part of float64 counts, which
+	// DogStatsD cannot send, into the next collection, keyed by metric
+	// name and tags.
+	mu         sync.Mutex
+	remainders map[string]float64
+}
+
+func newDogStatsDExporter(client *statsd.Client) *dogstatsdExporter {
+	return &dogstatsdExporter{client: client, remainders: make(map[string]float64)}
+}
+
+func (e *dogstatsdExporter) Temporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	return deltaTemporality(kind)
+}
+
+func (e *dogstatsdExporter) Aggregation(kind sdkmetric.InstrumentKind) sdkmetric.Aggregation {
+	return sdkmetric.DefaultAggregationSelector(kind)
+}
+
+// Export sends the metrics in rm. DogStatsD buffers and sends them in the
+// background, so errors only arise from a closed client.
+func (e *dogstatsdExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var errs []error
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if err := e.export(m); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *dogstatsdExporter) export(m metricdata.Metrics) error {
+	switch data := m.Data.(type) {
+	case metricdata.Sum[int64]:
+		return exportSum(e, m.Name, data, func(v int64) float64 { return float64(v) })
+	case metricdata.Sum[float64]:
+		return exportSum(e, m.Name, data, func(v float64) float64 { return v })
+	case metricdata.Gauge[int64]:
+		return exportGauge(e, m.Name, data, func(v int64) float64 { return float64(v) })
+	case metricdata.Gauge[float64]:
+		return exportGauge(e, m.Name, data, func(v float64) float64 { return v })
+	case metricdata.Histogram[int64]:
+		return exportHistogram(e, m.Name, data, func(v int64) float64 { return float64(v) })
+	case metricdata.Histogram[float64]:
+		return exportHistogram(e, m.Name, data, func(v float64) float64 { return v })
+	}
+	return nil
+}
+
+func exportSum[N int64 | float64](e *dogstatsdExporter, name string, data metricdata.Sum[N], toFloat func(N) float64) error {
+	var errs []error
+	for _, dp := range data.DataPoints {
+		tags := dogstatsdTags(dp.Attributes)
+		var err error
+		if data.IsMonotonic && data.Temporality == metricdata.DeltaTemporality {
+			err = e.count(name, toFloat(dp.Value), tags)
+		} else {
+			err = e.client.Gauge(name, toFloat(dp.Value), tags, 1)
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+func exportGauge[N int64 | float64](e *dogstatsdExporter, name string, data metricdata.Gauge[N], toFloat func(N) float64) error {
+	var errs []error
+	for _, dp := range data.DataPoints {
+		errs = append(errs, e.client.Gauge(name, toFloat(dp.Value), dogstatsdTags(dp.Attributes), 1))
+	}
+	return errors.Join(errs...)
+}
+
+func exportHistogram[N int64 | float64](e *dogstatsdExporter, name string, data metricdata.Histogram[N], toFloat func(N) float64) error {
+	var errs []error
+	for _, dp := range data.DataPoints {
+		if dp.Count == 0 {
+			continue
+		}
+		tags := dogstatsdTags(dp.Attributes)
+		errs = append(errs,
+			e.client.Count(name+".count", int64(dp.Count), tags, 1),
+			e.client.Gauge(name+".avg", toFloat(dp.Sum)/float64(dp.Count), tags, 1),
+		)
+		if v, ok := dp.Min.Value(); ok {
+			errs = append(errs, e.client.Gauge(name+".min", toFloat(v), tags, 1))
+		}
+		if v, ok := dp.Max.Value(); ok {
+			errs = append(errs, e.client.Gauge(name+".max", toFloat(v), tags, 1))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// count sends value as a DogStatsD count, carrying its fractional part over
+// to the next count of the same series.
+func (e *dogstatsdExporter) count(name string, value float64, tags []string) error {
+	key := name + "|" + strings.Join(tags, ",")
+	value += e.remainders[key]
+	whole := math.Trunc(value)
+	if rest := value - whole; rest != 0 {
+		e.remainders[key] = rest
+	} else {
+		delete(e.remainders, key)
+	}
+	if whole == 0 {
+		return nil
+	}
+	return e.client.Count(name, int64(whole), tags, 1)
+}
+
+func (e *dogstatsdExporter) ForceFlush(ctx context.Context) error {
+	return e.client.Flush()
+}
+
+func (e *dogstatsdExporter) Shutdown(ctx context.Context) error {
+	return e.client.Close()
+}
+
+// dogstatsdTags converts attributes to DogStatsD key:value tags.
+func dogstatsdTags(set attribute.Set) []string {
+	if set.Len() == 0 {
+		return nil
+	}
+	tags := make([]string, 0, set.Len())
+	for iter := set.Iter(); iter.Next(); {
+		kv := iter.Attribute()
+		tags = append(tags, string(kv.Key)+":"+kv.Value.Emit())
+	}
+	return tags
+}
+
+// dogstatsdShutdowner shuts down the MeterProvider, which flushes the last
+// collection to the Agent and closes the client.
+type dogstatsdShutdowner struct {
+	provider *sdkmetric.MeterProvider
+}
+
+func (s *dogstatsdShutdowner) Shutdown(ctx context.Context) error {
+	if err := s.provider.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shutdown DogStatsD MeterProvider: %w", err)
+	}
+	return nil
+}
+
+// ForceFlush sends the current values of all instruments to the Agent.
+func (s *dogstatsdShutdowner) ForceFlush(ctx context.Context) error {
+	if err := s.provider.ForceFlush(ctx); err != nil {
+		return fmt.Errorf("failed to flush DogStatsD MeterProvider: %w", err)
+	}
+	return nil
+}
+
+func (s *dogstatsdShutdowner) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(s, msg)
+}
+
+func init() {
+	RegisterMetricsProvider(string(DogStatsDMetrics), dogstatsdMetricsProvider{})
+}
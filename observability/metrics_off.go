@@ -4,8 +4,10 @@ package observability
 
 import (
 	"context"
+
+	"go.opentelemetry.io/otel/metric"
 )
 
-func setupMetrics(ctx context.Context) (Shutdowner, error) {
+func setupMetrics(ctx context.Context, provider metric.MeterProvider) (Shutdowner, error) {
 	return &noOpShutdowner{}, nil
 }
@@ -10,16 +10,16 @@ import (
 	"runtime/debug"
 
 	"github.com/shirou/gopsutil/v3/process"
-	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 )
 
-func setupMetrics(ctx context.Context) (Shutdowner, error) {
+func setupMetrics(ctx context.Context, provider metric.MeterProvider) (Shutdowner, error) {
 	p, err := process.NewProcess(int32(os.Getpid()))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get current process: %w", err)
 	}
-	meter := newMeter(otel.GetMeterProvider(), p)
+	meter := newMeter(provider, p)
 	if err := meter.start(); err != nil {
 		return nil, fmt.Errorf("failed to start runtime metrics: %w", err)
 	}
@@ -86,6 +86,30 @@ func (m *meter) start() error {
 		return err
 	}
 
+	// --- Process Metrics ---
+	openFDs, err := m.meter.Int64ObservableUpDownCounter("process.open_file_descriptor.count", metric.WithDescription("Number of file descriptors in use by the process"), metric.WithUnit("{file_descriptor}"))
+	if err != nil {
+		return err
+	}
+	threads, err := m.meter.Int64ObservableUpDownCounter("process.thread.count", metric.WithDescription("Process threads count"), metric.WithUnit("{thread}"))
+	if err != nil {
+		return err
+	}
+	memUsage, err := m.meter.Int64ObservableUpDownCounter("process.memory.usage", metric.WithDescription("The amount of physical memory in use (RSS)"), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	memVirtual, err := m.meter.Int64ObservableUpDownCounter("process.memory.virtual", metric.WithDescription("The amount of committed virtual memory (VMS)"), metric.WithUnit("By"))
+	if err != nil {
+		return err
+	}
+	ctxSwitches, err := m.meter.Int64ObservableCounter("process.context_switches", metric.WithDescription("Number of times the process has been context switched"), metric.WithUnit("{context_switch}"))
+	if err != nil {
+		return err
+	}
+	voluntary := metric.WithAttributes(attribute.String("process.context_switch_type", "voluntary"))
+	involuntary := metric.WithAttributes(attribute.String("process.context_switch_type", "involuntary"))
+
 	// Register the callback that will be periodically invoked.
 	_, err = m.meter.RegisterCallback(
 		func(_ context.Context, o metric.Observer) error {
@@ -111,9 +135,26 @@ func (m *meter) start() error {
 			o.ObserveFloat64(gcPauseTotal, gcStats.PauseTotal.Seconds())
 			o.ObserveInt64(gcCount, gcStats.NumGC)
 
+			// Process; not every statistic is available on every OS.
+			if fds, err := m.process.NumFDs(); err == nil {
+				o.ObserveInt64(openFDs, int64(fds))
+			}
+			if n, err := m.process.NumThreads(); err == nil {
+				o.ObserveInt64(threads, int64(n))
+			}
+			if mem, err := m.process.MemoryInfo(); err == nil {
+				o.ObserveInt64(memUsage, int64(mem.RSS))
+				o.ObserveInt64(memVirtual, int64(mem.VMS))
+			}
+			if cs, err := m.process.NumCtxSwitches(); err == nil {
+				o.ObserveInt64(ctxSwitches, cs.Voluntary, voluntary)
+				o.ObserveInt64(ctxSwitches, cs.Involuntary, involuntary)
+			}
+
 			return nil
 		},
 		cpuUsage, heapAlloc, heapSys, heapIdle, heapInuse, goroutines, gcPauseTotal, gcCount,
+		openFDs, threads, memUsage, memVirtual, ctxSwitches,
 	)
 
 	return err
@@ -128,4 +169,4 @@ func (m *meter) Shutdown(ctx context.Context) error {
 // ShutdownOrLog implements the Shutdowner interface for the meter.
 func (m *meter) ShutdownOrLog(msg string) {
 	// The meter shutdown is a no-op, so no action is needed.
-}
\ No newline at end of file
+}
@@ -0,0 +1,59 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/metric"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// otlpMetricsProvider exports metrics over OTLP/HTTP with a periodic reader.
+type otlpMetricsProvider struct{}
+
+// Setup creates the OTLP metric exporter and MeterProvider.
+func (otlpMetricsProvider) Setup(ctx context.Context, cfg MetricsConfig) (metric.MeterProvider, Shutdowner, error) {
+	selector, err := temporalitySelector(cfg.Temporality)
+	if err != nil {
+		return nil, nil, err
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx,
+		otlpmetrichttp.WithEndpointURL(cfg.URL),
+		otlpmetrichttp.WithTemporalitySelector(selector),
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	}
+
+	var exporter sdkmetric.Exporter = metricExporter
+	if cfg.stats != nil {
+		exporter = statsMetricExporter{Exporter: exporter, stats: cfg.stats}
+	}
+	mp := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(exporter)),
+		sdkmetric.WithResource(newOTLPResource(cfg.ServiceName, cfg.ServiceApp, cfg.ServiceEnv, cfg.ServiceVersion, cfg.ResourceAttributes)),
+	)
+
+	return mp, &otlpShutdowner{provider: mp, name: "MeterProvider"}, nil
+}
+
+// temporalitySelector returns the exporter's temporality selector for the
+// given WithMetricTemporality value.
+func temporalitySelector(temporality string) (sdkmetric.TemporalitySelector, error) {
+	switch strings.ToLower(temporality) {
+	case "", "cumulative":
+		return sdkmetric.DefaultTemporalitySelector, nil
+	case "delta":
+		return deltaTemporality, nil
+	default:
+		return nil, fmt.Errorf("unknown metric temporality %q; use \"cumulative\" or \"delta\"", temporality)
+	}
+}
+
+func init() {
+	RegisterMetricsProvider(string(OTLPMetrics), otlpMetricsProvider{})
+}
@@ -0,0 +1,39 @@
+//go:build otlp || datadog || !none
+
+package observability
+
+import (
+	"context"
+	"time"
+
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+// deltaTemporality selects delta temporality for counters and histograms,
+// whose increments can be summed, and cumulative temporality for up-down
+// counters, whose current value is what matters, as recommended by the
+// OTLP exporter specification.
+func deltaTemporality(kind sdkmetric.InstrumentKind) metricdata.Temporality {
+	switch kind {
+	case sdkmetric.InstrumentKindCounter,
+		sdkmetric.InstrumentKindObservableCounter,
+		sdkmetric.InstrumentKindHistogram:
+		return metricdata.DeltaTemporality
+	default:
+		return metricdata.CumulativeTemporality
+	}
+}
+
+// statsMetricExporter records the outcome and latency of each export of the
+// metric Exporter it wraps, and reports failed exports.
+type statsMetricExporter struct {
+	sdkmetric.Exporter
+	stats *pipelineStats
+}
+
+func (e statsMetricExporter) Export(ctx context.Context, rm *metricdata.ResourceMetrics) error {
+	start := time.Now()
+	err := e.Exporter.Export(ctx, rm)
+	return e.stats.recordExport(ctx, signalMetrics, start, err)
+}
@@ -0,0 +1,77 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// MetricsConfig carries the settings a metrics provider needs to initialize.
+type MetricsConfig struct {
+	ServiceName string
+	ServiceApp  string
+	ServiceEnv  string
+	// ServiceVersion identifies the build of the service; it may be empty.
+	ServiceVersion string
+	URL            string
+	// Temporality is the aggregation temporality of exported metrics,
+	// "cumulative" or "delta" (see WithMetricTemporality).
+	Temporality string
+
+	// ResourceAttributes describe where the service runs, as found by
+	// resource detection (see WithResourceDetection), followed by those set
+	// with WithMetricAttributes.
+	ResourceAttributes []attribute.KeyValue
+
+	// stats, if set, counts the provider's failed exports and records their
+	// latency, for the factory's self-telemetry.
+	stats *pipelineStats
+}
+
+// MetricsProvider sets up a metrics backend. The returned MeterProvider backs
+// every instrument created through the factory's Metrics, as well as the
+// automatic runtime metrics, and is also installed globally unless
+// WithGlobalProviders(false) is set.
+type MetricsProvider interface {
+	Setup(ctx context.Context, cfg MetricsConfig) (metric.MeterProvider, Shutdowner, error)
+}
+
+// setupMetricsProvider initializes the metrics backend registered for
+// metricsType, installing its MeterProvider as the global one if global is
+// set.
+func setupMetricsProvider(ctx context.Context, metricsType string, cfg MetricsConfig, global bool) (metric.MeterProvider, Shutdowner, error) {
+	normalizedMetricsType := normalizeMetricsType(metricsType)
+
+	provider, ok := lookupMetricsProvider(normalizedMetricsType)
+	if !ok {
+		return nil, nil, fmt.Errorf("%s metrics are not included in this build; rebuild with the %q build tag or without APM build tags", normalizedMetricsType, normalizedMetricsType)
+	}
+
+	mp, shutdowner, err := provider.Setup(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if global {
+		otel.SetMeterProvider(mp)
+	}
+	return mp, shutdowner, nil
+}
+
+// parseMetricAttributes parses the comma-separated key=value pairs of
+// OBS_METRIC_ATTRIBUTES, in the format of OTEL_RESOURCE_ATTRIBUTES. Pairs
+// without a key are skipped.
+func parseMetricAttributes(val string) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+	for _, pair := range strings.Split(val, ",") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); key == "" {
+			continue
+		}
+		attrs = append(attrs, attribute.String(key, strings.TrimSpace(value)))
+	}
+	return attrs
+}
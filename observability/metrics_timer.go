@@ -0,0 +1,64 @@
+package observability
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// durationBuckets are the histogram bucket boundaries, in seconds, of the
+// duration histograms recorded by Timer. They follow the OpenTelemetry
+// semantic conventions for HTTP durations, which suit most request-scoped
+// work.
+var durationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2.5, 5, 7.5, 10}
+
+// Timer measures the time from its creation until Stop and records it in a
+// duration histogram. A Timer is meant to be stopped once.
+type Timer struct {
+	ctx       context.Context
+	histogram metric.Float64Histogram
+	start     time.Time
+}
+
+// Timer starts a stopwatch whose Stop records the elapsed time, in seconds,
+// in the histogram named name:
+//
+//	t := obs.Metrics.Timer("cache.refresh.duration")
+//	refreshCache()
+//	t.Stop(attribute.String("cache.name", "users"))
+//
+// Like Histogram, Timer applies the WithMetricPrefix prefix to name. If the
+// histogram cannot be created, the error is logged and the Timer
+// measures time without recording it.
+func (m *Metrics) Timer(name string) *Timer {
+	histogram, err := m.Histogram(name,
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...),
+	)
+	if err != nil {
+		m.obs.Log.Warn("Failed to create timer histogram", "metric", name, "error", err)
+		histogram = nil
+	}
+	return &Timer{ctx: m.obs.ctx, histogram: histogram, start: time.Now()}
+}
+
+// Stop records the time elapsed since the Timer started, with attrs as the
+// measurement's attributes, and returns it.
+func (t *Timer) Stop(attrs ...attribute.KeyValue) time.Duration {
+	elapsed := time.Since(t.start)
+	if t.histogram != nil {
+		t.histogram.Record(t.ctx, elapsed.Seconds(), metric.WithAttributes(attrs...))
+	}
+	return elapsed
+}
+
+// Time runs fn and records how long it took, in seconds, in the histogram
+// named name, with attrs as the measurement's attributes. It returns the
+// duration.
+func (m *Metrics) Time(name string, fn func(), attrs ...attribute.KeyValue) time.Duration {
+	t := m.Timer(name)
+	fn()
+	return t.Stop(attrs...)
+}
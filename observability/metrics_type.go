@@ -1,6 +1,9 @@
 package observability
 
-import "strings"
+import (
+	"strings"
+	"sync"
+)
 
 // MetricsType defines the type of Metrics implementation.
 type MetricsType string
@@ -8,18 +11,44 @@ type MetricsType string
 const (
 	// OTLPMetrics represents the OpenTelemetry Protocol for metrics.
 	OTLPMetrics MetricsType = "otlp"
+	// DogStatsDMetrics sends metrics to a Datadog Agent over DogStatsD.
+	DogStatsDMetrics MetricsType = "dogstatsd"
 	// NoneMetrics disables metrics.
 	NoneMetrics MetricsType = "none"
 )
 
+var (
+	metricsProvidersMu sync.RWMutex
+	metricsProviders   = make(map[MetricsType]MetricsProvider)
+)
+
+// RegisterMetricsProvider makes a metrics backend available under the given
+// name, so it can be selected at runtime with WithMetricsType or
+// OBS_METRICS_TYPE. Names are case-insensitive, and registering an existing
+// name replaces it. Providers must be registered before Factory.Setup is called.
+func RegisterMetricsProvider(name string, provider MetricsProvider) {
+	metricsProvidersMu.Lock()
+	defer metricsProvidersMu.Unlock()
+	metricsProviders[MetricsType(strings.ToLower(name))] = provider
+}
+
+// lookupMetricsProvider returns the provider registered for metricsType, if any.
+func lookupMetricsProvider(metricsType MetricsType) (MetricsProvider, bool) {
+	metricsProvidersMu.RLock()
+	defer metricsProvidersMu.RUnlock()
+	p, ok := metricsProviders[metricsType]
+	return p, ok
+}
+
 // normalizeMetricsType converts a string to a canonical MetricsType, ignoring case.
 func normalizeMetricsType(metricsType string) MetricsType {
-	switch strings.ToLower(metricsType) {
-	case "otlp":
-		return OTLPMetrics
-	case "none":
-		return NoneMetrics
-	default:
-		return NoneMetrics // Default to no metrics if the type is unknown
+	t := MetricsType(strings.ToLower(metricsType))
+	switch t {
+	case OTLPMetrics, NoneMetrics:
+		return t
+	}
+	if _, ok := lookupMetricsProvider(t); ok {
+		return t
 	}
+	return NoneMetrics // Default to no metrics if the type is unknown
 }
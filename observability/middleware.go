@@ -0,0 +1,250 @@
+package observability
+
+import (
+	"crypto/rand"
+	"log/slog"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Middleware instruments every request handled by next. It starts the root
+// span as StartSpanFromRequest does, makes the request's Observability
+// available through ObsFromCtx(r.Context()), and records the response status
+// code, body size, and duration on the span, marking it as an error for 5xx
+// responses.
+//
+// With WithBodyCapture, allowlisted fields of JSON request and response bodies
+// are recorded on the span for the configured paths.
+//
+// With WithTraceIDHeader and WithRequestIDHeader, the trace ID and request ID
+// are returned in response headers.
+//
+// Once the handler returns, the span is renamed to the route that matched the
+// request, if any; see WithRoutePattern and WithSpanNameFormatter.
+//
+// With WithSlowRequestThreshold, requests that take too long are flagged on
+// the span, logged, and counted.
+//
+// With WithAccessLog, a log record describing the request and its response
+// is written once the response is complete.
+//
+// Requests to paths excluded with WithIgnoredPaths are passed to next
+// without any instrumentation.
+//
+// Panics are recovered: the panic is logged with its stack trace, recorded as
+// an error on the span, and the client receives a 500 Internal Server Error.
+func (f *Factory) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.ignored(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		start := time.Now()
+		r, ctx, span, obs := f.StartSpanFromRequest(r)
+		if header := f.config.TraceIDHeader.Value; header != "" {
+			if traceID, _ := f.providers.spans.TraceIDs(ctx); traceID != "" {
+				w.Header().Set(header, traceID)
+			}
+		}
+		if header := f.config.RequestIDHeader.Value; header != "" {
+			if id := RequestIDFrom(ctx); id != "" {
+				w.Header().Set(header, id)
+			}
+		}
+		rw := newResponseRecorder(w)
+		var reqBody *captureBuffer
+		capture := f.config.BodyCapture.Value
+		if capture.enabledFor(r.URL.Path) && span.IsRecording() {
+			if r.Body != nil && isJSON(r.Header.Get("Content-Type")) {
+				reqBody = newCaptureBuffer(capture.maxBytes())
+				r.Body = &teeBody{ReadCloser: r.Body, buf: reqBody}
+			}
+			rw.body = newCaptureBuffer(capture.maxBytes())
+		}
+		defer func() {
+			duration := time.Since(start)
+			route := f.route(r)
+			if route != "" {
+				f.nameSpanByRoute(span, route)
+			} else {
+				route = r.URL.Path
+			}
+			endRequestSpan(span, rw, duration, f.config.LegacyHTTPAttrs.Value)
+			threshold := f.config.SlowRequest.Value
+			slow := threshold > 0 && duration > threshold
+			if slow {
+				span.SetAttributes(attribute.Bool("slow", true))
+			}
+			if reqBody != nil {
+				span.SetAttributes(bodyAttributes("http.request.body", reqBody, capture.Fields, f.config.AttributeFilter.Value)...)
+			}
+			if rw.body != nil && isJSON(rw.Header().Get("Content-Type")) {
+				span.SetAttributes(bodyAttributes("http.response.body", rw.body, capture.Fields, f.config.AttributeFilter.Value)...)
+			}
+			if slow {
+				f.reportSlowRequest(obs, r.Method, route, duration)
+			}
+			span.End()
+			if f.config.AccessLog.Value {
+				f.logAccess(obs, r, rw, route, duration)
+			}
+		}()
+
+		defer func() {
+			if v := recover(); v != nil {
+				obs.recordPanic(v)
+				if v == http.ErrAbortHandler {
+					// Let net/http abort the response as the handler intended.
+					panic(v)
+				}
+				if !rw.wroteHeader {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// ignored reports whether requests to urlPath are excluded from
+// instrumentation by WithIgnoredPaths.
+func (f *Factory) ignored(urlPath string) bool {
+	for _, p := range f.config.IgnoredPaths.Value {
+		if p == urlPath {
+			return true
+		}
+		if strings.ContainsAny(p, "*?[") {
+			if ok, _ := path.Match(p, urlPath); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseIgnoredPaths parses the comma-separated paths of OBS_IGNORED_PATHS.
+func parseIgnoredPaths(val string) []string {
+	var paths []string
+	for _, p := range strings.Split(val, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	return paths
+}
+
+// maxRequestIDLen bounds the length of request IDs accepted from clients.
+const maxRequestIDLen = 128
+
+// requestID returns the request ID received from the client, or a new random
+// ID if it is empty, too long, or contains anything but printable ASCII
+// other than the space.
+func requestID(received string) string {
+	valid := received != "" && len(received) <= maxRequestIDLen
+	for i := 0; valid && i < len(received); i++ {
+		valid = received[i] > ' ' && received[i] <= '~'
+	}
+	if valid {
+		return received
+	}
+	return rand.Text()
+}
+
+// route returns the route pattern that matched r, without the method and
+// host that http.ServeMux patterns may include, or "" if none did.
+func (f *Factory) route(r *http.Request) string {
+	if pattern := f.config.RoutePattern.Value; pattern != nil {
+		return pattern(r)
+	}
+	route := r.Pattern
+	if _, path, ok := strings.Cut(route, " "); ok {
+		route = strings.TrimLeft(path, " \t")
+	}
+	if i := strings.IndexByte(route, '/'); i > 0 {
+		route = route[i:]
+	}
+	return route
+}
+
+// spanRenamer is implemented by spans that can be renamed after they start.
+type spanRenamer interface {
+	SetName(name string)
+}
+
+// nameSpanByRoute records route as http.route and names span after it,
+// unless WithSpanNameFormatter has named the span already.
+func (f *Factory) nameSpanByRoute(span Span, route string) {
+	span.SetAttributes(attribute.String("http.route", route))
+	if f.config.SpanNameFormatter.Value != nil {
+		return
+	}
+	if s, ok := span.(spanRenamer); ok {
+		s.SetName(route)
+	}
+}
+
+// logAccess writes the access log record for a completed request.
+func (f *Factory) logAccess(obs *Observability, r *http.Request, rw *responseRecorder, route string, duration time.Duration) {
+	obs.Log.LogWithAttrs(f.config.AccessLogLevel.Value, "HTTP request",
+		slog.String("http.method", r.Method),
+		slog.String("http.route", route),
+		slog.Int("http.status_code", rw.status),
+		slog.Int64("http.response_content_length", rw.bytes),
+		slog.Float64("http.duration_ms", float64(duration.Microseconds())/1000),
+		slog.String("http.client_addr", r.RemoteAddr),
+	)
+}
+
+// reportSlowRequest logs and counts a request that took longer than the
+// WithSlowRequestThreshold threshold.
+func (f *Factory) reportSlowRequest(obs *Observability, method, route string, duration time.Duration) {
+	obs.Log.Warn("Slow request",
+		"http.method", method,
+		"http.route", route,
+		"http.duration_ms", float64(duration.Microseconds())/1000,
+		"threshold_ms", float64(f.config.SlowRequest.Value.Microseconds())/1000,
+	)
+	if f.slowRequests == nil {
+		return
+	}
+	f.slowRequests.Add(obs.ctx, 1, metric.WithAttributes(
+		attribute.String("http.method", method),
+		attribute.String("http.route", route),
+	))
+}
+
+// newSlowRequestCounter creates the counter of requests slower than the
+// WithSlowRequestThreshold threshold in meter.
+func newSlowRequestCounter(meter metric.Meter) (metric.Int64Counter, error) {
+	return meter.Int64Counter("http.server.slow_requests",
+		metric.WithDescription("Number of HTTP requests that took longer than the slow request threshold"),
+		metric.WithUnit("{request}"),
+	)
+}
+
+// endRequestSpan records the response on the request's root span, under the
+// legacy attribute keys too if legacy is set. Following the HTTP semantic
+// conventions, only 5xx responses mark a server span as failed.
+func endRequestSpan(span Span, rw *responseRecorder, duration time.Duration, legacy bool) {
+	span.SetAttributes(
+		attribute.Int("http.response.status_code", rw.status),
+		attribute.Int64("http.response.body.size", rw.bytes),
+		attribute.Float64("http.duration_ms", float64(duration.Microseconds())/1000),
+	)
+	if legacy {
+		span.SetAttributes(
+			attribute.Int("http.status_code", rw.status),
+			attribute.Int64("http.response_content_length", rw.bytes),
+		)
+	}
+	if rw.status >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(rw.status))
+	}
+}
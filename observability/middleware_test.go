@@ -0,0 +1,42 @@
+package observability
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		received string
+		keep     bool
+	}{
+		{name: "uuid", received: "3f2b8c1e-9a4d-4e6f-8b7a-1c2d3e4f5a6b", keep: true},
+		{name: "printable punctuation", received: "req:1/2_3.4~", keep: true},
+		{name: "longest accepted", received: strings.Repeat("a", maxRequestIDLen), keep: true},
+		{name: "empty"},
+		{name: "too long", received: strings.Repeat("a", maxRequestIDLen+1)},
+		{name: "space", received: "req 1"},
+		{name: "newline", received: "req-1\nX-Injected: 1"},
+		{name: "control", received: "req-\x001"},
+		{name: "delete", received: "req-\x7f"},
+		{name: "non-ASCII", received: "req-é"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := requestID(tt.received)
+			if tt.keep {
+				if got != tt.received {
+					t.Errorf("requestID(%q) = %q, want it kept", tt.received, got)
+				}
+				return
+			}
+			if got == tt.received || got == "" {
+				t.Errorf("requestID(%q) = %q, want a generated ID", tt.received, got)
+			}
+			if again := requestID(tt.received); again == got {
+				t.Errorf("requestID(%q) returned %q twice, want a new ID each time", tt.received, got)
+			}
+		})
+	}
+}
@@ -10,6 +10,9 @@ package observability
 import (
 	"context"
 	"log/slog"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
 )
 
 // Shutdowner defines a contract for components that can be gracefully shut down.
@@ -30,28 +33,66 @@ type Observability struct {
 	Log          *Log
 	Metrics      *Metrics
 	ErrorHandler *ErrorHandler
+	Audit        *Audit
 	ctx          context.Context
 	serviceName  string
 	apmType      APMType
+	providers    providers
+}
+
+// providers are the telemetry pipelines an Observability reports through.
+// Each Factory has its own, so several services in one process do not share
+// configuration.
+type providers struct {
+	logger *slog.Logger
+	spans  SpanFactory
+	meters metric.MeterProvider
+	// instruments caches the instruments created from meters.
+	instruments *instrumentCache
+	// metricPrefix is prepended to the names of instruments created
+	// through Metrics.
+	metricPrefix string
+	// errorFormat is the format of the responses written by
+	// ErrorHandler.HTTP.
+	errorFormat string
+	// fatal, if set, is called by ErrorHandler.Fatal in place of exiting.
+	fatal func(msg string, args ...any)
+	// traceURLTemplate is the link to a trace in the trace viewer, with
+	// {traceID} and {spanID} placeholders.
+	traceURLTemplate string
+	// audit is the destination of the records written through Audit.
+	audit *auditLog
+	// loggerLevels holds the minimum levels of the loggers returned by
+	// Log.Named, by name.
+	loggerLevels map[string]slog.Level
+	// contextFields extract the fields added to spans from their context.
+	contextFields []func(context.Context) []slog.Attr
 }
 
-// NewObservability creates a new Observability instance.
+// defaultProviders returns the process-wide pipelines: the default slog
+// logger, the registered span factory for apmType, and the global
+// MeterProvider.
+func defaultProviders(apmType APMType) providers {
+	return providers{
+		logger: slog.Default(),
+		spans:  spanFactoryFor(apmType),
+		meters: otel.GetMeterProvider(),
+		// The global MeterProvider delegates to the one installed later, so
+		// its instruments stay valid across otel.SetMeterProvider.
+		instruments: defaultInstruments,
+		audit:       defaultAuditLog,
+	}
+}
+
+// NewObservability creates a new Observability instance that reports through
+// the process-wide default logger, tracer, and meter provider. Instances
+// created by a Factory report through that factory's own pipelines instead.
 func NewObservability(ctx context.Context, serviceName string, apmType string, logSource bool, logLevel, traceLogLevel slog.Level, metrics bool) *Observability {
 	typedAPMType := normalizeAPMType(apmType)
-	obs := &Observability{
-		ctx:         ctx,
-		serviceName: serviceName,
-		apmType:     typedAPMType,
-	}
-	// The factory is now responsible for initializing the logger.
-	// We assume baseLogger is already initialized and available.
-	obs.Trace = newTrace(obs, serviceName, typedAPMType)
-	obs.Log = newLog(obs, baseLogger)
-	obs.Metrics = newMetrics(obs)
-	obs.ErrorHandler = newErrorHandler(obs) // Initialize the error handler
+	obs := newObservability(ctx, serviceName, typedAPMType, defaultProviders(typedAPMType))
 
 	if metrics {
-		shutdowner, err := setupMetrics(ctx)
+		shutdowner, err := setupMetrics(ctx, obs.providers.meters)
 		if err != nil {
 			obs.Log.Error("failed to setup metrics", "error", err)
 		} else {
@@ -64,6 +105,22 @@ func NewObservability(ctx context.Context, serviceName string, apmType string, l
 	return obs
 }
 
+// newObservability creates an Observability instance reporting through p.
+func newObservability(ctx context.Context, serviceName string, apmType APMType, p providers) *Observability {
+	obs := &Observability{
+		ctx:         ctx,
+		serviceName: serviceName,
+		apmType:     apmType,
+		providers:   p,
+	}
+	obs.Trace = newTrace(obs)
+	obs.Log = newLog(obs)
+	obs.Metrics = newMetrics(obs)
+	obs.ErrorHandler = newErrorHandler(obs)
+	obs.Audit = newAudit(obs)
+	return obs
+}
+
 // Context returns the current context from the Observability instance.
 func (o *Observability) Context() context.Context {
 	return o.ctx
@@ -79,13 +136,31 @@ func (o *Observability) clone(ctx context.Context) *Observability {
 
 	// Re-initialize the components that depend on the observability object itself
 	// to ensure they point to the new, cloned object, not the original.
-	newObs.Trace = newTrace(&newObs, newObs.serviceName, newObs.apmType)
-	newObs.Log = newLog(&newObs, baseLogger)
+	newObs.Trace = newTrace(&newObs)
+	newObs.Log = newLog(&newObs)
 	newObs.Metrics = newMetrics(&newObs)
 	newObs.ErrorHandler = newErrorHandler(&newObs)
+	newObs.Audit = newAudit(&newObs)
 	return &newObs
 }
 
+// flusher is implemented by components that buffer telemetry and can push it
+// to their backend on demand.
+type flusher interface {
+	ForceFlush(ctx context.Context) error
+}
+
+// forceFlush flushes s if it buffers telemetry.
+func forceFlush(ctx context.Context, s Shutdowner) error {
+	if t, ok := s.(*trackedShutdowner); ok {
+		s = t.Shutdowner
+	}
+	if f, ok := s.(flusher); ok {
+		return f.ForceFlush(ctx)
+	}
+	return nil
+}
+
 // noOpShutdowner implements the Shutdowner interface for components that need no shutdown logic.
 type noOpShutdowner struct{}
 
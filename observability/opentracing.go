@@ -0,0 +1,296 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OpenTracer is an opentracing.Tracer whose spans are created by the APM
+// provider the factory set up, so libraries still instrumented with
+// OpenTracing, such as older Kafka and database clients, contribute spans to
+// the same traces as the rest of the service during a migration. Install it
+// as the OpenTracing global tracer after Setup:
+//
+//	opentracing.SetGlobalTracer(f.OpenTracer())
+//
+// OpenTracing spans cannot be started or finished at an explicit time, so
+// the StartTime and FinishTime options are ignored, and baggage is carried
+// as W3C baggage.
+type OpenTracer struct {
+	spans SpanFactory
+}
+
+var _ opentracing.Tracer = (*OpenTracer)(nil)
+
+// OpenTracer returns an OpenTracing bridge to the APM provider set up by
+// Setup; see OpenTracer.
+func (f *Factory) OpenTracer() *OpenTracer {
+	return &OpenTracer{spans: f.providers.spans}
+}
+
+// ContextWithSpan returns a copy of ctx carrying the active span of ctx as
+// an OpenTracing span too, so OpenTracing-instrumented code called with it,
+// which looks its parent up with opentracing.SpanFromContext, creates child
+// spans of it.
+func (t *OpenTracer) ContextWithSpan(ctx context.Context) context.Context {
+	span := t.spans.SpanFromContext(ctx)
+	if span == nil {
+		return ctx
+	}
+	return opentracing.ContextWithSpan(ctx, &openTracingSpan{tracer: t, span: span, ctx: ctx})
+}
+
+// StartSpan starts a span as a child of the first span referenced in opts,
+// or as a new root.
+func (t *OpenTracer) StartSpan(operationName string, opts ...opentracing.StartSpanOption) opentracing.Span {
+	var sso opentracing.StartSpanOptions
+	for _, opt := range opts {
+		opt.Apply(&sso)
+	}
+
+	parent := context.Background()
+	for _, ref := range sso.References {
+		if sc, ok := ref.ReferencedContext.(openTracingSpanContext); ok {
+			parent = sc.ctx
+			break
+		}
+	}
+
+	ctx, span := t.spans.Start(parent, operationName)
+	s := &openTracingSpan{tracer: t, span: span, ctx: ctx}
+	for k, v := range sso.Tags {
+		s.SetTag(k, v)
+	}
+	return s
+}
+
+// Inject writes the trace context of sc into carrier, which must be an
+// opentracing.TextMapWriter for the HTTPHeaders and TextMap formats.
+func (t *OpenTracer) Inject(sc opentracing.SpanContext, format interface{}, carrier interface{}) error {
+	spanContext, ok := sc.(openTracingSpanContext)
+	if !ok {
+		return opentracing.ErrInvalidSpanContext
+	}
+	if format != opentracing.HTTPHeaders && format != opentracing.TextMap {
+		return opentracing.ErrUnsupportedFormat
+	}
+	writer, ok := carrier.(opentracing.TextMapWriter)
+	if !ok {
+		return opentracing.ErrInvalidCarrier
+	}
+
+	header := http.Header{}
+	t.spans.Inject(spanContext.ctx, header)
+	for k, values := range header {
+		for _, v := range values {
+			writer.Set(k, v)
+		}
+	}
+	return nil
+}
+
+// Extract reads a trace context from carrier, which must be an
+// opentracing.TextMapReader for the HTTPHeaders and TextMap formats.
+func (t *OpenTracer) Extract(format interface{}, carrier interface{}) (opentracing.SpanContext, error) {
+	if format != opentracing.HTTPHeaders && format != opentracing.TextMap {
+		return nil, opentracing.ErrUnsupportedFormat
+	}
+	reader, ok := carrier.(opentracing.TextMapReader)
+	if !ok {
+		return nil, opentracing.ErrInvalidCarrier
+	}
+
+	header := http.Header{}
+	if err := reader.ForeachKey(func(k, v string) error {
+		header.Add(k, v)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	// Providers return the context unchanged when the headers carry no
+	// trace context or baggage.
+	base := context.Background()
+	ctx := t.spans.Extract(base, header)
+	if ctx == base {
+		return nil, opentracing.ErrSpanContextNotFound
+	}
+	return openTracingSpanContext{ctx: ctx}, nil
+}
+
+// openTracingSpanContext is the span context of an OpenTracing span: the
+// context the span, or the remote trace context, was started in.
+type openTracingSpanContext struct {
+	ctx context.Context
+}
+
+func (sc openTracingSpanContext) ForeachBaggageItem(handler func(k, v string) bool) {
+	for _, m := range baggage.FromContext(sc.ctx).Members() {
+		if !handler(m.Key(), m.Value()) {
+			return
+		}
+	}
+}
+
+// openTracingSpan adapts a Span to opentracing.Span.
+type openTracingSpan struct {
+	tracer *OpenTracer
+	span   Span
+
+	mu  sync.Mutex
+	ctx context.Context
+}
+
+func (s *openTracingSpan) Finish() {
+	s.span.End()
+}
+
+func (s *openTracingSpan) FinishWithOptions(opts opentracing.FinishOptions) {
+	for _, record := range opts.LogRecords {
+		s.LogFields(record.Fields...)
+	}
+	for _, data := range opts.BulkLogData {
+		s.LogFields(data.ToLogRecord().Fields...)
+	}
+	s.span.End()
+}
+
+func (s *openTracingSpan) Context() opentracing.SpanContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return openTracingSpanContext{ctx: s.ctx}
+}
+
+// SetOperationName renames the span, if its provider supports renaming.
+func (s *openTracingSpan) SetOperationName(operationName string) opentracing.Span {
+	if r, ok := s.span.(spanRenamer); ok {
+		r.SetName(operationName)
+	}
+	return s
+}
+
+// SetTag sets an attribute on the span. The error tag marks the span as
+// failed, and a positive sampling.priority keeps its trace, as OpenTracing's
+// semantic conventions define.
+func (s *openTracingSpan) SetTag(key string, value interface{}) opentracing.Span {
+	switch key {
+	case string(ext.Error):
+		if failed, ok := value.(bool); ok {
+			if failed {
+				s.span.SetStatus(codes.Error, "")
+			}
+			return s
+		}
+	case string(ext.SamplingPriority):
+		if priority, ok := value.(uint16); ok {
+			if priority > 0 {
+				s.span.Keep()
+			}
+			return s
+		}
+	}
+	s.span.SetAttributes(ToAttribute(key, value))
+	return s
+}
+
+// LogFields records the fields as a span event, named by the event field if
+// there is one. An error field is recorded as the span's error.
+func (s *openTracingSpan) LogFields(fields ...otlog.Field) {
+	name := ""
+	attrs := make([]attribute.KeyValue, 0, len(fields))
+	for _, field := range fields {
+		switch value := field.Value().(type) {
+		case error:
+			if field.Key() == "error" || field.Key() == "error.object" {
+				s.span.RecordError(value)
+				continue
+			}
+			attrs = append(attrs, attribute.String(field.Key(), value.Error()))
+		default:
+			if field.Key() == "event" {
+				name = fmt.Sprint(value)
+				continue
+			}
+			attrs = append(attrs, ToAttribute(field.Key(), value))
+		}
+	}
+	if name == "" && len(attrs) == 0 {
+		return
+	}
+	if name == "" {
+		name = "log"
+	}
+	s.span.AddEvent(name, trace.WithAttributes(attrs...))
+}
+
+// LogKV is LogFields for alternating keys and values. Error values are
+// recorded as the span's error rather than converted to strings.
+func (s *openTracingSpan) LogKV(alternatingKeyValues ...interface{}) {
+	fields, err := otlog.InterleavedKVToFields(alternatingKeyValues...)
+	if err != nil {
+		s.LogFields(otlog.Error(err))
+		return
+	}
+	for i := range fields {
+		if err, ok := alternatingKeyValues[2*i+1].(error); ok {
+			fields[i] = otlog.Error(err)
+		}
+	}
+	s.LogFields(fields...)
+}
+
+// SetBaggageItem adds W3C baggage to the span's context, which spans started
+// from it and outgoing requests carry. Invalid keys or values are dropped.
+func (s *openTracingSpan) SetBaggageItem(restrictedKey, value string) opentracing.Span {
+	member, err := baggage.NewMemberRaw(restrictedKey, value)
+	if err != nil {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bag, err := baggage.FromContext(s.ctx).SetMember(member)
+	if err != nil {
+		return s
+	}
+	s.ctx = baggage.ContextWithBaggage(s.ctx, bag)
+	return s
+}
+
+func (s *openTracingSpan) BaggageItem(restrictedKey string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return baggage.FromContext(s.ctx).Member(restrictedKey).Value()
+}
+
+func (s *openTracingSpan) Tracer() opentracing.Tracer {
+	return s.tracer
+}
+
+// LogEvent is deprecated in OpenTracing; it logs an event field.
+func (s *openTracingSpan) LogEvent(event string) {
+	s.LogFields(otlog.String("event", event))
+}
+
+// LogEventWithPayload is deprecated in OpenTracing; it logs an event field
+// with a payload field.
+func (s *openTracingSpan) LogEventWithPayload(event string, payload interface{}) {
+	s.LogFields(otlog.String("event", event), otlog.Object("payload", payload))
+}
+
+// Log is deprecated in OpenTracing; it logs the data as fields.
+func (s *openTracingSpan) Log(data opentracing.LogData) {
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+	s.LogFields(data.ToLogRecord().Fields...)
+}
@@ -0,0 +1,241 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cpuProfileSlot is held while the process's one CPU profile runs, by the
+// continuous profiler or by an on-demand profile from the admin server.
+var cpuProfileSlot = make(chan struct{}, 1)
+
+// cpuProfileHandoff passes the slot from the running continuous profiler to
+// an on-demand profile, which waits for the channel it sends to be closed
+// once the continuous profile has stopped.
+var cpuProfileHandoff = make(chan chan struct{})
+
+// acquireCPUProfile waits, until done is closed, for the CPU profile to be
+// free, and reports whether it was acquired. With preempt, a running
+// continuous profile ends its period early and hands the profile over, so
+// an on-demand profile need not wait it out.
+func acquireCPUProfile(done <-chan struct{}, preempt bool) bool {
+	handoff := cpuProfileHandoff
+	if !preempt {
+		handoff = nil
+	}
+	stopped := make(chan struct{})
+	select {
+	case cpuProfileSlot <- struct{}{}:
+	case handoff <- stopped:
+		<-stopped
+	case <-done:
+		return false
+	}
+	return true
+}
+
+// releaseCPUProfile frees the CPU profile acquired with acquireCPUProfile.
+func releaseCPUProfile() {
+	<-cpuProfileSlot
+}
+
+// profilingPeriod is how long each CPU profile runs before it is uploaded.
+const profilingPeriod = 15 * time.Second
+
+// Span attribute and profiler labels that link traces and profiles, as
+// Grafana and Pyroscope expect them.
+const (
+	profileIDKey  = "pyroscope.profile.id"
+	spanIDLabel   = "span_id"
+	spanNameLabel = "span_name"
+)
+
+// profilingConfig holds the settings for continuous profiling.
+type profilingConfig struct {
+	ServiceName    string
+	ServiceApp     string
+	ServiceEnv     string
+	ServiceVersion string
+	// URL is the Pyroscope server the profiles are pushed to. Credentials
+	// in its userinfo are sent with basic authentication.
+	URL string
+}
+
+// profiler continuously records CPU profiles and pushes them to a Pyroscope
+// server through its ingest API.
+type profiler struct {
+	endpoint string
+	user     *url.Userinfo
+	name     string
+	client   *http.Client
+	logger   *slog.Logger
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// startProfiler starts profiling the process and pushing a CPU profile to
+// cfg.URL every 15 seconds.
+func startProfiler(cfg profilingConfig, logger *slog.Logger) (*profiler, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid profiling URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid profiling URL %q: scheme must be http or https", cfg.URL)
+	}
+	user := u.User
+	u.User = nil
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/ingest"
+
+	p := &profiler{
+		endpoint: u.String(),
+		user:     user,
+		name:     profileName(cfg),
+		client:   &http.Client{Timeout: 10 * time.Second},
+		logger:   logger,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.run()
+	return p, nil
+}
+
+// profileName returns the Pyroscope application name of the CPU profiles,
+// labeled with the service identity under the names traces use for it, so
+// that a trace's service finds its profiles.
+func profileName(cfg profilingConfig) string {
+	labels := []string{
+		"service_name=" + profileLabel(cfg.ServiceName),
+		"application=" + profileLabel(cfg.ServiceApp),
+		"environment=" + profileLabel(cfg.ServiceEnv),
+	}
+	if cfg.ServiceVersion != "" {
+		labels = append(labels, "service_version="+profileLabel(cfg.ServiceVersion))
+	}
+	return profileLabel(cfg.ServiceName) + ".cpu{" + strings.Join(labels, ",") + "}"
+}
+
+// profileLabel replaces the characters that delimit Pyroscope application
+// names and labels.
+var profileLabel = strings.NewReplacer("{", "_", "}", "_", ",", "_", "=", "_", " ", "_").Replace
+
+// run records CPU profiles until stopped. An on-demand profile from the
+// admin server ends the current period early, and the next starts once it
+// has finished.
+func (p *profiler) run() {
+	defer close(p.done)
+	for {
+		if !acquireCPUProfile(p.stop, false) {
+			return
+		}
+		var buf bytes.Buffer
+		from := time.Now()
+		if err := pprof.StartCPUProfile(&buf); err != nil {
+			releaseCPUProfile()
+			// Another CPU profile, such as one requested from
+			// net/http/pprof, is running; retry in the next period.
+			p.logger.Debug("Failed to start CPU profile", "error", err)
+			select {
+			case <-p.stop:
+				return
+			case <-time.After(profilingPeriod):
+			}
+			continue
+		}
+
+		stopped := false
+		select {
+		case <-p.stop:
+			stopped = true
+			pprof.StopCPUProfile()
+			releaseCPUProfile()
+		case handedOff := <-cpuProfileHandoff:
+			// The on-demand profile releases the slot once it is done.
+			pprof.StopCPUProfile()
+			close(handedOff)
+		case <-time.After(profilingPeriod):
+			pprof.StopCPUProfile()
+			releaseCPUProfile()
+		}
+		if err := p.upload(from, time.Now(), buf.Bytes()); err != nil {
+			p.logger.Warn("Failed to upload CPU profile", "error", err)
+		}
+		if stopped {
+			return
+		}
+	}
+}
+
+// upload pushes one pprof-encoded profile covering from to until.
+func (p *profiler) upload(from, until time.Time, profile []byte) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("profile", "profile.pprof")
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(profile); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	query := url.Values{
+		"name":       {p.name},
+		"from":       {strconv.FormatInt(from.Unix(), 10)},
+		"until":      {strconv.FormatInt(until.Unix(), 10)},
+		"spyName":    {"gospy"},
+		"sampleRate": {"100"},
+	}
+	req, err := http.NewRequest(http.MethodPost, p.endpoint+"?"+query.Encode(), &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	if p.user != nil {
+		password, _ := p.user.Password()
+		req.SetBasicAuth(p.user.Username(), password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("profile upload failed: %s", resp.Status)
+	}
+	return nil
+}
+
+// Shutdown stops profiling and uploads the profile recorded so far.
+func (p *profiler) Shutdown(ctx context.Context) error {
+	select {
+	case <-p.stop:
+	default:
+		close(p.stop)
+	}
+	select {
+	case <-p.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to shutdown profiler: %w", ctx.Err())
+	}
+}
+
+// ShutdownOrLog implements the Shutdowner interface.
+func (p *profiler) ShutdownOrLog(msg string) {
+	shutdownWithDefaultTimeout(p, msg)
+}
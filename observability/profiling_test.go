@@ -0,0 +1,146 @@
+package observability
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+)
+
+// pyroscopeUpload is what a fake Pyroscope server received in one request.
+type pyroscopeUpload struct {
+	path     string
+	query    map[string]string
+	user     string
+	password string
+	profile  *profile.Profile
+	err      error
+}
+
+// newFakePyroscope starts a server that decodes the profiles pushed to it
+// and sends them on the returned channel.
+func newFakePyroscope(t *testing.T) (*httptest.Server, <-chan pyroscopeUpload) {
+	uploads := make(chan pyroscopeUpload, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u := pyroscopeUpload{path: r.URL.Path, query: map[string]string{}}
+		for k := range r.URL.Query() {
+			u.query[k] = r.URL.Query().Get(k)
+		}
+		u.user, u.password, _ = r.BasicAuth()
+		file, _, err := r.FormFile("profile")
+		if err == nil {
+			u.profile, err = profile.Parse(file)
+			file.Close()
+		}
+		u.err = err
+		uploads <- u
+	}))
+	t.Cleanup(srv.Close)
+	return srv, uploads
+}
+
+func receiveUpload(t *testing.T, uploads <-chan pyroscopeUpload) pyroscopeUpload {
+	t.Helper()
+	select {
+	case u := <-uploads:
+		if u.err != nil {
+			t.Fatalf("decoding upload: %v", u.err)
+		}
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("no profile uploaded")
+		return pyroscopeUpload{}
+	}
+}
+
+// waitForProfiler waits until a CPU profile is running.
+func waitForProfiler(t *testing.T) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); len(cpuProfileSlot) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("profiler did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestProfilerUpload(t *testing.T) {
+	srv, uploads := newFakePyroscope(t)
+	cfg := profilingConfig{
+		ServiceName:    "checkout",
+		ServiceApp:     "shop",
+		ServiceEnv:     "prod",
+		ServiceVersion: "1.2.3",
+		URL:            "http://user:secret@" + srv.Listener.Addr().String() + "/",
+	}
+	p, err := startProfiler(cfg, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Unix()
+	waitForProfiler(t)
+	p.ShutdownOrLog("shutdown")
+
+	u := receiveUpload(t, uploads)
+	if u.path != "/ingest" {
+		t.Errorf("path = %q, want /ingest", u.path)
+	}
+	if u.user != "user" || u.password != "secret" {
+		t.Errorf("basic auth = %q:%q, want user:secret", u.user, u.password)
+	}
+	want := map[string]string{
+		"name":       "checkout.cpu{service_name=checkout,application=shop,environment=prod,service_version=1.2.3}",
+		"spyName":    "gospy",
+		"sampleRate": "100",
+	}
+	for k, v := range want {
+		if u.query[k] != v {
+			t.Errorf("%s = %q, want %q", k, u.query[k], v)
+		}
+	}
+	from, _ := strconv.ParseInt(u.query["from"], 10, 64)
+	until, _ := strconv.ParseInt(u.query["until"], 10, 64)
+	if from < start-1 || until < from {
+		t.Errorf("from, until = %d, %d; want from >= %d and until >= from", from, until, start-1)
+	}
+	if len(u.profile.SampleType) == 0 || u.profile.SampleType[0].Type != "samples" {
+		t.Errorf("sample types = %v, want a CPU profile", u.profile.SampleType)
+	}
+}
+
+func TestOnDemandCPUProfilePreemptsProfiler(t *testing.T) {
+	srv, uploads := newFakePyroscope(t)
+	p, err := startProfiler(profilingConfig{ServiceName: "checkout", URL: srv.URL}, slog.New(slog.DiscardHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.ShutdownOrLog("shutdown")
+	waitForProfiler(t)
+
+	rec := httptest.NewRecorder()
+	servePprofCPU(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/profile?seconds=0.05", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %q", rec.Code, rec.Body)
+	}
+	if _, err := profile.Parse(rec.Body); err != nil {
+		t.Errorf("decoding on-demand profile: %v", err)
+	}
+	// The profiler uploaded the period the on-demand profile cut short.
+	receiveUpload(t, uploads)
+}
+
+func TestAcquireCPUProfileCanceled(t *testing.T) {
+	if !acquireCPUProfile(nil, false) {
+		t.Fatal("could not acquire a free CPU profile")
+	}
+	defer releaseCPUProfile()
+	done := make(chan struct{})
+	close(done)
+	if acquireCPUProfile(done, true) {
+		t.Error("acquired a CPU profile in use")
+	}
+}
@@ -0,0 +1,102 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Headers of Datadog's trace propagation format.
+const (
+	datadogTraceIDHeader  = "x-datadog-trace-id"
+	datadogParentIDHeader = "x-datadog-parent-id"
+	datadogPriorityHeader = "x-datadog-sampling-priority"
+	datadogTagsHeader     = "x-datadog-tags"
+
+	// datadogTraceIDHighTag carries the upper 64 bits of a 128-bit trace ID,
+	// as 16 hex digits, in the x-datadog-tags header.
+	datadogTraceIDHighTag = "_dd.p.tid"
+)
+
+// datadogPropagator propagates trace context in the headers Datadog tracers
+// use, so OpenTelemetry-instrumented services join traces with services
+// instrumented by Datadog's own libraries. Datadog headers carry the lower
+// 64 bits of the trace ID in decimal, and the upper 64 bits in the _dd.p.tid
+// tag when the trace ID is 128 bits long.
+type datadogPropagator struct{}
+
+var _ propagation.TextMapPropagator = datadogPropagator{}
+
+func (datadogPropagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	traceID := sc.TraceID()
+	spanID := sc.SpanID()
+	carrier.Set(datadogTraceIDHeader, strconv.FormatUint(binary.BigEndian.Uint64(traceID[8:]), 10))
+	carrier.Set(datadogParentIDHeader, strconv.FormatUint(binary.BigEndian.Uint64(spanID[:]), 10))
+	if sc.IsSampled() {
+		carrier.Set(datadogPriorityHeader, "1")
+	} else {
+		carrier.Set(datadogPriorityHeader, "0")
+	}
+	if high := binary.BigEndian.Uint64(traceID[:8]); high != 0 {
+		carrier.Set(datadogTagsHeader, datadogTraceIDHighTag+"="+hex.EncodeToString(traceID[:8]))
+	}
+}
+
+func (datadogPropagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	low, err := strconv.ParseUint(carrier.Get(datadogTraceIDHeader), 10, 64)
+	if err != nil || low == 0 {
+		return ctx
+	}
+	parent, err := strconv.ParseUint(carrier.Get(datadogParentIDHeader), 10, 64)
+	if err != nil || parent == 0 {
+		return ctx
+	}
+
+	var traceID trace.TraceID
+	binary.BigEndian.PutUint64(traceID[8:], low)
+	if high, ok := datadogTag(carrier.Get(datadogTagsHeader), datadogTraceIDHighTag); ok {
+		if b, err := hex.DecodeString(high); err == nil && len(b) == 8 {
+			copy(traceID[:8], b)
+		}
+	}
+	var spanID trace.SpanID
+	binary.BigEndian.PutUint64(spanID[:], parent)
+
+	var flags trace.TraceFlags
+	// Priorities 1 (auto keep) and 2 (user keep) keep the trace.
+	if priority, err := strconv.Atoi(carrier.Get(datadogPriorityHeader)); err == nil && priority > 0 {
+		flags = trace.FlagsSampled
+	}
+	return trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	}))
+}
+
+func (datadogPropagator) Fields() []string {
+	return []string{datadogTraceIDHeader, datadogParentIDHeader, datadogPriorityHeader, datadogTagsHeader}
+}
+
+// datadogTag returns the value of key in an x-datadog-tags header, a
+// comma-separated list of key=value pairs.
+func datadogTag(tags, key string) (string, bool) {
+	for _, tag := range strings.Split(tags, ",") {
+		if k, v, ok := strings.Cut(tag, "="); ok && strings.TrimSpace(k) == key {
+			return strings.TrimSpace(v), true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,67 @@
+package observability
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Recover recovers from a panic in the calling goroutine, logs it with its
+// stack trace, and records it as an error on the active span. It must be
+// deferred directly, typically at the top of a goroutine:
+//
+//	go func() {
+//		defer obs.Recover()
+//		// ...
+//	}()
+func (o *Observability) Recover() {
+	if v := recover(); v != nil {
+		o.recordPanic(v)
+	}
+}
+
+// recordPanic logs a recovered panic value at error level, with the call
+// that panicked as its source. The log handler records the error on the
+// active span and sets its status to Error. It must be called from the
+// deferred function that recovered v.
+func (o *Observability) recordPanic(v any) {
+	err, ok := v.(error)
+	if !ok {
+		err = fmt.Errorf("panic: %v", v)
+	}
+	ctx := o.Log.getCtx()
+	if !o.Log.logger.Enabled(ctx, slog.LevelError) {
+		return
+	}
+	var pc uintptr
+	if forwardsSource(o.Log.logger.Handler(), slog.LevelError) {
+		pc = panicPC()
+	}
+	o.Log.handle(ctx, slog.LevelError, pc, "Recovered from panic", "error", err, "stack", string(debug.Stack()))
+}
+
+// panicPC returns the program counter of the call that panicked, as seen
+// from the deferred function recovering the panic: that of the first frame
+// outside the runtime below runtime.gopanic. It returns zero if the
+// goroutine is not panicking.
+func panicPC() uintptr {
+	var pcs [64]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs[:])])
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		switch {
+		case frame.Function == "runtime.gopanic":
+			panicking = true
+		case panicking && !strings.HasPrefix(frame.Function, "runtime."):
+			// Frame.PC is the call itself; the record's source is looked up
+			// from the return address, as runtime.Callers reports it.
+			return frame.PC + 1
+		}
+		if !more {
+			return 0
+		}
+	}
+}
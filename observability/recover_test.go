@@ -0,0 +1,93 @@
+package observability
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func panicWithValue() { panic("boom") }
+
+func panicNilMap() {
+	var m map[string]int
+	m["key"] = 1
+}
+
+func panicNilPointer() {
+	var p *int
+	_ = *p
+}
+
+func TestRecoverSource(t *testing.T) {
+	tests := []struct {
+		name string
+		fn   func()
+		want string
+	}{
+		{name: "panic", fn: panicWithValue, want: "github.com/app-obs/go/observability.panicWithValue"},
+		{name: "runtime error", fn: panicNilMap, want: "github.com/app-obs/go/observability.panicNilMap"},
+		{name: "fault", fn: panicNilPointer, want: "github.com/app-obs/go/observability.panicNilPointer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			obs := newSourceTestObservability(&buf)
+			func() {
+				defer obs.Recover()
+				tt.fn()
+			}()
+			if got := recordSource(t, &buf); got != tt.want {
+				t.Errorf("source = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewarePanicSource(t *testing.T) {
+	var buf bytes.Buffer
+	f := NewFactory(
+		WithServiceName("recover-test"),
+		WithApmType(string(None)),
+		WithMetricsType(string(NoneMetrics)),
+		WithSetSlogDefault(false),
+		WithLogHandler(func(slog.Handler) slog.Handler {
+			return slog.NewJSONHandler(&buf, &slog.HandlerOptions{AddSource: true})
+		}),
+	)
+	shutdowner, err := f.Setup(context.Background())
+	if err != nil {
+		t.Fatalf("Setup: %v", err)
+	}
+	defer shutdowner.Shutdown(context.Background())
+	buf.Reset()
+
+	handler := f.Middleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { panicWithValue() }))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record struct {
+			Msg    string      `json:"msg"`
+			Source slog.Source `json:"source"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("decoding %q: %v", scanner.Text(), err)
+		}
+		if record.Msg == "Recovered from panic" {
+			if want := "github.com/app-obs/go/observability.panicWithValue"; record.Source.Function != want {
+				t.Errorf("source = %s, want %s", record.Source.Function, want)
+			}
+			return
+		}
+	}
+	t.Fatalf("no panic record in %q", buf.String())
+}
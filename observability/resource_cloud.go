@@ -0,0 +1,220 @@
+package observability
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// metadataHost is the link-local address of the EC2, GCE, and Azure instance
+// metadata services.
+const metadataHost = "http://169.254.169.254"
+
+// metadataClient queries instance metadata services. Requests are bounded by
+// the detector's context; the metadata services are link-local and never
+// redirect, so no further settings are needed.
+var metadataClient = &http.Client{}
+
+// EC2Detector reports the cloud region, availability zone, account, and
+// instance of an AWS EC2 host, read from the instance metadata service
+// (IMDSv2).
+func EC2Detector() ResourceDetector {
+	return ResourceDetectorFunc(func(ctx context.Context) ([]attribute.KeyValue, error) {
+		token, err := fetchMetadata(ctx, http.MethodPut, metadataHost+"/latest/api/token",
+			map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+		if err != nil {
+			return nil, fmt.Errorf("EC2 metadata token: %w", err)
+		}
+		var doc struct {
+			AccountID        string `json:"accountId"`
+			Region           string `json:"region"`
+			AvailabilityZone string `json:"availabilityZone"`
+			InstanceID       string `json:"instanceId"`
+			InstanceType     string `json:"instanceType"`
+			ImageID          string `json:"imageId"`
+		}
+		if err := fetchMetadataJSON(ctx, metadataHost+"/latest/dynamic/instance-identity/document",
+			map[string]string{"X-aws-ec2-metadata-token": string(token)}, &doc); err != nil {
+			return nil, fmt.Errorf("EC2 instance identity: %w", err)
+		}
+		return nonEmptyAttrs(
+			"cloud.provider", "aws",
+			"cloud.platform", "aws_ec2",
+			"cloud.region", doc.Region,
+			"cloud.availability_zone", doc.AvailabilityZone,
+			"cloud.account.id", doc.AccountID,
+			"host.id", doc.InstanceID,
+			"host.type", doc.InstanceType,
+			"host.image.id", doc.ImageID,
+		), nil
+	})
+}
+
+// ECSDetector reports the cluster, task, and container of an AWS ECS task,
+// read from the task metadata endpoint (version 4). It returns nothing
+// outside ECS.
+func ECSDetector() ResourceDetector {
+	return ResourceDetectorFunc(func(ctx context.Context) ([]attribute.KeyValue, error) {
+		endpoint := os.Getenv("ECS_CONTAINER_METADATA_URI_V4")
+		if endpoint == "" {
+			return nil, nil
+		}
+		var container struct {
+			DockerID     string `json:"DockerId"`
+			ContainerARN string `json:"ContainerARN"`
+		}
+		if err := fetchMetadataJSON(ctx, endpoint, nil, &container); err != nil {
+			return nil, fmt.Errorf("ECS container metadata: %w", err)
+		}
+		var task struct {
+			Cluster          string `json:"Cluster"`
+			TaskARN          string `json:"TaskARN"`
+			Family           string `json:"Family"`
+			Revision         string `json:"Revision"`
+			AvailabilityZone string `json:"AvailabilityZone"`
+			LaunchType       string `json:"LaunchType"`
+		}
+		if err := fetchMetadataJSON(ctx, endpoint+"/task", nil, &task); err != nil {
+			return nil, fmt.Errorf("ECS task metadata: %w", err)
+		}
+		// ARNs look like arn:aws:ecs:<region>:<account>:task/<cluster>/<id>.
+		var region, account string
+		if parts := strings.SplitN(task.TaskARN, ":", 6); len(parts) == 6 {
+			region, account = parts[3], parts[4]
+		}
+		return nonEmptyAttrs(
+			"cloud.provider", "aws",
+			"cloud.platform", "aws_ecs",
+			"cloud.region", region,
+			"cloud.account.id", account,
+			"cloud.availability_zone", task.AvailabilityZone,
+			"aws.ecs.cluster.arn", task.Cluster,
+			"aws.ecs.task.arn", task.TaskARN,
+			"aws.ecs.task.family", task.Family,
+			"aws.ecs.task.revision", task.Revision,
+			"aws.ecs.launchtype", strings.ToLower(task.LaunchType),
+			"aws.ecs.container.arn", container.ContainerARN,
+			"container.id", container.DockerID,
+		), nil
+	})
+}
+
+// GCPDetector reports the project, zone, and instance of a Google Compute
+// Engine host, read from the metadata server.
+func GCPDetector() ResourceDetector {
+	return ResourceDetectorFunc(func(ctx context.Context) ([]attribute.KeyValue, error) {
+		header := map[string]string{"Metadata-Flavor": "Google"}
+		project, err := fetchMetadata(ctx, http.MethodGet, metadataHost+"/computeMetadata/v1/project/project-id", header)
+		if err != nil {
+			return nil, fmt.Errorf("GCP project metadata: %w", err)
+		}
+		var instance struct {
+			ID          json.Number `json:"id"`
+			Name        string      `json:"name"`
+			Zone        string      `json:"zone"`
+			MachineType string      `json:"machineType"`
+		}
+		if err := fetchMetadataJSON(ctx, metadataHost+"/computeMetadata/v1/instance/?recursive=true", header, &instance); err != nil {
+			return nil, fmt.Errorf("GCP instance metadata: %w", err)
+		}
+		// Zone and machine type are resource paths such as
+		// projects/<number>/zones/us-central1-a.
+		zone := lastPathSegment(instance.Zone)
+		region := zone
+		if i := strings.LastIndex(zone, "-"); i > 0 {
+			region = zone[:i]
+		}
+		return nonEmptyAttrs(
+			"cloud.provider", "gcp",
+			"cloud.platform", "gcp_compute_engine",
+			"cloud.account.id", string(project),
+			"cloud.region", region,
+			"cloud.availability_zone", zone,
+			"host.id", instance.ID.String(),
+			"host.name", instance.Name,
+			"host.type", lastPathSegment(instance.MachineType),
+		), nil
+	})
+}
+
+// AzureDetector reports the subscription, region, and virtual machine of an
+// Azure VM, read from the instance metadata service.
+func AzureDetector() ResourceDetector {
+	return ResourceDetectorFunc(func(ctx context.Context) ([]attribute.KeyValue, error) {
+		var compute struct {
+			Location       string `json:"location"`
+			Name           string `json:"name"`
+			VMID           string `json:"vmId"`
+			VMSize         string `json:"vmSize"`
+			SubscriptionID string `json:"subscriptionId"`
+			Zone           string `json:"zone"`
+		}
+		if err := fetchMetadataJSON(ctx, metadataHost+"/metadata/instance/compute?api-version=2021-02-01",
+			map[string]string{"Metadata": "true"}, &compute); err != nil {
+			return nil, fmt.Errorf("Azure instance metadata: %w", err)
+		}
+		return nonEmptyAttrs(
+			"cloud.provider", "azure",
+			"cloud.platform", "azure_vm",
+			"cloud.region", compute.Location,
+			"cloud.availability_zone", compute.Zone,
+			"cloud.account.id", compute.SubscriptionID,
+			"host.id", compute.VMID,
+			"host.name", compute.Name,
+			"host.type", compute.VMSize,
+		), nil
+	})
+}
+
+// fetchMetadata performs a metadata request and returns the response body.
+func fetchMetadata(ctx context.Context, method, url string, header map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := metadataClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// fetchMetadataJSON performs a GET metadata request and decodes the JSON
+// response into v.
+func fetchMetadataJSON(ctx context.Context, url string, header map[string]string, v any) error {
+	body, err := fetchMetadata(ctx, http.MethodGet, url, header)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// nonEmptyAttrs builds string attributes from key/value pairs, skipping
+// empty values.
+func nonEmptyAttrs(kv ...string) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		if kv[i+1] != "" {
+			attrs = append(attrs, attribute.String(kv[i], kv[i+1]))
+		}
+	}
+	return attrs
+}
+
+// lastPathSegment returns the part of path after the final slash.
+func lastPathSegment(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
@@ -0,0 +1,128 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// resourceDetectTimeout bounds each detector, since cloud detectors query
+// metadata endpoints that do not answer outside their platform.
+const resourceDetectTimeout = 2 * time.Second
+
+// ResourceDetector finds attributes describing where the service runs, such
+// as the host, container, or cloud region. A detector that does not apply to
+// the current environment returns no attributes and no error.
+type ResourceDetector interface {
+	Detect(ctx context.Context) ([]attribute.KeyValue, error)
+}
+
+// ResourceDetectorFunc adapts a function to the ResourceDetector interface.
+type ResourceDetectorFunc func(ctx context.Context) ([]attribute.KeyValue, error)
+
+// Detect calls f(ctx).
+func (f ResourceDetectorFunc) Detect(ctx context.Context) ([]attribute.KeyValue, error) {
+	return f(ctx)
+}
+
+// HostDetector reports host.name, host.arch, and os.type.
+func HostDetector() ResourceDetector {
+	return ResourceDetectorFunc(func(ctx context.Context) ([]attribute.KeyValue, error) {
+		attrs := []attribute.KeyValue{
+			attribute.String("host.arch", hostArch()),
+			attribute.String("os.type", runtime.GOOS),
+		}
+		if name, err := os.Hostname(); err == nil {
+			attrs = append(attrs, attribute.String("host.name", name))
+		}
+		return attrs, nil
+	})
+}
+
+// KubernetesDetector reports the pod, namespace, and node of the service and
+// its container ID when running in a Kubernetes cluster.
+func KubernetesDetector() ResourceDetector {
+	return ResourceDetectorFunc(func(ctx context.Context) ([]attribute.KeyValue, error) {
+		return detectKubernetes(), nil
+	})
+}
+
+// defaultResourceDetectors are used by WithResourceDetection. They only read
+// local state, so they never delay startup.
+func defaultResourceDetectors() []ResourceDetector {
+	return []ResourceDetector{HostDetector(), KubernetesDetector(), ServerlessDetector()}
+}
+
+// resourceDetectorsByName maps the names accepted by OBS_RESOURCE_DETECTORS
+// to their detectors.
+var resourceDetectorsByName = map[string]func() ResourceDetector{
+	"host":       HostDetector,
+	"k8s":        KubernetesDetector,
+	"ec2":        EC2Detector,
+	"ecs":        ECSDetector,
+	"gcp":        GCPDetector,
+	"azure":      AzureDetector,
+	"serverless": ServerlessDetector,
+}
+
+// parseResourceDetectors parses a comma-separated list of detector names,
+// ignoring unknown ones.
+func parseResourceDetectors(names string) []ResourceDetector {
+	var detectors []ResourceDetector
+	for _, name := range strings.Split(names, ",") {
+		if newDetector, ok := resourceDetectorsByName[strings.ToLower(strings.TrimSpace(name))]; ok {
+			detectors = append(detectors, newDetector())
+		}
+	}
+	return detectors
+}
+
+// detectResource runs detectors concurrently and merges their attributes in
+// order, so a later detector overrides a key set by an earlier one. A
+// detector that fails, typically because the service does not run on its
+// platform, is logged at debug level and skipped.
+func detectResource(ctx context.Context, logger *slog.Logger, detectors []ResourceDetector) []attribute.KeyValue {
+	results := make([][]attribute.KeyValue, len(detectors))
+	var wg sync.WaitGroup
+	for i, d := range detectors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, resourceDetectTimeout)
+			defer cancel()
+			attrs, err := d.Detect(ctx)
+			if err != nil {
+				logger.Debug("Resource detector found nothing", "error", err)
+				return
+			}
+			results[i] = attrs
+		}()
+	}
+	wg.Wait()
+
+	var merged []attribute.KeyValue
+	for _, attrs := range results {
+		merged = append(merged, attrs...)
+	}
+	return merged
+}
+
+// hostArch maps GOARCH to the host.arch semantic convention values.
+func hostArch() string {
+	switch runtime.GOARCH {
+	case "386":
+		return "x86"
+	case "arm":
+		return "arm32"
+	case "ppc64le", "ppc64":
+		return "ppc64"
+	default:
+		return runtime.GOARCH
+	}
+}
@@ -0,0 +1,98 @@
+package observability
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	k8sNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	cgroupFile       = "/proc/self/cgroup"
+	mountInfoFile    = "/proc/self/mountinfo"
+)
+
+var (
+	// cgroupContainerID matches the container ID at the end of a cgroup v1
+	// path, e.g. ".../docker-<id>.scope" or ".../kubepods/.../<id>".
+	cgroupContainerID = regexp.MustCompile(`([0-9a-f]{64})(?:\.scope)?$`)
+	// mountContainerID matches the container ID in a cgroup v2 mount, where
+	// runtimes bind-mount per-container files such as /etc/hostname.
+	mountContainerID = regexp.MustCompile(`/containers/([0-9a-f]{64})/`)
+)
+
+// detectKubernetes returns the k8s.* and container.id resource attributes of
+// the current pod, or nil when not running in a Kubernetes cluster. Values
+// come from downward API environment variables where the deployment exposes
+// them (K8S_POD_NAME or POD_NAME, K8S_NAMESPACE_NAME or POD_NAMESPACE,
+// K8S_NODE_NAME or NODE_NAME, K8S_POD_UID or POD_UID), falling back to the
+// hostname for the pod name and the service account namespace file.
+func detectKubernetes() []attribute.KeyValue {
+	if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	add := func(key, value string) {
+		if value != "" {
+			attrs = append(attrs, attribute.String(key, value))
+		}
+	}
+
+	podName := firstEnv("K8S_POD_NAME", "POD_NAME")
+	if podName == "" {
+		podName, _ = os.Hostname()
+	}
+	namespace := firstEnv("K8S_NAMESPACE_NAME", "POD_NAMESPACE")
+	if namespace == "" {
+		if b, err := os.ReadFile(k8sNamespaceFile); err == nil {
+			namespace = strings.TrimSpace(string(b))
+		}
+	}
+
+	add("k8s.pod.name", podName)
+	add("k8s.pod.uid", firstEnv("K8S_POD_UID", "POD_UID"))
+	add("k8s.namespace.name", namespace)
+	add("k8s.node.name", firstEnv("K8S_NODE_NAME", "NODE_NAME"))
+	add("container.id", containerID())
+	return attrs
+}
+
+// containerID returns the ID of the container the process runs in, read from
+// the cgroup v1 hierarchy or, on cgroup v2 hosts, from the mount table.
+func containerID() string {
+	if id := scanFile(cgroupFile, cgroupContainerID); id != "" {
+		return id
+	}
+	return scanFile(mountInfoFile, mountContainerID)
+}
+
+// scanFile returns the first submatch of re on any line of path.
+func scanFile(path string, re *regexp.Regexp) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := re.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// firstEnv returns the value of the first of keys that is set.
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if val := os.Getenv(key); val != "" {
+			return val
+		}
+	}
+	return ""
+}
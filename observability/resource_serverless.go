@@ -0,0 +1,73 @@
+package observability
+
+import (
+	"context"
+	"os"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ServerlessDetector reports the function or service and its revision when
+// running on a serverless platform: Google Cloud Run (services and jobs),
+// Google Cloud Functions, AWS Lambda, or Azure Functions. It reads the
+// environment the platform sets, such as K_SERVICE, FUNCTION_TARGET, and
+// AWS_LAMBDA_FUNCTION_NAME, and returns nothing elsewhere.
+func ServerlessDetector() ResourceDetector {
+	return ResourceDetectorFunc(func(ctx context.Context) ([]attribute.KeyValue, error) {
+		return detectServerless(), nil
+	})
+}
+
+// detectServerless returns the cloud.* and faas.* resource attributes of the
+// serverless platform the process runs on, or nil if there is none.
+func detectServerless() []attribute.KeyValue {
+	switch {
+	case os.Getenv("AWS_LAMBDA_FUNCTION_NAME") != "":
+		attrs := nonEmptyAttrs(
+			"cloud.provider", "aws",
+			"cloud.platform", "aws_lambda",
+			"cloud.region", os.Getenv("AWS_REGION"),
+			"faas.name", os.Getenv("AWS_LAMBDA_FUNCTION_NAME"),
+			"faas.version", os.Getenv("AWS_LAMBDA_FUNCTION_VERSION"),
+			"faas.instance", os.Getenv("AWS_LAMBDA_LOG_STREAM_NAME"),
+		)
+		if mb, err := strconv.Atoi(os.Getenv("AWS_LAMBDA_FUNCTION_MEMORY_SIZE")); err == nil {
+			attrs = append(attrs, attribute.Int64("faas.max_memory", int64(mb)<<20))
+		}
+		return attrs
+	case os.Getenv("FUNCTION_TARGET") != "":
+		// Cloud Functions sets K_SERVICE to the function's name, and its
+		// first generation FUNCTION_NAME instead.
+		return nonEmptyAttrs(
+			"cloud.provider", "gcp",
+			"cloud.platform", "gcp_cloud_functions",
+			"cloud.region", os.Getenv("FUNCTION_REGION"),
+			"faas.name", firstEnv("K_SERVICE", "FUNCTION_NAME"),
+			"faas.version", os.Getenv("K_REVISION"),
+		)
+	case os.Getenv("K_SERVICE") != "":
+		return nonEmptyAttrs(
+			"cloud.provider", "gcp",
+			"cloud.platform", "gcp_cloud_run",
+			"faas.name", os.Getenv("K_SERVICE"),
+			"faas.version", os.Getenv("K_REVISION"),
+		)
+	case os.Getenv("CLOUD_RUN_JOB") != "":
+		return nonEmptyAttrs(
+			"cloud.provider", "gcp",
+			"cloud.platform", "gcp_cloud_run",
+			"faas.name", os.Getenv("CLOUD_RUN_JOB"),
+			"gcp.cloud_run.job.execution", os.Getenv("CLOUD_RUN_EXECUTION"),
+			"gcp.cloud_run.job.task_index", os.Getenv("CLOUD_RUN_TASK_INDEX"),
+		)
+	case os.Getenv("FUNCTIONS_WORKER_RUNTIME") != "":
+		return nonEmptyAttrs(
+			"cloud.provider", "azure",
+			"cloud.platform", "azure_functions",
+			"cloud.region", os.Getenv("REGION_NAME"),
+			"faas.name", os.Getenv("WEBSITE_SITE_NAME"),
+		)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package observability
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// responseRecorder wraps an http.ResponseWriter to capture the status code and
+// the number of body bytes written, and optionally the start of the body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	bytes       int64
+	wroteHeader bool
+	body        *captureBuffer
+}
+
+func newResponseRecorder(w http.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+}
+
+// WriteHeader records the status code and forwards it.
+func (rw *responseRecorder) WriteHeader(code int) {
+	if !rw.wroteHeader {
+		rw.status = code
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+// Write records the body size and forwards the bytes.
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += int64(n)
+	if rw.body != nil {
+		rw.body.write(b[:n])
+	}
+	return n, err
+}
+
+// Flush implements http.Flusher when the underlying writer supports it.
+func (rw *responseRecorder) Flush() {
+	rw.wroteHeader = true
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, for use by http.ResponseController.
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// Hijack implements http.Hijacker when the underlying writer supports it,
+// and otherwise fails with http.ErrNotSupported. What is written to the
+// hijacked connection is not recorded.
+func (rw *responseRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, buf, err := http.NewResponseController(rw.ResponseWriter).Hijack()
+	if err == nil {
+		rw.wroteHeader = true
+	}
+	return conn, buf, err
+}
+
+// ReadFrom implements io.ReaderFrom, so the underlying writer can still send
+// files with sendfile, unless the body is being captured.
+func (rw *responseRecorder) ReadFrom(src io.Reader) (int64, error) {
+	rf, ok := rw.ResponseWriter.(io.ReaderFrom)
+	if !ok || rw.body != nil {
+		// writerOnly hides ReadFrom, which io.Copy would call again.
+		return io.Copy(writerOnly{rw}, src)
+	}
+	rw.wroteHeader = true
+	n, err := rf.ReadFrom(src)
+	rw.bytes += n
+	return n, err
+}
+
+// writerOnly exposes only the Write method of a writer.
+type writerOnly struct{ io.Writer }
@@ -0,0 +1,102 @@
+package observability
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestResponseRecorderHijack(t *testing.T) {
+	t.Run("supported", func(t *testing.T) {
+		hijacked := make(chan error, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := newResponseRecorder(w)
+			conn, buf, err := rw.Hijack()
+			if err == nil {
+				buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 2\r\n\r\nok")
+				buf.Flush()
+				conn.Close()
+			}
+			hijacked <- err
+		}))
+		defer srv.Close()
+
+		resp, err := http.Get(srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err := <-hijacked; err != nil {
+			t.Fatalf("Hijack: %v", err)
+		}
+		if string(body) != "ok" {
+			t.Errorf("body = %q, want %q", body, "ok")
+		}
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		rw := newResponseRecorder(httptest.NewRecorder())
+		if _, _, err := rw.Hijack(); !errors.Is(err, http.ErrNotSupported) {
+			t.Errorf("Hijack error = %v, want http.ErrNotSupported", err)
+		}
+	})
+}
+
+// readerFromWriter records whether ReadFrom was called.
+type readerFromWriter struct {
+	*httptest.ResponseRecorder
+	called bool
+}
+
+func (w *readerFromWriter) ReadFrom(src io.Reader) (int64, error) {
+	w.called = true
+	return io.Copy(w.ResponseRecorder, src)
+}
+
+func TestResponseRecorderReadFrom(t *testing.T) {
+	const body = "hello, world"
+	tests := []struct {
+		name       string
+		readerFrom bool
+		capture    bool
+		wantCalled bool
+	}{
+		{name: "delegates", readerFrom: true, wantCalled: true},
+		{name: "copies without ReaderFrom"},
+		{name: "copies while capturing", readerFrom: true, capture: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := &readerFromWriter{ResponseRecorder: httptest.NewRecorder()}
+			var w http.ResponseWriter = base.ResponseRecorder
+			if tt.readerFrom {
+				w = base
+			}
+			rw := newResponseRecorder(w)
+			if tt.capture {
+				rw.body = newCaptureBuffer(5)
+			}
+
+			n, err := rw.ReadFrom(strings.NewReader(body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if n != int64(len(body)) || rw.bytes != n {
+				t.Errorf("copied %d bytes, recorded %d, want %d", n, rw.bytes, len(body))
+			}
+			if got := base.Body.String(); got != body {
+				t.Errorf("body = %q, want %q", got, body)
+			}
+			if tt.capture && string(rw.body.data) != body[:5] {
+				t.Errorf("captured %q, want %q", rw.body.data, body[:5])
+			}
+			if base.called != tt.wantCalled {
+				t.Errorf("underlying ReadFrom called = %v, want %v", base.called, tt.wantCalled)
+			}
+		})
+	}
+}
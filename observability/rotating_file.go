@@ -0,0 +1,168 @@
+package observability
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for FileRotation fields left at zero.
+const (
+	defaultFileMaxBytes   = 100 << 20
+	defaultFileMaxBackups = 10
+)
+
+// FileRotation bounds the files written by the file exporters. Once the
+// active file would grow past MaxBytes, it is renamed with the time of the
+// rotation appended to its name (spans.jsonl becomes
+// spans-20261016T140500.000.jsonl) and a new file is started. Rotated files
+// are never written to again, so they can be shipped as they appear; only
+// the newest MaxBackups are kept. A zero field keeps its default: 100 MiB and
+// 10 backups.
+type FileRotation struct {
+	MaxBytes   int64
+	MaxBackups int
+}
+
+func (r FileRotation) withDefaults() FileRotation {
+	if r.MaxBytes <= 0 {
+		r.MaxBytes = defaultFileMaxBytes
+	}
+	if r.MaxBackups <= 0 {
+		r.MaxBackups = defaultFileMaxBackups
+	}
+	return r
+}
+
+// backupTimeFormat is the layout of the rotation time in backup names.
+const backupTimeFormat = "20060102T150405.000"
+
+// rotatingFile is an io.WriteCloser that appends to a file and rotates it as
+// configured. Each Write goes entirely to one file, so records written with
+// a single Write are never split across files.
+type rotatingFile struct {
+	path     string
+	rotation FileRotation
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens path for appending, creating it and its directory
+// if needed.
+func openRotatingFile(path string, rotation FileRotation) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, rotation: rotation.withDefaults()}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.size > 0 && f.size+int64(len(p)) > f.rotation.MaxBytes {
+		if err := f.rotate(); err != nil {
+			return 0, fmt.Errorf("failed to rotate %s: %w", f.path, err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the active file to its backup name, opens a new one, and
+// removes the backups beyond the configured number.
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	f.file = nil
+	ext := filepath.Ext(f.path)
+	prefix := strings.TrimSuffix(f.path, ext) + "-"
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return err
+	}
+	// Backups are named for their rotation time, so their names sort by it.
+	// Other files matching the pattern are left alone.
+	rotated := func(name string) (time.Time, error) {
+		return time.Parse(backupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+	}
+	var backups []string
+	for _, name := range matches {
+		if _, err := rotated(name); err == nil {
+			backups = append(backups, name)
+		}
+	}
+	slices.Sort(backups)
+	// A rotation within the same millisecond as the newest backup, or after
+	// the clock stepped back, is named a millisecond after it, so that no
+	// backup is overwritten and names keep sorting by rotation order.
+	t := time.Now().UTC().Truncate(time.Millisecond)
+	if n := len(backups); n > 0 {
+		if last, _ := rotated(backups[n-1]); !t.After(last) {
+			t = last.Add(time.Millisecond)
+		}
+	}
+	backup := prefix + t.Format(backupTimeFormat) + ext
+	if err := os.Rename(f.path, backup); err != nil {
+		return err
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	backups = append(backups, backup)
+	for len(backups) > f.rotation.MaxBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// Sync commits the active file to stable storage.
+func (f *rotatingFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	return f.file.Sync()
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,150 @@
+package observability
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// readBackups returns the contents of the rotated files of path, oldest
+// first.
+func readBackups(t *testing.T, path string) []string {
+	t.Helper()
+	names, err := filepath.Glob(strings.TrimSuffix(path, ".jsonl") + "-*.jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(names)
+	var contents []string
+	for _, name := range names {
+		if !regexp.MustCompile(`-\d{8}T\d{6}\.\d{3}\.jsonl$`).MatchString(name) {
+			t.Errorf("backup name %s", name)
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contents = append(contents, string(b))
+	}
+	return contents
+}
+
+func TestRotatingFile(t *testing.T) {
+	tests := []struct {
+		name        string
+		rotation    FileRotation
+		writes      []string
+		wantActive  string
+		wantBackups []string
+	}{
+		{
+			name:       "within limit",
+			rotation:   FileRotation{MaxBytes: 10},
+			writes:     []string{"aaaa\n", "bbbb\n"},
+			wantActive: "aaaa\nbbbb\n",
+		},
+		{
+			name:        "rotates before a write past the limit",
+			rotation:    FileRotation{MaxBytes: 10},
+			writes:      []string{"aaaa\n", "bbbb\n", "c\n"},
+			wantActive:  "c\n",
+			wantBackups: []string{"aaaa\nbbbb\n"},
+		},
+		{
+			name:        "oversized write kept whole",
+			rotation:    FileRotation{MaxBytes: 4},
+			writes:      []string{"a\n", "bbbbbbbb\n", "c\n"},
+			wantActive:  "c\n",
+			wantBackups: []string{"a\n", "bbbbbbbb\n"},
+		},
+		{
+			name:        "oldest backups removed",
+			rotation:    FileRotation{MaxBytes: 2, MaxBackups: 2},
+			writes:      []string{"1\n", "2\n", "3\n", "4\n", "5\n"},
+			wantActive:  "5\n",
+			wantBackups: []string{"3\n", "4\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "traces", "spans.jsonl")
+			f, err := openRotatingFile(path, tt.rotation)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, w := range tt.writes {
+				if n, err := f.Write([]byte(w)); err != nil || n != len(w) {
+					t.Fatalf("Write(%q) = %d, %v", w, n, err)
+				}
+			}
+			if err := f.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			active, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(active) != tt.wantActive {
+				t.Errorf("active file = %q, want %q", active, tt.wantActive)
+			}
+			if got := readBackups(t, path); !slices.Equal(got, tt.wantBackups) {
+				t.Errorf("backups = %q, want %q", got, tt.wantBackups)
+			}
+		})
+	}
+}
+
+func TestRotatingFileAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spans.jsonl")
+	if err := os.WriteFile(path, []byte("aaaa\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := openRotatingFile(path, FileRotation{MaxBytes: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The existing content counts toward the limit.
+	f.Write([]byte("bbbb\n"))
+	f.Close()
+	if got := readBackups(t, path); !slices.Equal(got, []string{"aaaa\n"}) {
+		t.Errorf("backups = %q, want the existing content", got)
+	}
+	if _, err := f.Write([]byte("c\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write after Close: %v, want os.ErrClosed", err)
+	}
+}
+
+func TestFileRotationDefaults(t *testing.T) {
+	got := FileRotation{MaxBytes: -1}.withDefaults()
+	if got.MaxBytes != defaultFileMaxBytes || got.MaxBackups != defaultFileMaxBackups {
+		t.Errorf("defaults = %+v", got)
+	}
+	got = FileRotation{MaxBytes: 1, MaxBackups: 2}.withDefaults()
+	if got.MaxBytes != 1 || got.MaxBackups != 2 {
+		t.Errorf("explicit values changed to %+v", got)
+	}
+}
+
+func TestRotatingFileKeepsOtherFiles(t *testing.T) {
+	dir := t.TempDir()
+	other := filepath.Join(dir, "spans-archive.jsonl")
+	if err := os.WriteFile(other, []byte("keep\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := openRotatingFile(filepath.Join(dir, "spans.jsonl"), FileRotation{MaxBytes: 2, MaxBackups: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, w := range []string{"1\n", "2\n", "3\n"} {
+		f.Write([]byte(w))
+	}
+	f.Close()
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("file matching the backup pattern removed: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package observability
+
+import (
+	"context"
+	"time"
+)
+
+// SamplingPriorityKey is the attribute with which kept spans are marked,
+// following the OpenTracing convention honored by the Datadog Agent and by
+// tail-sampling collectors: a positive priority asks for the trace to be
+// retained.
+const SamplingPriorityKey = "sampling.priority"
+
+// forceSampleKey marks a context whose new spans are sampled regardless of
+// the sample rate.
+type forceSampleKey struct{}
+
+// forceSampled reports whether ctx was marked by Observability.ForceSample.
+func forceSampled(ctx context.Context) bool {
+	keep, _ := ctx.Value(forceSampleKey{}).(bool)
+	return keep
+}
+
+// ForceSample marks the current trace for retention regardless of the
+// sample rate, so that business-critical operations, such as payments, are
+// always traced. It keeps the active span, if any (see Span.Keep), and
+// returns an Observability whose new spans, and those started from its
+// context, are sampled:
+//
+//	obs = obs.ForceSample()
+//	ctx, obs, span := obs.StartSpan("charge", nil)
+//
+// A span that was not sampled when it started cannot be recorded after the
+// fact, so call ForceSample before starting the spans that matter.
+func (o *Observability) ForceSample() *Observability {
+	if span := o.providers.spans.SpanFromContext(o.ctx); span != nil {
+		span.Keep()
+	}
+	return o.clone(context.WithValue(o.ctx, forceSampleKey{}, true))
+}
+
+// TailSampling configures the tail sampling of WithTailSampling.
+type TailSampling struct {
+	// Latency keeps the traces whose local root span took at least this
+	// long. Zero keeps no trace for its latency.
+	Latency time.Duration
+	// BaselineRatio is the fraction, in [0, 1], of the other traces kept, so
+	// that normal traffic stays visible too.
+	BaselineRatio float64
+	// Window is how long the spans of a trace are held waiting for its root
+	// span to end. Traces still open after it are decided on the spans seen
+	// so far. Zero means 10 seconds.
+	Window time.Duration
+	// MaxTraces bounds the traces held at once; the oldest are decided early
+	// when it is exceeded. Zero means 10000.
+	MaxTraces int
+}
+
+func (t TailSampling) withDefaults() TailSampling {
+	if t.Window <= 0 {
+		t.Window = 10 * time.Second
+	}
+	if t.MaxTraces <= 0 {
+		t.MaxTraces = 10000
+	}
+	return t
+}
@@ -0,0 +1,112 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// adaptiveWindow is how often the sampling probability of an operation
+	// is adjusted to its throughput.
+	adaptiveWindow = 15 * time.Second
+	// maxAdaptiveOperations bounds the operations tracked separately; root
+	// spans of any further operations share one budget.
+	maxAdaptiveOperations = 1000
+)
+
+// adaptiveSampler samples root spans so that each operation, identified by
+// the span name, yields about a target number of traces per minute: every
+// adaptiveWindow, the probability of an operation is set to the ratio of its
+// budget to the root spans it had in the last window. Within a window, at
+// most twice the budget is sampled, bounding bursts. Child spans follow the
+// decision of their parent, so traces stay complete.
+type adaptiveSampler struct {
+	// budget is the number of traces per operation to sample per window.
+	budget float64
+
+	mu  sync.Mutex
+	ops map[string]*operationRate
+}
+
+// operationRate tracks the throughput of one operation.
+type operationRate struct {
+	windowStart time.Time
+	seen        int
+	sampled     int
+	probability float64
+}
+
+func newAdaptiveSampler(tracesPerMinute int) *adaptiveSampler {
+	return &adaptiveSampler{
+		budget: float64(tracesPerMinute) * adaptiveWindow.Seconds() / time.Minute.Seconds(),
+		ops:    make(map[string]*operationRate),
+	}
+}
+
+func (s *adaptiveSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	parent := trace.SpanContextFromContext(p.ParentContext)
+	result := sdktrace.SamplingResult{Decision: sdktrace.Drop, Tracestate: parent.TraceState()}
+	if parent.IsValid() {
+		if parent.IsSampled() {
+			result.Decision = sdktrace.RecordAndSample
+		}
+		return result
+	}
+	if s.sample(p.Name, p.TraceID) {
+		result.Decision = sdktrace.RecordAndSample
+	}
+	return result
+}
+
+// sample decides whether to sample a root span of the named operation.
+func (s *adaptiveSampler) sample(name string, traceID trace.TraceID) bool {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	op, ok := s.ops[name]
+	if !ok {
+		if len(s.ops) >= maxAdaptiveOperations {
+			name = ""
+			op = s.ops[name]
+		}
+		if op == nil {
+			op = &operationRate{windowStart: now, probability: 1}
+			s.ops[name] = op
+		}
+	}
+	if elapsed := now.Sub(op.windowStart); elapsed >= adaptiveWindow {
+		// Scale to a full window, in case the operation was idle for
+		// several.
+		perWindow := float64(op.seen) * adaptiveWindow.Seconds() / elapsed.Seconds()
+		op.probability = 1
+		if perWindow > s.budget {
+			op.probability = s.budget / perWindow
+		}
+		op.windowStart, op.seen, op.sampled = now, 0, 0
+	}
+	op.seen++
+
+	if float64(op.sampled) >= 2*s.budget {
+		return false
+	}
+	// Like TraceIDRatioBased, compare the lower 63 bits of the trace ID with
+	// the probability, so the decision is random yet reproducible.
+	x := binary.BigEndian.Uint64(traceID[8:16]) >> 1
+	if float64(x) >= op.probability*(1<<63) {
+		return false
+	}
+	op.sampled++
+	return true
+}
+
+func (s *adaptiveSampler) Description() string {
+	return fmt.Sprintf("AdaptiveSampler{%g per %s}", s.budget, adaptiveWindow)
+}
@@ -0,0 +1,114 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"encoding/binary"
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// traceIDAt returns a trace ID whose lower 63 bits are the fraction x of
+// their range, which ratio-based samplers compare with their probability.
+func traceIDAt(x float64) trace.TraceID {
+	var id trace.TraceID
+	binary.BigEndian.PutUint64(id[8:], uint64(x*(1<<63))<<1)
+	return id
+}
+
+func TestAdaptiveSamplerBudget(t *testing.T) {
+	// 4 traces per minute is a budget of 1 per 15-second window, of which
+	// at most twice are sampled.
+	s := newAdaptiveSampler(4)
+	var sampled int
+	for range 10 {
+		if s.sample("GET /orders", traceIDAt(0)) {
+			sampled++
+		}
+	}
+	if sampled != 2 {
+		t.Errorf("sampled %d root spans in the first window, want 2", sampled)
+	}
+	// Other operations have budgets of their own.
+	if !s.sample("GET /users", traceIDAt(0)) {
+		t.Error("did not sample another operation")
+	}
+}
+
+func TestAdaptiveSamplerAdjustsProbability(t *testing.T) {
+	tests := []struct {
+		name            string
+		seen            int
+		elapsed         time.Duration
+		wantProbability float64
+	}{
+		{name: "under budget", seen: 5, elapsed: adaptiveWindow, wantProbability: 1},
+		{name: "over budget", seen: 40, elapsed: adaptiveWindow, wantProbability: 0.25},
+		{name: "idle windows", seen: 40, elapsed: 4 * adaptiveWindow, wantProbability: 1},
+		{name: "scaled to a window", seen: 80, elapsed: 2 * adaptiveWindow, wantProbability: 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newAdaptiveSampler(40) // a budget of 10 per window
+			s.ops["op"] = &operationRate{
+				windowStart: time.Now().Add(-tt.elapsed),
+				seen:        tt.seen,
+				probability: 1,
+			}
+			s.sample("op", traceIDAt(0))
+			op := s.ops["op"]
+			if math.Abs(op.probability-tt.wantProbability) > 1e-6 {
+				t.Errorf("probability = %g, want %g", op.probability, tt.wantProbability)
+			}
+			if op.seen != 1 || op.sampled != 1 {
+				t.Errorf("seen, sampled = %d, %d after a new window, want 1, 1", op.seen, op.sampled)
+			}
+			if s.sample("op", traceIDAt(tt.wantProbability*1.01)) && tt.wantProbability < 1 {
+				t.Error("sampled a trace ID above the probability")
+			}
+		})
+	}
+}
+
+func TestAdaptiveSamplerOperationLimit(t *testing.T) {
+	s := newAdaptiveSampler(4)
+	for i := range maxAdaptiveOperations {
+		s.ops[strconv.Itoa(i)] = &operationRate{windowStart: time.Now(), probability: 1}
+	}
+	s.sample("one more", traceIDAt(0))
+	s.sample("and another", traceIDAt(0))
+	if _, ok := s.ops["one more"]; ok {
+		t.Error("tracked an operation beyond the limit")
+	}
+	if op := s.ops[""]; op == nil || op.seen != 2 {
+		t.Errorf("shared operation = %+v, want 2 root spans seen", op)
+	}
+}
+
+func TestAdaptiveSamplerFollowsParent(t *testing.T) {
+	s := newAdaptiveSampler(4)
+	for _, sampled := range []bool{false, true} {
+		var flags trace.TraceFlags
+		if sampled {
+			flags = trace.FlagsSampled
+		}
+		parent := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceIDAt(0.99),
+			SpanID:     trace.SpanID{1},
+			TraceFlags: flags,
+		}))
+		got := s.ShouldSample(sdktrace.SamplingParameters{ParentContext: parent, TraceID: traceIDAt(0.99), Name: "child"})
+		if got.Decision == sdktrace.RecordAndSample != sampled {
+			t.Errorf("parent sampled %v: decision %v", sampled, got.Decision)
+		}
+	}
+	if len(s.ops) != 0 {
+		t.Error("child spans counted toward an operation")
+	}
+}
@@ -0,0 +1,30 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// keepSampler samples the spans started from a context marked by
+// Observability.ForceSample, and defers to its Sampler for the others.
+type keepSampler struct {
+	sdktrace.Sampler
+}
+
+func (s keepSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	if !forceSampled(p.ParentContext) {
+		return s.Sampler.ShouldSample(p)
+	}
+	return sdktrace.SamplingResult{
+		Decision:   sdktrace.RecordAndSample,
+		Attributes: []attribute.KeyValue{attribute.Int(SamplingPriorityKey, 1)},
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+func (s keepSampler) Description() string {
+	return "KeepSampler{" + s.Sampler.Description() + "}"
+}
@@ -0,0 +1,172 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tailSamplingProcessor holds the ended spans of each trace until its local
+// root span ends, and passes them on only if the trace is worth keeping: it
+// contains an error or a kept span (see Span.Keep), its root took at least
+// the configured latency, or it falls in the baseline ratio. Spans that end
+// after their trace was decided follow the decision.
+type tailSamplingProcessor struct {
+	next sdktrace.SpanProcessor
+	cfg  TailSampling
+
+	mu     sync.Mutex
+	traces map[trace.TraceID]*tailTrace
+	// order lists the traces by the time their first span ended, for
+	// eviction.
+	order []trace.TraceID
+}
+
+// tailTrace is a trace held by a tailSamplingProcessor.
+type tailTrace struct {
+	first     time.Time
+	spans     []sdktrace.ReadOnlySpan
+	important bool
+	decided   bool
+	keep      bool
+}
+
+func newTailSamplingProcessor(next sdktrace.SpanProcessor, cfg TailSampling) *tailSamplingProcessor {
+	return &tailSamplingProcessor{
+		next:   next,
+		cfg:    cfg.withDefaults(),
+		traces: make(map[trace.TraceID]*tailTrace),
+	}
+}
+
+func (p *tailSamplingProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *tailSamplingProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	now := time.Now()
+	id := s.SpanContext().TraceID()
+	p.mu.Lock()
+	_, known := p.traces[id]
+	ready := p.evict(now, !known)
+
+	t, ok := p.traces[id]
+	if !ok {
+		t = &tailTrace{first: now}
+		p.traces[id] = t
+		p.order = append(p.order, id)
+	}
+	switch {
+	case t.decided && t.keep:
+		ready = append(ready, s)
+	case t.decided:
+	default:
+		t.spans = append(t.spans, s)
+		t.important = t.important || important(s)
+		if parent := s.Parent(); !parent.IsValid() || parent.IsRemote() {
+			ready = append(ready, p.decide(t, s)...)
+		}
+	}
+	p.mu.Unlock()
+
+	p.forward(ready)
+}
+
+// important reports whether s alone makes its trace worth keeping.
+func important(s sdktrace.ReadOnlySpan) bool {
+	if s.Status().Code == codes.Error {
+		return true
+	}
+	for _, kv := range s.Attributes() {
+		if kv.Key == SamplingPriorityKey {
+			return kv.Value.AsInt64() > 0
+		}
+	}
+	return false
+}
+
+// decide decides whether to keep t, given its root span if it has ended, and
+// returns the spans to pass on.
+func (p *tailSamplingProcessor) decide(t *tailTrace, root sdktrace.ReadOnlySpan) []sdktrace.ReadOnlySpan {
+	t.decided = true
+	t.keep = t.important ||
+		(root != nil && p.cfg.Latency > 0 && root.EndTime().Sub(root.StartTime()) >= p.cfg.Latency) ||
+		p.baseline(t.spans[0].SpanContext().TraceID())
+	spans := t.spans
+	t.spans = nil
+	if !t.keep {
+		return nil
+	}
+	return spans
+}
+
+// baseline reports whether traceID falls in the baseline ratio, comparing
+// its lower 63 bits as TraceIDRatioBased does, so the choice is consistent
+// across services.
+func (p *tailSamplingProcessor) baseline(traceID trace.TraceID) bool {
+	if p.cfg.BaselineRatio >= 1 {
+		return true
+	}
+	x := binary.BigEndian.Uint64(traceID[8:16]) >> 1
+	return float64(x) < p.cfg.BaselineRatio*(1<<63)
+}
+
+// evict forgets the traces first seen a window ago, and the oldest beyond
+// the maximum, leaving room for one more if adding, deciding those whose
+// root has not ended. It returns the spans to pass on.
+func (p *tailSamplingProcessor) evict(now time.Time, adding bool) []sdktrace.ReadOnlySpan {
+	limit := p.cfg.MaxTraces
+	if adding {
+		limit--
+	}
+	var ready []sdktrace.ReadOnlySpan
+	for len(p.order) > 0 {
+		id := p.order[0]
+		t := p.traces[id]
+		if now.Sub(t.first) < p.cfg.Window && len(p.traces) <= limit {
+			break
+		}
+		if !t.decided {
+			ready = append(ready, p.decide(t, nil)...)
+		}
+		delete(p.traces, id)
+		p.order = p.order[1:]
+	}
+	return ready
+}
+
+func (p *tailSamplingProcessor) forward(spans []sdktrace.ReadOnlySpan) {
+	for _, s := range spans {
+		p.next.OnEnd(s)
+	}
+}
+
+// flushPending decides every trace still waiting for its root span.
+func (p *tailSamplingProcessor) flushPending() {
+	var ready []sdktrace.ReadOnlySpan
+	p.mu.Lock()
+	for _, t := range p.traces {
+		if !t.decided {
+			ready = append(ready, p.decide(t, nil)...)
+		}
+	}
+	p.mu.Unlock()
+	p.forward(ready)
+}
+
+func (p *tailSamplingProcessor) ForceFlush(ctx context.Context) error {
+	p.flushPending()
+	return p.next.ForceFlush(ctx)
+}
+
+func (p *tailSamplingProcessor) Shutdown(ctx context.Context) error {
+	p.flushPending()
+	return p.next.Shutdown(ctx)
+}
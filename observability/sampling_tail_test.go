@@ -0,0 +1,161 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTailSamplingTracer returns a tracer whose ended spans go through a tail
+// sampling processor with cfg to the returned recorder.
+func newTailSamplingTracer(t *testing.T, cfg TailSampling) (trace.Tracer, *tailSamplingProcessor, *tracetest.SpanRecorder) {
+	recorder := tracetest.NewSpanRecorder()
+	p := newTailSamplingProcessor(recorder, cfg)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(p))
+	t.Cleanup(func() { tp.Shutdown(context.Background()) })
+	return tp.Tracer("test"), p, recorder
+}
+
+func TestTailSamplingDecision(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   TailSampling
+		child func(trace.Span)
+		took  time.Duration
+		keep  bool
+	}{
+		{name: "normal trace dropped", took: time.Millisecond},
+		{
+			name:  "error kept",
+			child: func(s trace.Span) { s.SetStatus(codes.Error, "failed") },
+			keep:  true,
+		},
+		{
+			name:  "kept span",
+			child: func(s trace.Span) { s.SetAttributes(attribute.Int(SamplingPriorityKey, 1)) },
+			keep:  true,
+		},
+		{
+			name:  "zero sampling priority dropped",
+			child: func(s trace.Span) { s.SetAttributes(attribute.Int(SamplingPriorityKey, 0)) },
+		},
+		{name: "slow root kept", cfg: TailSampling{Latency: time.Second}, took: 2 * time.Second, keep: true},
+		{name: "fast root dropped", cfg: TailSampling{Latency: time.Second}, took: 500 * time.Millisecond},
+		{name: "baseline kept", cfg: TailSampling{BaselineRatio: 1}, keep: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracer, _, recorder := newTailSamplingTracer(t, tt.cfg)
+			start := time.Now()
+			ctx, root := tracer.Start(context.Background(), "root", trace.WithTimestamp(start))
+			_, child := tracer.Start(ctx, "child")
+			if tt.child != nil {
+				tt.child(child)
+			}
+			child.End()
+			if len(recorder.Ended()) != 0 {
+				t.Fatal("child passed on before its root ended")
+			}
+			root.End(trace.WithTimestamp(start.Add(tt.took)))
+
+			want := 0
+			if tt.keep {
+				want = 2
+			}
+			if got := len(recorder.Ended()); got != want {
+				t.Errorf("passed on %d spans, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestTailSamplingLateSpans(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		ratio := 0.0
+		if keep {
+			ratio = 1
+		}
+		tracer, _, recorder := newTailSamplingTracer(t, TailSampling{BaselineRatio: ratio})
+		ctx, root := tracer.Start(context.Background(), "root")
+		_, late := tracer.Start(ctx, "late")
+		root.End()
+		late.End()
+
+		want := 0
+		if keep {
+			want = 2
+		}
+		if got := len(recorder.Ended()); got != want {
+			t.Errorf("keep %v: passed on %d spans, want %d", keep, got, want)
+		}
+	}
+}
+
+func TestTailSamplingEviction(t *testing.T) {
+	tracer, _, recorder := newTailSamplingTracer(t, TailSampling{MaxTraces: 1})
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, failed := tracer.Start(ctx, "failed")
+	failed.SetStatus(codes.Error, "failed")
+	failed.End()
+
+	// A second trace exceeds MaxTraces, deciding the first without its root.
+	ctx2, root2 := tracer.Start(context.Background(), "root2")
+	_, other := tracer.Start(ctx2, "other")
+	other.End()
+	if got := len(recorder.Ended()); got != 1 {
+		t.Fatalf("passed on %d spans after eviction, want 1", got)
+	}
+	// The evicted trace is forgotten: its root is decided on its own.
+	root.End()
+	root2.End()
+	if got := len(recorder.Ended()); got != 1 {
+		t.Errorf("passed on %d spans, want 1", got)
+	}
+}
+
+func TestTailSamplingFlushDecidesPending(t *testing.T) {
+	tracer, p, recorder := newTailSamplingTracer(t, TailSampling{})
+	ctx, root := tracer.Start(context.Background(), "root")
+	defer root.End()
+	_, failed := tracer.Start(ctx, "failed")
+	failed.SetStatus(codes.Error, "failed")
+	failed.End()
+
+	if err := p.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(recorder.Ended()); got != 1 {
+		t.Errorf("passed on %d spans, want 1", got)
+	}
+}
+
+func TestTailSamplingBaseline(t *testing.T) {
+	low := trace.TraceID{8: 0x00, 15: 0x01}
+	high := trace.TraceID{8: 0xff, 9: 0xff, 10: 0xff, 11: 0xff, 12: 0xff, 13: 0xff, 14: 0xff, 15: 0xff}
+	mid := trace.TraceID{8: 0x80}
+	tests := []struct {
+		ratio   float64
+		traceID trace.TraceID
+		want    bool
+	}{
+		{0, low, false},
+		{1, high, true},
+		{0.5, low, true},
+		{0.5, mid, false},
+		{0.75, mid, true},
+	}
+	for _, tt := range tests {
+		p := newTailSamplingProcessor(tracetest.NewSpanRecorder(), TailSampling{BaselineRatio: tt.ratio})
+		if got := p.baseline(tt.traceID); got != tt.want {
+			t.Errorf("baseline(%v) with ratio %g = %v, want %v", tt.traceID, tt.ratio, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// Signals whose exports pipelineStats counts.
+const (
+	signalTraces  = "traces"
+	signalMetrics = "metrics"
+	signalLogs    = "logs"
+)
+
+// pipelineStats counts what the factory's own telemetry pipeline handles,
+// so that logs and spans lost on the way out, and failing exports, are
+// visible as metrics and through expvar instead of vanishing silently.
+// The counters are updated on every log record and span and are read when
+// metrics are collected.
+type pipelineStats struct {
+	logErrors             atomic.Uint64
+	logsDropped           atomic.Uint64
+	spansStarted          atomic.Uint64
+	spansEnded            atomic.Uint64
+	traceExportFailures   atomic.Uint64
+	metricsExportFailures atomic.Uint64
+	logExportFailures     atomic.Uint64
+
+	// exportDuration records the latency of each export once metrics are
+	// set up. Exports before then are only counted.
+	exportDuration atomic.Pointer[metric.Float64Histogram]
+
+	// onExportError, if set, reports failed exports.
+	onExportError func(signal string, err error)
+}
+
+// recordExport records the outcome and latency of an export of signal that
+// started at start, and reports it if it failed. It returns err marked as
+// reported, so that the OpenTelemetry error handler skips it.
+func (s *pipelineStats) recordExport(ctx context.Context, signal string, start time.Time, err error) error {
+	if h := s.exportDuration.Load(); h != nil {
+		(*h).Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(
+			attribute.String("signal", signal),
+			attribute.Bool("error", err != nil),
+		))
+	}
+	if err == nil {
+		return nil
+	}
+	switch signal {
+	case signalTraces:
+		s.traceExportFailures.Add(1)
+	case signalMetrics:
+		s.metricsExportFailures.Add(1)
+	case signalLogs:
+		s.logExportFailures.Add(1)
+	}
+	if s.onExportError != nil {
+		s.onExportError(signal, err)
+	}
+	return &exportError{err: err}
+}
+
+// exportError is a failed export that was already logged and passed to the
+// export error handler.
+type exportError struct {
+	err error
+}
+
+func (e *exportError) Error() string { return e.err.Error() }
+func (e *exportError) Unwrap() error { return e.err }
+
+// reportExportError logs a failed export and passes it to the handler set
+// with WithExportErrorHandler.
+func (f *Factory) reportExportError(signal string, err error) {
+	f.providers.logger.Error("Telemetry export failed", "signal", signal, "error", err)
+	if handler := f.config.ExportError.Value; handler != nil {
+		handler(err)
+	}
+}
+
+// handleOTelError is installed as the OpenTelemetry error handler. It logs
+// the errors the SDK and instrumentation libraries report, which the SDK
+// otherwise writes to the standard logger, skipping failed exports that
+// reportExportError already logged.
+func (f *Factory) handleOTelError(err error) {
+	var reported *exportError
+	if errors.As(err, &reported) {
+		return
+	}
+	f.providers.logger.Error("OpenTelemetry error", "error", err)
+}
+
+// pipelineState is the pipeline's self-telemetry as published through
+// expvar.
+type pipelineState struct {
+	LogErrors             uint64 `json:"log_errors"`
+	LogsDropped           uint64 `json:"logs_dropped"`
+	SpansStarted          uint64 `json:"spans_started"`
+	SpansEnded            uint64 `json:"spans_ended"`
+	TraceExportFailures   uint64 `json:"trace_export_failures"`
+	MetricsExportFailures uint64 `json:"metrics_export_failures"`
+	LogExportFailures     uint64 `json:"log_export_failures"`
+}
+
+func (s *pipelineStats) state() pipelineState {
+	return pipelineState{
+		LogErrors:             s.logErrors.Load(),
+		LogsDropped:           s.logsDropped.Load(),
+		SpansStarted:          s.spansStarted.Load(),
+		SpansEnded:            s.spansEnded.Load(),
+		TraceExportFailures:   s.traceExportFailures.Load(),
+		MetricsExportFailures: s.metricsExportFailures.Load(),
+		LogExportFailures:     s.logExportFailures.Load(),
+	}
+}
+
+// registerPipelineMetrics reports the factory's pipelineStats, and the
+// records dropped by the asynchronous log queue, through meter.
+func (f *Factory) registerPipelineMetrics(meter metric.Meter) error {
+	dropped, err := meter.Int64ObservableCounter("observability.logs.dropped",
+		metric.WithDescription("Log records dropped because a log queue was full or their export failed"),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		return err
+	}
+	logErrors, err := meter.Int64ObservableCounter("observability.logs.errors",
+		metric.WithDescription("Log records the log handler failed to write"),
+		metric.WithUnit("{record}"))
+	if err != nil {
+		return err
+	}
+	spansStarted, err := meter.Int64ObservableCounter("observability.spans.started",
+		metric.WithDescription("Spans started by the tracer"),
+		metric.WithUnit("{span}"))
+	if err != nil {
+		return err
+	}
+	spansEnded, err := meter.Int64ObservableCounter("observability.spans.ended",
+		metric.WithDescription("Spans ended and handed to the exporter"),
+		metric.WithUnit("{span}"))
+	if err != nil {
+		return err
+	}
+	exportFailures, err := meter.Int64ObservableCounter("observability.export.failures",
+		metric.WithDescription("Exports that failed, whose telemetry was lost"),
+		metric.WithUnit("{export}"))
+	if err != nil {
+		return err
+	}
+	exportDuration, err := meter.Float64Histogram("observability.export.duration",
+		metric.WithDescription("Duration of exports to the backend"),
+		metric.WithUnit("s"),
+		metric.WithExplicitBucketBoundaries(durationBuckets...))
+	if err != nil {
+		return err
+	}
+
+	traces := metric.WithAttributes(attribute.String("signal", signalTraces))
+	metrics := metric.WithAttributes(attribute.String("signal", signalMetrics))
+	logs := metric.WithAttributes(attribute.String("signal", signalLogs))
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		n := f.stats.logsDropped.Load()
+		if f.asyncLogs != nil {
+			_, _, asyncDropped := f.asyncLogs.stats()
+			n += asyncDropped
+		}
+		o.ObserveInt64(dropped, int64(n))
+		o.ObserveInt64(logErrors, int64(f.stats.logErrors.Load()))
+		o.ObserveInt64(spansStarted, int64(f.stats.spansStarted.Load()))
+		o.ObserveInt64(spansEnded, int64(f.stats.spansEnded.Load()))
+		o.ObserveInt64(exportFailures, int64(f.stats.traceExportFailures.Load()), traces)
+		o.ObserveInt64(exportFailures, int64(f.stats.metricsExportFailures.Load()), metrics)
+		o.ObserveInt64(exportFailures, int64(f.stats.logExportFailures.Load()), logs)
+		return nil
+	}, dropped, logErrors, spansStarted, spansEnded, exportFailures)
+	if err != nil {
+		return err
+	}
+	f.stats.exportDuration.Store(&exportDuration)
+	return nil
+}
@@ -0,0 +1,40 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// statsProcessor is a SpanProcessor that counts the spans started and ended
+// in a TracerProvider.
+type statsProcessor struct {
+	stats *pipelineStats
+}
+
+func (p statsProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.stats.spansStarted.Add(1)
+}
+
+func (p statsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.stats.spansEnded.Add(1)
+}
+
+func (statsProcessor) Shutdown(context.Context) error   { return nil }
+func (statsProcessor) ForceFlush(context.Context) error { return nil }
+
+// statsSpanExporter records the outcome and latency of each export of the
+// SpanExporter it wraps, and reports failed exports.
+type statsSpanExporter struct {
+	sdktrace.SpanExporter
+	stats *pipelineStats
+}
+
+func (e statsSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	start := time.Now()
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	return e.stats.recordExport(ctx, signalTraces, start, err)
+}
@@ -0,0 +1,48 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// selfTestTimeout bounds the flush of the self-test run by Setup.
+const selfTestTimeout = 5 * time.Second
+
+// SelfTest checks that telemetry flows end to end: it emits a marker span
+// named "obs.selftest" with a log line inside it, flushes the pipeline, and
+// returns an error if the flush or an export failed. Deployment pipelines
+// can run it after Setup, or enable it there with WithSelfTest, and find the
+// marker span by the trace.id of the "Observability self-test passed" log.
+//
+// The Datadog tracer does not report whether its flush reached the Agent,
+// so with Datadog, SelfTest only confirms that the span was emitted.
+func (f *Factory) SelfTest(ctx context.Context) error {
+	failures := f.stats.traceExportFailures.Load() + f.stats.metricsExportFailures.Load()
+
+	spanCtx, obs, span := f.newObservability(ctx).StartSpan("obs.selftest", nil)
+	obs.Log.Info("Observability self-test")
+	span.End()
+	traceID, _ := f.providers.spans.TraceIDs(spanCtx)
+
+	err := f.shutdowner.ForceFlush(ctx)
+	if err == nil && f.stats.traceExportFailures.Load()+f.stats.metricsExportFailures.Load() > failures {
+		err = errors.New("export failed")
+	}
+	if err != nil {
+		f.providers.logger.Error("Observability self-test failed", "trace.id", traceID, "error", err)
+		return fmt.Errorf("observability self-test failed: %w", err)
+	}
+	f.providers.logger.Info("Observability self-test passed", "trace.id", traceID)
+	return nil
+}
+
+// runSelfTest runs SelfTest at the end of Setup and records its outcome as
+// the status of the selftest component, which /debug/health reports.
+func (f *Factory) runSelfTest(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+	err := f.SelfTest(ctx)
+	f.setStatus("selftest", string(normalizeAPMType(f.config.ApmType.Value)), statusPassed, err)
+}
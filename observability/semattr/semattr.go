@@ -0,0 +1,192 @@
+// Package semattr provides typed constructors for the OpenTelemetry semantic
+// convention attributes that services set most often, so that attribute keys
+// are not typed by hand and do not drift from the conventions:
+//
+//	ctx, obs, span := obs.StartSpanWith("SELECT orders",
+//		semattr.DBSystemPostgres,
+//		semattr.DBNamespace("shop"),
+//		semattr.DBCollectionName("orders"),
+//		semattr.DBOperationName("SELECT"),
+//	)
+//
+// The keys follow version 1.34.0 of the semantic conventions.
+package semattr
+
+import (
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+)
+
+// SchemaURL is the schema of the semantic conventions the attributes follow.
+const SchemaURL = semconv.SchemaURL
+
+// HTTP attributes.
+
+// HTTPRequestMethod is the http.request.method of a request, such as "GET".
+func HTTPRequestMethod(method string) attribute.KeyValue {
+	return semconv.HTTPRequestMethodKey.String(method)
+}
+
+// HTTPRoute is the http.route a server matched a request with, such as
+// "/orders/{id}".
+func HTTPRoute(route string) attribute.KeyValue {
+	return semconv.HTTPRoute(route)
+}
+
+// HTTPResponseStatusCode is the http.response.status_code of a response.
+func HTTPResponseStatusCode(code int) attribute.KeyValue {
+	return semconv.HTTPResponseStatusCode(code)
+}
+
+// URLFull is the url.full of a client request. Remove credentials from it.
+func URLFull(url string) attribute.KeyValue {
+	return semconv.URLFull(url)
+}
+
+// URLPath is the url.path of a request.
+func URLPath(path string) attribute.KeyValue {
+	return semconv.URLPath(path)
+}
+
+// URLScheme is the url.scheme of a request, such as "https".
+func URLScheme(scheme string) attribute.KeyValue {
+	return semconv.URLScheme(scheme)
+}
+
+// ServerAddress is the server.address a request was sent to.
+func ServerAddress(address string) attribute.KeyValue {
+	return semconv.ServerAddress(address)
+}
+
+// ServerPort is the server.port a request was sent to.
+func ServerPort(port int) attribute.KeyValue {
+	return semconv.ServerPort(port)
+}
+
+// ClientAddress is the client.address a request came from.
+func ClientAddress(address string) attribute.KeyValue {
+	return semconv.ClientAddress(address)
+}
+
+// UserAgentOriginal is the user_agent.original header of a request.
+func UserAgentOriginal(userAgent string) attribute.KeyValue {
+	return semconv.UserAgentOriginal(userAgent)
+}
+
+// Database attributes. Use one of the DBSystem values with the others.
+var (
+	DBSystemPostgres = semconv.DBSystemNamePostgreSQL
+	DBSystemMySQL    = semconv.DBSystemNameMySQL
+	DBSystemRedis    = semconv.DBSystemNameRedis
+	DBSystemMongoDB  = semconv.DBSystemNameMongoDB
+)
+
+// DBNamespace is the db.namespace, such as the database name, an operation
+// runs in.
+func DBNamespace(namespace string) attribute.KeyValue {
+	return semconv.DBNamespace(namespace)
+}
+
+// DBCollectionName is the db.collection.name, such as the table, an
+// operation acts on.
+func DBCollectionName(name string) attribute.KeyValue {
+	return semconv.DBCollectionName(name)
+}
+
+// DBOperationName is the db.operation.name, such as "SELECT" or "GET".
+func DBOperationName(name string) attribute.KeyValue {
+	return semconv.DBOperationName(name)
+}
+
+// DBQueryText is the db.query.text of an operation. Use placeholders rather
+// than literal values, which may be sensitive.
+func DBQueryText(query string) attribute.KeyValue {
+	return semconv.DBQueryText(query)
+}
+
+// Messaging attributes. Use one of the MessagingSystem values and one of
+// the MessagingOperation values with the others.
+var (
+	MessagingSystemKafka    = semconv.MessagingSystemKafka
+	MessagingSystemRabbitMQ = semconv.MessagingSystemRabbitMQ
+
+	MessagingOperationSend    = semconv.MessagingOperationTypeSend
+	MessagingOperationReceive = semconv.MessagingOperationTypeReceive
+	MessagingOperationProcess = semconv.MessagingOperationTypeProcess
+)
+
+// MessagingDestinationName is the messaging.destination.name, such as the
+// queue, a message is sent to or received from.
+func MessagingDestinationName(name string) attribute.KeyValue {
+	return semconv.MessagingDestinationName(name)
+}
+
+// MessagingKafkaTopic is the messaging.destination.name of a Kafka message:
+// its topic.
+func MessagingKafkaTopic(topic string) attribute.KeyValue {
+	return semconv.MessagingDestinationName(topic)
+}
+
+// MessagingKafkaPartition is the messaging.destination.partition.id of a
+// Kafka message.
+func MessagingKafkaPartition(partition int) attribute.KeyValue {
+	return semconv.MessagingDestinationPartitionID(strconv.Itoa(partition))
+}
+
+// MessagingKafkaOffset is the messaging.kafka.offset of a Kafka message.
+func MessagingKafkaOffset(offset int) attribute.KeyValue {
+	return semconv.MessagingKafkaOffset(offset)
+}
+
+// MessagingKafkaMessageKey is the messaging.kafka.message.key of a Kafka
+// message.
+func MessagingKafkaMessageKey(key string) attribute.KeyValue {
+	return semconv.MessagingKafkaMessageKey(key)
+}
+
+// MessagingConsumerGroupName is the messaging.consumer.group.name of the
+// consumer that receives a message.
+func MessagingConsumerGroupName(name string) attribute.KeyValue {
+	return semconv.MessagingConsumerGroupName(name)
+}
+
+// RPC attributes.
+
+// RPCSystemGRPC is the rpc.system of gRPC calls.
+var RPCSystemGRPC = semconv.RPCSystemGRPC
+
+// RPCService is the rpc.service of a call, such as "shop.OrderService".
+func RPCService(service string) attribute.KeyValue {
+	return semconv.RPCService(service)
+}
+
+// RPCMethod is the rpc.method of a call, such as "GetOrder".
+func RPCMethod(method string) attribute.KeyValue {
+	return semconv.RPCMethod(method)
+}
+
+// RPCGRPCStatusCode is the rpc.grpc.status_code of a gRPC call.
+func RPCGRPCStatusCode(code int) attribute.KeyValue {
+	return semconv.RPCGRPCStatusCodeKey.Int(code)
+}
+
+// General attributes.
+
+// PeerService is the peer.service, the logical name of the remote service
+// a call goes to, such as "inventory".
+func PeerService(service string) attribute.KeyValue {
+	return semconv.PeerService(service)
+}
+
+// ErrorType is the error.type of a failed operation, such as the status
+// code or the class of the error.
+func ErrorType(errorType string) attribute.KeyValue {
+	return semconv.ErrorTypeKey.String(errorType)
+}
+
+// UserID is the user.id of the user an operation acts for.
+func UserID(id string) attribute.KeyValue {
+	return semconv.UserID(id)
+}
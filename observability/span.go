@@ -2,13 +2,34 @@ package observability
 
 import (
 	"context"
+	"fmt"
 
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // SpanAttributes provides a simpler, map-based way to define span attributes, similar to logrus.Fields.
+// Nested SpanAttributes are flattened into keys joined with dots, so
+// SpanAttributes{"http": SpanAttributes{"method": "GET"}} sets http.method.
 type SpanAttributes map[string]interface{}
 
+// appendAttributes appends a to attrs as span attributes, with its keys
+// qualified by prefix and nested SpanAttributes, or plain
+// map[string]interface{} values, flattened.
+func (a SpanAttributes) appendAttributes(attrs []attribute.KeyValue, prefix string) []attribute.KeyValue {
+	for k, v := range a {
+		switch nested := v.(type) {
+		case SpanAttributes:
+			attrs = nested.appendAttributes(attrs, prefix+k+".")
+		case map[string]interface{}:
+			attrs = SpanAttributes(nested).appendAttributes(attrs, prefix+k+".")
+		default:
+			attrs = append(attrs, ToAttribute(prefix+k, v))
+		}
+	}
+	return attrs
+}
+
 // StartSpanFromCtx is a convenience function that gets the observability
 // container from the context and starts a new span.
 // It returns the new context, a new observability container associated with that
@@ -33,11 +54,7 @@ func (o *Observability) StartSpan(name string, attrs SpanAttributes) (context.Co
 	ctx, span := o.Trace.Start(o.ctx, name)
 
 	if len(attrs) > 0 {
-		otelAttrs := make([]attribute.KeyValue, 0, len(attrs))
-		for k, v := range attrs {
-			otelAttrs = append(otelAttrs, ToAttribute(k, v))
-		}
-		span.SetAttributes(otelAttrs...)
+		span.SetAttributes(attrs.appendAttributes(make([]attribute.KeyValue, 0, len(attrs)), "")...)
 	}
 
 	// Return a clone of the observability object with the new context.
@@ -53,4 +70,36 @@ func (o *Observability) StartSpanWith(name string, attrs ...attribute.KeyValue)
 	}
 	// Return a clone of the observability object with the new context.
 	return ctx, o.clone(ctx), span
-}
\ No newline at end of file
+}
+
+// startServerSpan is StartSpanWith for the root span of a request the
+// service handles, which is started with the server span kind.
+func (o *Observability) startServerSpan(name string, attrs ...attribute.KeyValue) (context.Context, *Observability, Span) {
+	ctx, span := o.Trace.start(o.ctx, name, trace.SpanKindServer)
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+	return ctx, o.clone(ctx), span
+}
+
+// RunInSpan runs fn in a new child span named name and ends the span when fn
+// returns. fn receives the span's context and Observability. If fn returns an
+// error, it is recorded on the span, the span's status is set to Error, and
+// the error is returned. A panic in fn is recorded the same way and then
+// propagated.
+func (o *Observability) RunInSpan(name string, attrs SpanAttributes, fn func(ctx context.Context, obs *Observability) error) (err error) {
+	ctx, obs, span := o.StartSpan(name, attrs)
+	defer span.EndWith(&err)
+	defer func() {
+		if v := recover(); v != nil {
+			var ok bool
+			if err, ok = v.(error); !ok {
+				err = fmt.Errorf("panic: %v", v)
+			}
+			// EndWith, deferred above, records err as the panic unwinds.
+			panic(v)
+		}
+	}()
+
+	return fn(ctx, obs)
+}
@@ -0,0 +1,181 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxTrackedParents bounds the bookkeeping of spans known to have children,
+// in case children keep ending after their parents.
+const maxTrackedParents = 10000
+
+// compressionProcessor merges runs of identical, short, consecutive sibling
+// spans into a single composite span before passing them on, following the
+// "exact match" strategy of Elastic APM's span compression. A span is
+// compressible when it is a leaf, did not fail, has a local parent, and took
+// no longer than maxDuration. The composite keeps the first span's identity,
+// ends when the last one ended, and carries span.composite.count and
+// span.composite.sum_ms attributes.
+type compressionProcessor struct {
+	next        sdktrace.SpanProcessor
+	maxDuration time.Duration
+
+	mu      sync.Mutex
+	pending map[trace.SpanID]*compressionBuffer // keyed by parent span ID
+	parents map[trace.SpanID]struct{}           // spans that have ended children
+}
+
+// compressionBuffer accumulates a run of identical siblings.
+type compressionBuffer struct {
+	first sdktrace.ReadOnlySpan
+	end   time.Time
+	count int
+	sum   time.Duration
+}
+
+func newCompressionProcessor(next sdktrace.SpanProcessor, maxDuration time.Duration) *compressionProcessor {
+	return &compressionProcessor{
+		next:        next,
+		maxDuration: maxDuration,
+		pending:     make(map[trace.SpanID]*compressionBuffer),
+		parents:     make(map[trace.SpanID]struct{}),
+	}
+}
+
+func (p *compressionProcessor) OnStart(parent context.Context, s sdktrace.ReadWriteSpan) {
+	p.next.OnStart(parent, s)
+}
+
+func (p *compressionProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	var ready []sdktrace.ReadOnlySpan
+
+	p.mu.Lock()
+	id := s.SpanContext().SpanID()
+	_, hasChildren := p.parents[id]
+	delete(p.parents, id)
+
+	// Children end before their parent, so whatever is still buffered for
+	// this span must be emitted ahead of it.
+	if buf, ok := p.pending[id]; ok {
+		ready = append(ready, buf.span())
+		delete(p.pending, id)
+	}
+
+	parent := s.Parent()
+	if parent.IsValid() && !parent.IsRemote() {
+		parentID := parent.SpanID()
+		if len(p.parents) < maxTrackedParents {
+			p.parents[parentID] = struct{}{}
+		}
+
+		buf := p.pending[parentID]
+		switch {
+		case !hasChildren && p.compressible(s) && buf != nil && buf.matches(s):
+			buf.add(s)
+			p.mu.Unlock()
+			return
+		case !hasChildren && p.compressible(s):
+			if buf != nil {
+				ready = append(ready, buf.span())
+			}
+			p.pending[parentID] = newCompressionBuffer(s)
+			p.mu.Unlock()
+			p.forward(ready)
+			return
+		case buf != nil:
+			// A different sibling ends the run.
+			ready = append(ready, buf.span())
+			delete(p.pending, parentID)
+		}
+	}
+	p.mu.Unlock()
+
+	p.forward(append(ready, s))
+}
+
+func (p *compressionProcessor) compressible(s sdktrace.ReadOnlySpan) bool {
+	return s.Status().Code != codes.Error && s.EndTime().Sub(s.StartTime()) <= p.maxDuration
+}
+
+func (p *compressionProcessor) forward(spans []sdktrace.ReadOnlySpan) {
+	for _, s := range spans {
+		p.next.OnEnd(s)
+	}
+}
+
+// flushPending emits every buffered run.
+func (p *compressionProcessor) flushPending() {
+	p.mu.Lock()
+	ready := make([]sdktrace.ReadOnlySpan, 0, len(p.pending))
+	for id, buf := range p.pending {
+		ready = append(ready, buf.span())
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+	p.forward(ready)
+}
+
+func (p *compressionProcessor) ForceFlush(ctx context.Context) error {
+	p.flushPending()
+	return p.next.ForceFlush(ctx)
+}
+
+func (p *compressionProcessor) Shutdown(ctx context.Context) error {
+	p.flushPending()
+	return p.next.Shutdown(ctx)
+}
+
+func newCompressionBuffer(s sdktrace.ReadOnlySpan) *compressionBuffer {
+	return &compressionBuffer{
+		first: s,
+		end:   s.EndTime(),
+		count: 1,
+		sum:   s.EndTime().Sub(s.StartTime()),
+	}
+}
+
+// matches reports whether s is an exact match for the buffered run.
+func (b *compressionBuffer) matches(s sdktrace.ReadOnlySpan) bool {
+	return s.Name() == b.first.Name() && s.SpanKind() == b.first.SpanKind()
+}
+
+func (b *compressionBuffer) add(s sdktrace.ReadOnlySpan) {
+	b.count++
+	b.sum += s.EndTime().Sub(s.StartTime())
+	if s.EndTime().After(b.end) {
+		b.end = s.EndTime()
+	}
+}
+
+// span returns the buffered run as a single span: the original span when the
+// run has one member, a composite otherwise.
+func (b *compressionBuffer) span() sdktrace.ReadOnlySpan {
+	if b.count == 1 {
+		return b.first
+	}
+	attrs := append(make([]attribute.KeyValue, 0, len(b.first.Attributes())+3), b.first.Attributes()...)
+	attrs = append(attrs,
+		attribute.Int("span.composite.count", b.count),
+		attribute.Float64("span.composite.sum_ms", float64(b.sum.Microseconds())/1000),
+		attribute.String("span.composite.compression_strategy", "exact_match"),
+	)
+	return &compositeSpan{ReadOnlySpan: b.first, end: b.end, attrs: attrs}
+}
+
+// compositeSpan presents a compressed run of spans as one span.
+type compositeSpan struct {
+	sdktrace.ReadOnlySpan
+	end   time.Time
+	attrs []attribute.KeyValue
+}
+
+func (s *compositeSpan) EndTime() time.Time               { return s.end }
+func (s *compositeSpan) Attributes() []attribute.KeyValue { return s.attrs }
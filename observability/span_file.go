@@ -0,0 +1,124 @@
+package observability
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// SpanRecord is a span as written by the "file" APM type, one JSON object
+// per line. Attribute values are decoded as JSON values, so numbers read
+// back as float64 and arrays as []any.
+type SpanRecord struct {
+	TraceID       string            `json:"trace_id"`
+	SpanID        string            `json:"span_id"`
+	ParentSpanID  string            `json:"parent_span_id,omitempty"`
+	Name          string            `json:"name"`
+	Kind          string            `json:"kind"`
+	StartTime     time.Time         `json:"start_time"`
+	EndTime       time.Time         `json:"end_time"`
+	StatusCode    string            `json:"status_code,omitempty"`
+	StatusMessage string            `json:"status_message,omitempty"`
+	Attributes    map[string]any    `json:"attributes,omitempty"`
+	Events        []SpanEventRecord `json:"events,omitempty"`
+	Links         []SpanLinkRecord  `json:"links,omitempty"`
+	Resource      map[string]any    `json:"resource,omitempty"`
+	Scope         string            `json:"scope,omitempty"`
+}
+
+// SpanEventRecord is an event recorded on a span, such as a log line or an
+// error.
+type SpanEventRecord struct {
+	Name       string         `json:"name"`
+	Time       time.Time      `json:"time"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// SpanLinkRecord links a span to a span of another trace.
+type SpanLinkRecord struct {
+	TraceID    string         `json:"trace_id"`
+	SpanID     string         `json:"span_id"`
+	Attributes map[string]any `json:"attributes,omitempty"`
+}
+
+// Duration returns how long the span took.
+func (s SpanRecord) Duration() time.Duration {
+	return s.EndTime.Sub(s.StartTime)
+}
+
+// SpanReader reads the spans written by the "file" APM type, for offline
+// analysis of shipped span files:
+//
+//	r := observability.NewSpanReader(file)
+//	for {
+//		span, err := r.Read()
+//		if err == io.EOF {
+//			break
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(span.Name, span.Duration())
+//	}
+type SpanReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+// maxSpanLine bounds the length of a line SpanReader accepts.
+const maxSpanLine = 16 << 20
+
+// NewSpanReader returns a SpanReader that reads from r.
+func NewSpanReader(r io.Reader) *SpanReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxSpanLine)
+	return &SpanReader{scanner: scanner}
+}
+
+// Read returns the next span. At the end of the input, it returns io.EOF.
+// Blank lines are skipped, and a line that is not a span is an error
+// naming its line number, after which reading can continue.
+func (r *SpanReader) Read() (SpanRecord, error) {
+	for r.scanner.Scan() {
+		r.line++
+		line := r.scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var span SpanRecord
+		if err := json.Unmarshal(line, &span); err != nil {
+			return SpanRecord{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return span, nil
+	}
+	if err := r.scanner.Err(); err != nil {
+		return SpanRecord{}, err
+	}
+	return SpanRecord{}, io.EOF
+}
+
+// ReadSpanFile reads every span in the file at path.
+func ReadSpanFile(path string) ([]SpanRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var spans []SpanRecord
+	r := NewSpanReader(file)
+	for {
+		span, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return spans, nil
+		}
+		if err != nil {
+			return spans, fmt.Errorf("%s: %w", path, err)
+		}
+		spans = append(spans, span)
+	}
+}
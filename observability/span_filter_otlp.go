@@ -0,0 +1,28 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// filteringSpanExporter exports only the spans keep accepts.
+type filteringSpanExporter struct {
+	sdktrace.SpanExporter
+	keep SpanFilter
+}
+
+func (e filteringSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	kept := make([]sdktrace.ReadOnlySpan, 0, len(spans))
+	for _, s := range spans {
+		if e.keep(s.Name(), s.Attributes()) {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return e.SpanExporter.ExportSpans(ctx, kept)
+}
@@ -0,0 +1,84 @@
+package observability
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// maxSpanMetricNames bounds the span names that get series of their own;
+// spans with further names are recorded under spanMetricOtherName.
+const maxSpanMetricNames = 1000
+
+const spanMetricOtherName = "other"
+
+// spanMetrics derives request, error, and duration metrics from finished
+// spans, with the instrument and attribute names of the OpenTelemetry
+// Collector's spanmetrics connector, so dashboards built for it work
+// unchanged. Spans ending before the instruments are registered are not
+// recorded.
+type spanMetrics struct {
+	instruments atomic.Pointer[spanMetricInstruments]
+
+	mu    sync.Mutex
+	names map[string]struct{}
+}
+
+type spanMetricInstruments struct {
+	calls    metric.Int64Counter
+	duration metric.Float64Histogram
+}
+
+// register creates the instruments in meter and starts recording.
+func (m *spanMetrics) register(meter metric.Meter) error {
+	calls, err := meter.Int64Counter("traces.span.metrics.calls", metric.WithDescription("Number of finished spans, by span name, kind, and status"), metric.WithUnit("{span}"))
+	if err != nil {
+		return err
+	}
+	duration, err := meter.Float64Histogram("traces.span.metrics.duration", metric.WithDescription("Duration of finished spans, by span name, kind, and status"), metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	m.instruments.Store(&spanMetricInstruments{calls: calls, duration: duration})
+	return nil
+}
+
+// record records a finished span. kind and status are the names of its
+// span kind and status code, such as "SPAN_KIND_SERVER" and
+// "STATUS_CODE_ERROR".
+func (m *spanMetrics) record(name, kind, status string, duration time.Duration) {
+	instruments := m.instruments.Load()
+	if instruments == nil {
+		return
+	}
+	attrs := metric.WithAttributeSet(attribute.NewSet(
+		attribute.String("span.name", m.boundName(name)),
+		attribute.String("span.kind", kind),
+		attribute.String("status.code", status),
+	))
+	ctx := context.Background()
+	instruments.calls.Add(ctx, 1, attrs)
+	instruments.duration.Record(ctx, duration.Seconds(), attrs)
+}
+
+// boundName returns name, or spanMetricOtherName once maxSpanMetricNames
+// other names have been seen.
+func (m *spanMetrics) boundName(name string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.names[name]; ok {
+		return name
+	}
+	if len(m.names) >= maxSpanMetricNames {
+		return spanMetricOtherName
+	}
+	if m.names == nil {
+		m.names = make(map[string]struct{})
+	}
+	m.names[name] = struct{}{}
+	return name
+}
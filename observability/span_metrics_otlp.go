@@ -0,0 +1,61 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span kinds and status codes as the spanmetrics connector names them.
+var (
+	spanKindNames = map[trace.SpanKind]string{
+		trace.SpanKindUnspecified: "SPAN_KIND_UNSPECIFIED",
+		trace.SpanKindInternal:    "SPAN_KIND_INTERNAL",
+		trace.SpanKindServer:      "SPAN_KIND_SERVER",
+		trace.SpanKindClient:      "SPAN_KIND_CLIENT",
+		trace.SpanKindProducer:    "SPAN_KIND_PRODUCER",
+		trace.SpanKindConsumer:    "SPAN_KIND_CONSUMER",
+	}
+	statusCodeNames = map[codes.Code]string{
+		codes.Unset: "STATUS_CODE_UNSET",
+		codes.Error: "STATUS_CODE_ERROR",
+		codes.Ok:    "STATUS_CODE_OK",
+	}
+)
+
+// spanMetricsProcessor records every finished span in its spanMetrics.
+type spanMetricsProcessor struct {
+	metrics *spanMetrics
+}
+
+func (p spanMetricsProcessor) OnStart(context.Context, sdktrace.ReadWriteSpan) {}
+
+func (p spanMetricsProcessor) OnEnd(s sdktrace.ReadOnlySpan) {
+	p.metrics.record(s.Name(), spanKindNames[s.SpanKind()], statusCodeNames[s.Status().Code], s.EndTime().Sub(s.StartTime()))
+}
+
+func (spanMetricsProcessor) Shutdown(context.Context) error   { return nil }
+func (spanMetricsProcessor) ForceFlush(context.Context) error { return nil }
+
+// recordOnlySampler records the spans its Sampler drops without sampling
+// them, so that span metrics see every span while only the sampled ones are
+// exported.
+type recordOnlySampler struct {
+	sdktrace.Sampler
+}
+
+func (s recordOnlySampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.Sampler.ShouldSample(p)
+	if result.Decision == sdktrace.Drop {
+		result.Decision = sdktrace.RecordOnly
+	}
+	return result
+}
+
+func (s recordOnlySampler) Description() string {
+	return "RecordOnly{" + s.Sampler.Description() + "}"
+}
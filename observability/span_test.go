@@ -0,0 +1,100 @@
+package observability
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func TestSpanAttributesFlattening(t *testing.T) {
+	tests := []struct {
+		name   string
+		attrs  SpanAttributes
+		prefix string
+		want   map[attribute.Key]attribute.Value
+	}{
+		{
+			name:  "flat",
+			attrs: SpanAttributes{"order.id": "o-1", "items": 3},
+			want: map[attribute.Key]attribute.Value{
+				"order.id": attribute.StringValue("o-1"),
+				"items":    attribute.IntValue(3),
+			},
+		},
+		{
+			name:  "nested SpanAttributes",
+			attrs: SpanAttributes{"http": SpanAttributes{"method": "GET", "status_code": 200}},
+			want: map[attribute.Key]attribute.Value{
+				"http.method":      attribute.StringValue("GET"),
+				"http.status_code": attribute.IntValue(200),
+			},
+		},
+		{
+			name:  "nested plain map",
+			attrs: SpanAttributes{"db": map[string]interface{}{"system": "postgresql"}},
+			want: map[attribute.Key]attribute.Value{
+				"db.system": attribute.StringValue("postgresql"),
+			},
+		},
+		{
+			name: "deeply nested",
+			attrs: SpanAttributes{"a": SpanAttributes{
+				"b": map[string]interface{}{"c": SpanAttributes{"d": true}},
+				"e": 1.5,
+			}},
+			want: map[attribute.Key]attribute.Value{
+				"a.b.c.d": attribute.BoolValue(true),
+				"a.e":     attribute.Float64Value(1.5),
+			},
+		},
+		{
+			name:  "empty nested map",
+			attrs: SpanAttributes{"empty": SpanAttributes{}, "kept": "yes"},
+			want: map[attribute.Key]attribute.Value{
+				"kept": attribute.StringValue("yes"),
+			},
+		},
+		{
+			name:   "prefix",
+			attrs:  SpanAttributes{"id": int64(7), "meta": SpanAttributes{"tier": "gold"}},
+			prefix: "customer.",
+			want: map[attribute.Key]attribute.Value{
+				"customer.id":        attribute.Int64Value(7),
+				"customer.meta.tier": attribute.StringValue("gold"),
+			},
+		},
+		{
+			name:  "other values formatted",
+			attrs: SpanAttributes{"tags": []string{"a", "b"}},
+			want: map[attribute.Key]attribute.Value{
+				"tags": attribute.StringValue("[a b]"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.attrs.appendAttributes(nil, tt.prefix)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d attributes %v, want %d", len(got), got, len(tt.want))
+			}
+			for _, kv := range got {
+				want, ok := tt.want[kv.Key]
+				if !ok {
+					t.Errorf("unexpected attribute %s=%s", kv.Key, kv.Value.Emit())
+					continue
+				}
+				if kv.Value != want {
+					t.Errorf("%s = %s (%s), want %s (%s)", kv.Key, kv.Value.Emit(), kv.Value.Type(), want.Emit(), want.Type())
+				}
+			}
+		})
+	}
+}
+
+func TestSpanAttributesAppends(t *testing.T) {
+	attrs := []attribute.KeyValue{attribute.String("existing", "x")}
+	attrs = SpanAttributes{"added": "y"}.appendAttributes(attrs, "")
+	if len(attrs) != 2 || attrs[0].Key != "existing" || attrs[1].Key != "added" {
+		t.Errorf("attributes = %v, want existing then added", attrs)
+	}
+}
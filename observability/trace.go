@@ -1,4 +1,3 @@
-
 package observability
 
 import (
@@ -11,63 +10,103 @@ import (
 )
 
 // Span is a unified interface for a trace span.
-// The underlying implementation is determined by build tags.
+// The underlying implementation is supplied by the active APM provider.
 type Span interface {
 	End()
+	// EndWith ends the span after recording *err, if err and *err are not
+	// nil, and setting the span's status to Error. It is meant to be deferred
+	// with a pointer to a named error result:
+	//
+	//	func f(ctx context.Context) (err error) {
+	//		ctx, _, span := observability.StartSpanFromCtx(ctx, "f", nil)
+	//		defer span.EndWith(&err)
+	//		// ...
+	//	}
+	EndWith(err *error)
 	AddEvent(string, ...trace.EventOption)
 	RecordError(error, ...trace.EventOption)
 	SetStatus(codes.Code, string)
 	SetAttributes(...attribute.KeyValue)
+	IsRecording() bool
+	// Keep marks the span's trace for retention regardless of the sample
+	// rate: Datadog spans are kept with manual.keep, and sampled
+	// OpenTelemetry spans get a sampling.priority of 1, which tail samplers
+	// can keep traces by. See Observability.ForceSample.
+	Keep()
 }
 
-// Trace holds the active tracer and APM type.
+// SpanFactory creates spans and propagates trace context for an APM provider.
+// Implementations must be safe for concurrent use.
+type SpanFactory interface {
+	// Start begins a new span as a child of the span or remote context in ctx.
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+
+	// SpanFromContext returns the active span in ctx, or nil if there is none.
+	SpanFromContext(ctx context.Context) Span
+
+	// TraceIDs returns the trace and span IDs of the active span in ctx in the
+	// backend's native format, or empty strings if there is no active span.
+	TraceIDs(ctx context.Context) (traceID, spanID string)
+
+	// Inject writes the trace context in ctx into outgoing HTTP headers.
+	Inject(ctx context.Context, header http.Header)
+
+	// Extract returns a copy of ctx carrying the trace context found in
+	// incoming HTTP headers, so the next span started from it continues the trace.
+	Extract(ctx context.Context, header http.Header) context.Context
+}
+
+// Trace holds the span factory of the active APM provider.
 type Trace struct {
-	obs     *Observability
-	apmType APMType
+	obs   *Observability
+	spans SpanFactory
 }
 
-// Start creates a new span. The actual implementation is provided by a
-// build-specific file (`trace_otlp.go`, `trace_datadog.go`, etc.).
+// Start creates a new span using the configured APM provider. Request
+// metadata stored in ctx (see WithUser), the workflow ID (see
+// StartWorkflow), and the fields extracted by WithContextFields are added to
+// the span as attributes.
 func (t *Trace) Start(ctx context.Context, spanName string) (context.Context, Span) {
-	return startSpan(t, ctx, spanName)
+	return t.start(ctx, spanName, trace.SpanKindInternal)
+}
+
+// spanKindStarter is implemented by span factories whose provider records
+// the kind of a span, such as server for the root span of a request.
+type spanKindStarter interface {
+	startKind(ctx context.Context, spanName string, kind trace.SpanKind) (context.Context, Span)
+}
+
+// start is Start for a span of the given kind. Providers that do not record
+// kinds start an ordinary span.
+func (t *Trace) start(ctx context.Context, spanName string, kind trace.SpanKind) (context.Context, Span) {
+	var span Span
+	if s, ok := t.spans.(spanKindStarter); ok && kind != trace.SpanKindInternal {
+		ctx, span = s.startKind(ctx, spanName, kind)
+	} else {
+		ctx, span = t.spans.Start(ctx, spanName)
+	}
+	if md, ok := ctx.Value(requestMetadataKey{}).(requestMetadata); ok {
+		span.SetAttributes(md.spanAttributes()...)
+	}
+	if id := WorkflowFrom(ctx); id != "" {
+		span.SetAttributes(attribute.String(WorkflowIDKey, id))
+	}
+	if fields := t.obs.providers.contextFields; len(fields) > 0 && span.IsRecording() {
+		span.SetAttributes(contextSpanAttributes(ctx, fields)...)
+	}
+	return ctx, span
 }
 
-// InjectHTTP injects the current trace context into HTTP headers. The actual
-// implementation is provided by a build-specific file.
+// InjectHTTP injects the current trace context into HTTP headers.
 func (t *Trace) InjectHTTP(req *http.Request) {
-	injectHTTP(t, req)
+	// Always use the context from the parent observability object.
+	t.spans.Inject(t.obs.Context(), req.Header)
 }
 
 // newTrace creates a new Trace instance.
-func newTrace(obs *Observability, serviceName string, apmType APMType) *Trace {
-	// The serviceName is used by the OTel tracer, which is initialized
-	// in the build-specific files.
-	initializeTracer(serviceName)
-
+func newTrace(obs *Observability) *Trace {
 	return &Trace{
-		obs:     obs,
-		apmType: apmType,
+		obs:   obs,
+		spans: obs.providers.spans,
 	}
 }
-
-/*
-The following functions and variables must be implemented by a build-specific file
-(e.g., trace_otlp.go, trace_datadog.go, trace_all.go, trace_none.go).
-This approach ensures that we only compile the code for the selected APM provider.
-
-var (
-	// startSpan creates a new span.
-	startSpan func(t *Trace, ctx context.Context, spanName string) (context.Context, Span)
-
-	// injectHTTP injects the trace context into HTTP headers.
-	injectHTTP func(t *Trace, req *http.Request)
-
-	// initializeTracer sets up the tracer for the given service name.
-	initializeTracer func(serviceName string)
-)
-*/
-var (
-	startSpan        func(t *Trace, ctx context.Context, spanName string) (context.Context, Span)
-	injectHTTP       func(t *Trace, req *http.Request)
-	initializeTracer func(serviceName string)
-)
@@ -1,109 +1,227 @@
-//go:build datadog
+//go:build datadog || !(otlp || none)
 
 package observability
 
 import (
 	"context"
 	"net/http"
-	"sync"
+	"strconv"
+	"sync/atomic"
 
-	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/ext"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
-var (
-	// unifiedSpanPool reduces allocations by reusing unifiedSpan objects.
-	unifiedSpanPool = sync.Pool{
-		New: func() interface{} {
-			return new(unifiedSpan)
-		},
-	}
-)
+// datadogAttributeTransform rewrites the tags of Datadog spans as the
+// AttributeFilter and AttrValueLimit of the Datadog tracer say. The tracer
+// is process-wide, so there is no instance to hold it.
+var datadogAttributeTransform atomic.Pointer[attributeTransform]
+
+// datadog64BitTraceIDs reports 128-bit trace IDs by their lower 64 bits in
+// decimal, as DD_TRACE_128_BIT_TRACEID_LOGGING_ENABLED=false asks.
+var datadog64BitTraceIDs atomic.Bool
 
-// unifiedSpan is a concrete implementation of the Span interface for Datadog.
-type unifiedSpan struct {
-	span interface{}
-	obs  *Observability
+// datadogSpan is the Span implementation for Datadog.
+type datadogSpan struct {
+	span tracer.Span
 }
 
-// End ends the span.
-func (s *unifiedSpan) End() {
-	if span, ok := s.span.(tracer.Span); ok {
-		span.Finish()
+// End ends the span. It does nothing on a nil or ended span.
+func (s *datadogSpan) End() {
+	if s == nil || s.span == nil {
+		return
 	}
+	s.span.Finish()
 	s.span = nil
-	s.obs = nil
-	unifiedSpanPool.Put(s)
 }
 
-// AddEvent adds an event to the span.
-func (s *unifiedSpan) AddEvent(name string, options ...trace.EventOption) {
-	if span, ok := s.span.(tracer.Span); ok {
-		span.SetTag("event", name)
+// EndWith finishes the span, marking it as failed with *err if set. It
+// does nothing on a nil or ended span.
+func (s *datadogSpan) EndWith(err *error) {
+	if s == nil || s.span == nil {
+		return
+	}
+	if err != nil && *err != nil {
+		s.setTags(errorAttributes(*err))
+		s.span.Finish(tracer.WithError(*err))
+	} else {
+		s.span.Finish()
 	}
+	s.span = nil
 }
 
-// RecordError records an error on the span.
-func (s *unifiedSpan) RecordError(err error, options ...trace.EventOption) {
-	if span, ok := s.span.(tracer.Span); ok {
-		span.SetTag("error", err)
-	}
+// AddEvent adds an event to the span. Datadog has no span events, so the
+// event name and its attributes are recorded as tags.
+func (s *datadogSpan) AddEvent(name string, options ...trace.EventOption) {
+	s.setEventTags(options)
+	s.span.SetTag("event", name)
+}
+
+// RecordError records an error on the span, and its classification, if it
+// is an ObsError, and the chain of errors it wraps as tags.
+func (s *datadogSpan) RecordError(err error, options ...trace.EventOption) {
+	s.setEventTags(options)
+	s.setTags(errorAttributes(err))
+	s.span.SetTag("error", err)
 }
 
 // SetStatus sets the status of the span.
-func (s *unifiedSpan) SetStatus(code codes.Code, description string) {
-	if span, ok := s.span.(tracer.Span); ok {
-		span.SetTag("status", description)
-	}
+func (s *datadogSpan) SetStatus(code codes.Code, description string) {
+	s.span.SetTag("status", description)
 }
 
 // SetAttributes sets attributes on the span.
-func (s *unifiedSpan) SetAttributes(attrs ...attribute.KeyValue) {
-	if span, ok := s.span.(tracer.Span); ok {
-		for _, attr := range attrs {
-			span.SetTag(string(attr.Key), attr.Value.AsInterface())
-		}
+func (s *datadogSpan) SetAttributes(attrs ...attribute.KeyValue) {
+	s.setTags(attrs)
+}
+
+// SetName sets the span's resource name, which Datadog groups spans by; the
+// operation name cannot change after the span starts.
+func (s *datadogSpan) SetName(name string) {
+	s.span.SetTag(ext.ResourceName, name)
+}
+
+// IsRecording always reports true; sampling decisions for Datadog are made
+// by the agent after the span is finished.
+func (s *datadogSpan) IsRecording() bool {
+	return true
+}
+
+// Keep sets the manual.keep tag, which keeps the whole trace.
+func (s *datadogSpan) Keep() {
+	s.span.SetTag(ext.ManualKeep, true)
+}
+
+func (s *datadogSpan) setEventTags(options []trace.EventOption) {
+	cfg := trace.NewEventConfig(options...)
+	s.setTags(cfg.Attributes())
+}
+
+func (s *datadogSpan) setTags(attrs []attribute.KeyValue) {
+	if transform := datadogAttributeTransform.Load(); transform != nil && *transform != nil {
+		attrs = (*transform)(attrs)
+	}
+	for _, attr := range attrs {
+		s.span.SetTag(string(attr.Key), attr.Value.AsInterface())
 	}
 }
 
-func init() {
-	startSpan = func(t *Trace, ctx context.Context, spanName string) (context.Context, Span) {
-		if t.apmType != Datadog {
-			// When built with the datadog tag, only datadog is supported.
-			return ctx, &noOpSpan{}
+// datadogRemoteKey stores a span context extracted from incoming headers until
+// the first local span is started from it.
+type datadogRemoteKey struct{}
+
+// datadogSpanFactory is the SpanFactory for the Datadog APM type.
+type datadogSpanFactory struct{}
+
+func (f datadogSpanFactory) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	return f.startKind(ctx, spanName, trace.SpanKindInternal)
+}
+
+// datadogSpanKinds are the span.kind tags of the span kinds other than
+// internal, which Datadog leaves untagged.
+var datadogSpanKinds = map[trace.SpanKind]string{
+	trace.SpanKindServer:   ext.SpanKindServer,
+	trace.SpanKindClient:   ext.SpanKindClient,
+	trace.SpanKindProducer: ext.SpanKindProducer,
+	trace.SpanKindConsumer: ext.SpanKindConsumer,
+}
+
+func (datadogSpanFactory) startKind(ctx context.Context, spanName string, kind trace.SpanKind) (context.Context, Span) {
+	var opts []ddtrace.StartSpanOption
+	if tag, ok := datadogSpanKinds[kind]; ok {
+		opts = append(opts, tracer.Tag(ext.SpanKind, tag))
+	}
+	if _, ok := tracer.SpanFromContext(ctx); !ok {
+		if remote, ok := ctx.Value(datadogRemoteKey{}).(ddtrace.SpanContext); ok {
+			opts = append(opts, tracer.ChildOf(remote))
 		}
+	}
 
-		span := unifiedSpanPool.Get().(*unifiedSpan)
-		span.obs = t.obs
+	if forceSampled(ctx) {
+		opts = append(opts, tracer.Tag(ext.ManualKeep, true))
+	}
+
+	span := new(datadogSpan)
+	span.span, ctx = tracer.StartSpanFromContext(ctx, spanName, opts...)
+	return ctx, span
+}
 
-		ddSpan, newDdCtx := tracer.StartSpanFromContext(ctx, spanName)
-		span.span = ddSpan
+func (datadogSpanFactory) SpanFromContext(ctx context.Context) Span {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		return &datadogSpan{span: span}
+	}
+	return nil
+}
 
-		return newDdCtx, span
+// baggageItem returns the ot-baggage-* item key of the active span or, before
+// one is started, of the remote span context.
+func (datadogSpanFactory) baggageItem(ctx context.Context, key string) (value string) {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		return span.BaggageItem(key)
+	}
+	if remote, ok := ctx.Value(datadogRemoteKey{}).(ddtrace.SpanContext); ok {
+		remote.ForeachBaggageItem(func(k, v string) bool {
+			if k == key {
+				value = v
+				return false
+			}
+			return true
+		})
 	}
+	return value
+}
 
-	injectHTTP = func(t *Trace, req *http.Request) {
-		if t.apmType != Datadog {
-			return
-		}
-		ctx := t.obs.Context()
-		if span, ok := tracer.SpanFromContext(ctx); ok {
-			tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(req.Header))
+// TraceIDs returns the IDs as Datadog logs them: a 128-bit trace ID, which
+// the tracer generates by default, as 32 hexadecimal digits, the form W3C
+// traceparent carries it in, and a 64-bit one, such as that of a trace
+// continued from an older tracer, as a decimal number.
+func (datadogSpanFactory) TraceIDs(ctx context.Context) (traceID, spanID string) {
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		sc := span.Context()
+		traceID = strconv.FormatUint(sc.TraceID(), 10)
+		if w3c, ok := sc.(ddtrace.SpanContextW3C); ok && !datadog64BitTraceIDs.Load() {
+			if id := w3c.TraceID128(); id[:16] != "0000000000000000" {
+				traceID = id
+			}
 		}
+		spanID = strconv.FormatUint(sc.SpanID(), 10)
 	}
+	return
+}
 
-	initializeTracer = func(serviceName string) {
-		// Datadog tracer is initialized via tracer.Start(), not here.
+// Inject writes the Datadog trace headers and, independently of them, W3C
+// baggage such as the workflow ID.
+func (datadogSpanFactory) Inject(ctx context.Context, header http.Header) {
+	propagation.Baggage{}.Inject(ctx, propagation.HeaderCarrier(header))
+	if span, ok := tracer.SpanFromContext(ctx); ok {
+		tracer.Inject(span.Context(), tracer.HTTPHeadersCarrier(header))
 	}
 }
 
-// noOpSpan is a no-op implementation of the Span interface.
-type noOpSpan struct{}
+func (datadogSpanFactory) Extract(ctx context.Context, header http.Header) context.Context {
+	ctx = propagation.Baggage{}.Extract(ctx, propagation.HeaderCarrier(header))
+	remote, err := tracer.Extract(tracer.HTTPHeadersCarrier(header))
+	if err != nil {
+		return ctx
+	}
+	if ts := header.Get("tracestate"); ts != "" {
+		ctx = context.WithValue(ctx, datadogTraceStateKey{}, ts)
+	}
+	return context.WithValue(ctx, datadogRemoteKey{}, remote)
+}
 
-func (s *noOpSpan) End()                                  {}
-func (s *noOpSpan) AddEvent(string, ...trace.EventOption) {}
-func (s *noOpSpan) RecordError(error, ...trace.EventOption) {}
-func (s *noOpSpan) SetStatus(codes.Code, string)          {}
-func (s *noOpSpan) SetAttributes(...attribute.KeyValue)   {}
+// datadogTraceStateKey is the context key of the tracestate of the incoming
+// request, which the tracer keeps to itself.
+type datadogTraceStateKey struct{}
+
+// traceState returns the tracestate of the incoming request the context
+// descends from; the tracer adds its dd entry to it when it injects.
+func (datadogSpanFactory) traceState(ctx context.Context) string {
+	ts, _ := ctx.Value(datadogTraceStateKey{}).(string)
+	return ts
+}
@@ -0,0 +1,76 @@
+//go:build datadog || !(otlp || none)
+
+package observability
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/mocktracer"
+)
+
+func TestDatadogSpanEndTwice(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+
+	_, span := datadogSpanFactory{}.Start(context.Background(), "op")
+	span.End()
+	span.End()
+	var err error
+	span.EndWith(&err)
+	if n := len(mt.FinishedSpans()); n != 1 {
+		t.Errorf("finished %d spans, want 1", n)
+	}
+
+	var nilSpan *datadogSpan
+	nilSpan.End()
+	nilSpan.EndWith(&err)
+}
+
+func TestDatadogTraceState(t *testing.T) {
+	mt := mocktracer.Start()
+	defer mt.Stop()
+	f := datadogSpanFactory{}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{
+			name: "continued trace",
+			header: http.Header{
+				"X-Datadog-Trace-Id":  {"1234"},
+				"X-Datadog-Parent-Id": {"5678"},
+				"Tracestate":          {"congo=t61rcWkgMzE"},
+			},
+			want: "congo=t61rcWkgMzE",
+		},
+		{
+			name:   "no trace context",
+			header: http.Header{"Tracestate": {"congo=t61rcWkgMzE"}},
+		},
+		{
+			name: "no tracestate",
+			header: http.Header{
+				"X-Datadog-Trace-Id":  {"1234"},
+				"X-Datadog-Parent-Id": {"5678"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := f.Extract(context.Background(), tt.header)
+			if got := f.traceState(ctx); got != tt.want {
+				t.Errorf("traceState = %q, want %q", got, tt.want)
+			}
+			// Spans started from the request keep it.
+			ctx, span := f.Start(ctx, "op")
+			defer span.End()
+			if got := f.traceState(ctx); got != tt.want {
+				t.Errorf("traceState in span = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,3 @@
-//go:build none
-
 package observability
 
 import (
@@ -8,28 +6,52 @@ import (
 
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/trace"
 )
 
-func init() {
-	startSpan = func(t *Trace, ctx context.Context, spanName string) (context.Context, Span) {
-		return ctx, &noOpSpan{}
-	}
+// noneSpanFactory is the SpanFactory for the "none" APM type. It is always
+// compiled in and never records anything.
+type noneSpanFactory struct{}
+
+func (noneSpanFactory) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	return ctx, &noOpSpan{}
+}
+
+func (noneSpanFactory) SpanFromContext(ctx context.Context) Span {
+	return nil
+}
+
+func (noneSpanFactory) TraceIDs(ctx context.Context) (traceID, spanID string) {
+	return "", ""
+}
+
+// Inject propagates only baggage, such as the workflow ID, since there is no
+// trace context.
+func (noneSpanFactory) Inject(ctx context.Context, header http.Header) {
+	propagation.Baggage{}.Inject(ctx, propagation.HeaderCarrier(header))
+}
 
-	injectHTTP = func(t *Trace, req *http.Request) {
-		// Do nothing
-	}
+func (noneSpanFactory) Extract(ctx context.Context, header http.Header) context.Context {
+	return propagation.Baggage{}.Extract(ctx, propagation.HeaderCarrier(header))
+}
 
-	initializeTracer = func(serviceName string) {
-		// Do nothing
-	}
+func setupNone(ctx context.Context, cfg TracingConfig) (Shutdowner, error) {
+	return &noOpShutdowner{}, nil
+}
+
+func init() {
+	RegisterAPMProvider(string(None), setupNone, noneSpanFactory{})
 }
 
 // noOpSpan is a no-op implementation of the Span interface.
 type noOpSpan struct{}
 
-func (s *noOpSpan) End()                                  {}
-func (s *noOpSpan) AddEvent(string, ...trace.EventOption) {}
+func (s *noOpSpan) End()                                    {}
+func (s *noOpSpan) EndWith(*error)                          {}
+func (s *noOpSpan) AddEvent(string, ...trace.EventOption)   {}
 func (s *noOpSpan) RecordError(error, ...trace.EventOption) {}
-func (s *noOpSpan) SetStatus(codes.Code, string)          {}
-func (s *noOpSpan) SetAttributes(...attribute.KeyValue)   {}
\ No newline at end of file
+func (s *noOpSpan) SetStatus(codes.Code, string)            {}
+func (s *noOpSpan) SetAttributes(...attribute.KeyValue)     {}
+func (s *noOpSpan) IsRecording() bool                       { return false }
+func (s *noOpSpan) Keep()                                   {}
@@ -1,11 +1,11 @@
-//go:build otlp
+//go:build otlp || !(datadog || none)
 
 package observability
 
 import (
 	"context"
 	"net/http"
-	"sync"
+	"runtime/pprof"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -14,84 +14,162 @@ import (
 	"go.opentelemetry.io/otel/trace"
 )
 
-var (
-	// unifiedSpanPool reduces allocations by reusing unifiedSpan objects.
-	unifiedSpanPool = sync.Pool{
-		New: func() interface{} {
-			return new(unifiedSpan)
-		},
-	}
-	otelTracer trace.Tracer
-)
+// otelTracer delegates to the global TracerProvider. It backs span
+// factories not bound to a provider of their own.
+var otelTracer trace.Tracer = otel.Tracer("github.com/app-obs/go/observability")
 
-// unifiedSpan is a concrete implementation of the Span interface for OTLP.
-type unifiedSpan struct {
+// otelSpan is the Span implementation for OTLP.
+type otelSpan struct {
 	span trace.Span
-	obs  *Observability
+
+	// labels is the context whose profiler labels End restores on the
+	// goroutine, for spans that labeled it with their span ID.
+	labels context.Context
 }
 
-// End ends the span.
-func (s *unifiedSpan) End() {
+// End ends the span. It does nothing on a nil or ended span.
+func (s *otelSpan) End() {
+	if s == nil || s.span == nil {
+		return
+	}
+	if s.labels != nil {
+		pprof.SetGoroutineLabels(s.labels)
+		s.labels = nil
+	}
 	s.span.End()
 	s.span = nil
-	s.obs = nil
-	unifiedSpanPool.Put(s)
+}
+
+// EndWith records *err on the span, if set, and ends it. It does nothing
+// on a nil or ended span.
+func (s *otelSpan) EndWith(err *error) {
+	if s == nil || s.span == nil {
+		return
+	}
+	if err != nil && *err != nil {
+		s.RecordError(*err)
+		s.span.SetStatus(codes.Error, (*err).Error())
+	}
+	s.End()
 }
 
 // AddEvent adds an event to the span.
-func (s *unifiedSpan) AddEvent(name string, options ...trace.EventOption) {
+func (s *otelSpan) AddEvent(name string, options ...trace.EventOption) {
 	s.span.AddEvent(name, options...)
 }
 
-// RecordError records an error on the span.
-func (s *unifiedSpan) RecordError(err error, options ...trace.EventOption) {
+// RecordError records an error on the span, and its classification, if it
+// is an ObsError, and the chain of errors it wraps as span attributes.
+func (s *otelSpan) RecordError(err error, options ...trace.EventOption) {
 	s.span.RecordError(err, options...)
+	if attrs := errorAttributes(err); attrs != nil {
+		s.span.SetAttributes(attrs...)
+	}
 }
 
 // SetStatus sets the status of the span.
-func (s *unifiedSpan) SetStatus(code codes.Code, description string) {
+func (s *otelSpan) SetStatus(code codes.Code, description string) {
 	s.span.SetStatus(code, description)
 }
 
 // SetAttributes sets attributes on the span.
-func (s *unifiedSpan) SetAttributes(attrs ...attribute.KeyValue) {
+func (s *otelSpan) SetAttributes(attrs ...attribute.KeyValue) {
 	s.span.SetAttributes(attrs...)
 }
 
-func init() {
-	startSpan = func(t *Trace, ctx context.Context, spanName string) (context.Context, Span) {
-		if t.apmType != OTLP {
-			// When built with the otlp tag, only otlp is supported.
-			return ctx, &noOpSpan{}
-		}
+// IsRecording reports whether the span is sampled and recording data.
+func (s *otelSpan) IsRecording() bool {
+	return s.span.IsRecording()
+}
+
+// Keep marks the span with a sampling priority of 1. A span that is not
+// sampled cannot be kept after it started.
+func (s *otelSpan) Keep() {
+	s.span.SetAttributes(attribute.Int(SamplingPriorityKey, 1))
+}
+
+// SetName renames the span.
+func (s *otelSpan) SetName(name string) {
+	s.span.SetName(name)
+}
 
-		span := unifiedSpanPool.Get().(*unifiedSpan)
-		span.obs = t.obs
+// otelSpanFactory is the SpanFactory for the OTLP APM type. The zero value,
+// which is registered for the type, uses the global tracer and propagator;
+// setupOTLP binds one to the TracerProvider it creates.
+type otelSpanFactory struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
 
-		newCtx, otelSpan := otelTracer.Start(ctx, spanName)
-		span.span = otelSpan
+	// profiling labels the CPU profile samples of sampled local root spans
+	// with their span ID, for trace-to-profile correlation.
+	profiling bool
+}
 
-		return newCtx, span
+func (f otelSpanFactory) Start(ctx context.Context, spanName string) (context.Context, Span) {
+	return f.startKind(ctx, spanName, trace.SpanKindInternal)
+}
+
+func (f otelSpanFactory) startKind(ctx context.Context, spanName string, kind trace.SpanKind) (context.Context, Span) {
+	tracer := f.tracer
+	if tracer == nil {
+		tracer = otelTracer
 	}
+	parent := trace.SpanContextFromContext(ctx)
+	span := new(otelSpan)
+	ctx, span.span = tracer.Start(ctx, spanName, trace.WithSpanKind(kind))
+	if f.profiling && (!parent.IsValid() || parent.IsRemote()) && span.span.SpanContext().IsSampled() {
+		ctx = span.profile(ctx, spanName)
+	}
+	return ctx, span
+}
 
-	injectHTTP = func(t *Trace, req *http.Request) {
-		if t.apmType != OTLP {
-			return
-		}
-		ctx := t.obs.Context()
-		otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+// profile labels the goroutine, and the goroutines it starts, with the
+// span's ID and name until the span ends, and records the ID as the span's
+// profile ID. Only local root spans are labeled: the profile of a request
+// covers its child spans, and Pyroscope looks profiles up by the root.
+func (s *otelSpan) profile(ctx context.Context, spanName string) context.Context {
+	spanID := s.span.SpanContext().SpanID().String()
+	s.span.SetAttributes(attribute.String(profileIDKey, spanID))
+	s.labels = ctx
+	ctx = pprof.WithLabels(ctx, pprof.Labels(spanIDLabel, spanID, spanNameLabel, spanName))
+	pprof.SetGoroutineLabels(ctx)
+	return ctx
+}
+
+func (otelSpanFactory) SpanFromContext(ctx context.Context) Span {
+	span := trace.SpanFromContext(ctx)
+	if !span.SpanContext().IsValid() {
+		return nil
 	}
+	return &otelSpan{span: span}
+}
 
-	initializeTracer = func(serviceName string) {
-		otelTracer = otel.Tracer(serviceName)
+func (otelSpanFactory) TraceIDs(ctx context.Context) (traceID, spanID string) {
+	sc := trace.SpanContextFromContext(ctx)
+	if sc.HasTraceID() {
+		traceID = sc.TraceID().String()
+	}
+	if sc.HasSpanID() {
+		spanID = sc.SpanID().String()
 	}
+	return
 }
 
-// noOpSpan is a no-op implementation of the Span interface.
-type noOpSpan struct{}
+func (otelSpanFactory) traceState(ctx context.Context) string {
+	return trace.SpanContextFromContext(ctx).TraceState().String()
+}
+
+func (f otelSpanFactory) Inject(ctx context.Context, header http.Header) {
+	f.textMapPropagator().Inject(ctx, propagation.HeaderCarrier(header))
+}
 
-func (s *noOpSpan) End()                                  {}
-func (s *noOpSpan) AddEvent(string, ...trace.EventOption) {}
-func (s *noOpSpan) RecordError(error, ...trace.EventOption) {}
-func (s *noOpSpan) SetStatus(codes.Code, string)          {}
-func (s *noOpSpan) SetAttributes(...attribute.KeyValue)   {}
+func (f otelSpanFactory) Extract(ctx context.Context, header http.Header) context.Context {
+	return f.textMapPropagator().Extract(ctx, propagation.HeaderCarrier(header))
+}
+
+func (f otelSpanFactory) textMapPropagator() propagation.TextMapPropagator {
+	if f.propagator != nil {
+		return f.propagator
+	}
+	return otel.GetTextMapPropagator()
+}
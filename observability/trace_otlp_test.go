@@ -0,0 +1,30 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestOtelSpanEndTwice(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer tp.Shutdown(context.Background())
+
+	_, span := otelSpanFactory{tracer: tp.Tracer("test")}.Start(context.Background(), "op")
+	span.End()
+	span.End()
+	var err error
+	span.EndWith(&err)
+	if n := len(recorder.Ended()); n != 1 {
+		t.Errorf("ended %d spans, want 1", n)
+	}
+
+	var nilSpan *otelSpan
+	nilSpan.End()
+	nilSpan.EndWith(&err)
+}
@@ -3,22 +3,160 @@ package observability
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// TracingConfig carries the settings an APM provider needs to initialize.
+type TracingConfig struct {
+	ServiceName string
+	ServiceApp  string
+	ServiceEnv  string
+	// ServiceVersion identifies the build of the service; it may be empty.
+	ServiceVersion string
+	ApmURL         string
+	SampleRate     float64
+	SpanLimits     SpanLimits
+
+	// AttrValueLimit, if positive, is the most bytes of a string attribute
+	// value exported; longer values are cut (see WithAttributeValueLimit).
+	AttrValueLimit int
+
+	// AttributeFilter, if set, drops the span attributes it does not keep
+	// before export (see WithAttributeFilter).
+	AttributeFilter *AttributeFilter
+
+	// SpanFilter, if set, decides which finished spans are exported (see
+	// WithSpanFilter). Supported by the providers built on the
+	// OpenTelemetry SDK.
+	SpanFilter SpanFilter
+
+	// AdaptiveSampling, if positive, replaces SampleRate with a target
+	// number of sampled traces per minute per operation (see
+	// WithAdaptiveSampling). Supported by the providers built on the
+	// OpenTelemetry SDK.
+	AdaptiveSampling int
+
+	// TailSampling, if set, samples every trace at its start and keeps only
+	// those it selects once they end (see WithTailSampling). Supported by
+	// the providers built on the OpenTelemetry SDK.
+	TailSampling *TailSampling
+
+	// spanMetrics, if set, records every finished span (see
+	// WithSpanMetrics).
+	spanMetrics *spanMetrics
+
+	// SpanCompression is the longest span duration eligible for compressing
+	// identical consecutive siblings into one span. Zero disables compression.
+	SpanCompression time.Duration
+
+	// IDGenerator creates trace and span IDs. Nil uses the provider's default.
+	IDGenerator IDGenerator
+
+	// XRay makes trace IDs X-Ray compatible and propagates the X-Ray trace
+	// header (see WithXRayCompatibility). Supported by the providers built on
+	// the OpenTelemetry SDK.
+	XRay bool
+
+	// DatadogPropagation propagates Datadog's trace headers as well as W3C
+	// trace context (see WithDatadogPropagation). Supported by the providers
+	// built on the OpenTelemetry SDK.
+	DatadogPropagation bool
+
+	// TraceStateEntry is a key=value entry added to the W3C tracestate of
+	// the service's spans (see WithTraceStateEntry). Supported by the
+	// providers built on the OpenTelemetry SDK.
+	TraceStateEntry string
+
+	// StdoutFormat is how the stdout provider writes spans: "pretty" (the
+	// default) or "json" (see WithStdoutTraceFormat).
+	StdoutFormat string
+
+	// FileRotation bounds the span file written by the file provider (see
+	// WithTraceFileRotation).
+	FileRotation FileRotation
+
+	// ResourceAttributes describe where the service runs, as found by
+	// resource detection (see WithResourceDetection).
+	ResourceAttributes []attribute.KeyValue
+
+	// Global asks the provider to also install its tracer and propagator as
+	// the process-wide defaults (e.g., with otel.SetTracerProvider), for
+	// instrumentation libraries that use them (see WithGlobalProviders).
+	Global bool
+
+	// Profiling asks the provider to label CPU profile samples with the span
+	// that recorded them, for trace-to-profile correlation (see
+	// WithProfilingURL). Supported by the OTLP provider.
+	Profiling bool
+
+	// stats, if set, counts the spans started and ended and the failed
+	// exports, and records the exports' latency, for the factory's
+	// self-telemetry.
+	stats *pipelineStats
+}
+
+// SpanFilter reports whether a finished span, given its name and
+// attributes, is exported. It must be safe for concurrent use.
+type SpanFilter func(name string, attrs []attribute.KeyValue) bool
+
+// SpanLimits bounds how much data a single span may hold. A zero value for
+// any field keeps the provider's default for that limit.
+type SpanLimits struct {
+	MaxAttributes int
+	MaxEvents     int
+	MaxLinks      int
+}
+
 // SetupFunc defines the signature for functions that set up an APM provider.
-type SetupFunc func(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL string, sampleRate float64) (Shutdowner, error)
+type SetupFunc func(ctx context.Context, cfg TracingConfig) (Shutdowner, error)
 
-// setupFuncs is a registry of APM setup functions, populated by build-tagged files.
-var setupFuncs = make(map[APMType]SetupFunc)
+// spanFactoryProvider is implemented by the Shutdowner of an APM provider
+// whose spans are bound to the tracer it set up rather than to process-wide
+// state.
+type spanFactoryProvider interface {
+	spanFactory() SpanFactory
+}
 
-// setupTracing initializes and configures the global TracerProvider based on APM type.
-func setupTracing(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL, apmType string, sampleRate float64) (Shutdowner, error) {
+// setupTracing initializes the APM provider for apmType. It returns the span
+// factory bound to the new tracer, or the one registered for the type if the
+// provider has no per-instance tracer.
+func setupTracing(ctx context.Context, apmType string, cfg TracingConfig) (Shutdowner, SpanFactory, error) {
 	normalizedApmType := normalizeAPMType(apmType)
 
-	setupFunc, ok := setupFuncs[normalizedApmType]
+	provider, ok := lookupAPMProvider(normalizedApmType)
 	if !ok {
-		return nil, fmt.Errorf("unsupported APM type: %s", apmType)
+		return nil, nil, fmt.Errorf("%s APM is not included in this build; rebuild with the %q build tag or without APM build tags", normalizedApmType, normalizedApmType)
 	}
 
-	return setupFunc(ctx, serviceName, serviceApp, serviceEnv, apmURL, sampleRate)
+	shutdowner, err := provider.setup(ctx, cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p, ok := shutdowner.(spanFactoryProvider); ok {
+		return shutdowner, p.spanFactory(), nil
+	}
+	return shutdowner, provider.spans, nil
+}
+
+// defaultJaegerURL is the OTLP/HTTP trace endpoint of a local Jaeger
+// all-in-one.
+const defaultJaegerURL = "http://localhost:4318/v1/traces"
+
+// jaegerTracesURL resolves the APM URL of the Jaeger APM type to its OTLP
+// trace endpoint: empty means a local Jaeger, and a URL without a path, such
+// as http://jaeger:4318, gets the standard /v1/traces path.
+func jaegerTracesURL(apmURL string) string {
+	if apmURL == "" {
+		return defaultJaegerURL
+	}
+	u, err := url.Parse(apmURL)
+	if err != nil || strings.Trim(u.Path, "/") != "" {
+		return apmURL
+	}
+	u.Path = "/v1/traces"
+	return u.String()
 }
@@ -1,35 +1,57 @@
-//go:build datadog
+//go:build datadog || !(otlp || none)
 
 package observability
 
 import (
 	"context"
-	"fmt"
 	"log/slog"
+	"os"
+	"strconv"
 
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
 )
 
 // setupDatadog configures and initializes the Datadog Tracer.
-func setupDatadog(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL string, sampleRate float64) (Shutdowner, error) {
-	tracer.Start(
-		tracer.WithService(serviceName),
-		tracer.WithEnv(serviceEnv),
-		tracer.WithServiceVersion(serviceApp),
-		tracer.WithAgentAddr(apmURL),
-		tracer.WithAnalyticsRate(sampleRate),
-	)
+func setupDatadog(ctx context.Context, cfg TracingConfig) (Shutdowner, error) {
+	tracer.Start(datadogStartOptions(cfg)...)
+	transform := cfg.attributeTransform()
+	datadogAttributeTransform.Store(&transform)
 
-	obs := NewObservability(ctx, serviceName, string(Datadog), true, slog.LevelDebug, slog.LevelInfo, false)
+	obs := NewObservability(ctx, cfg.ServiceName, string(Datadog), true, slog.LevelDebug, slog.LevelInfo, false)
 	obs.Log.Info("Datadog Tracer initialized successfully",
-		"APMURL", apmURL,
+		"APMURL", cfg.ApmURL,
 		"APMType", Datadog,
-		"SampleRate", sampleRate,
+		"SampleRate", cfg.SampleRate,
 	)
 
 	return &datadogShutdowner{}, nil
 }
 
+// datadogStartOptions returns the options the Datadog tracer is started
+// with for cfg, and applies the settings read from the tracer's environment.
+func datadogStartOptions(cfg TracingConfig) []tracer.StartOption {
+	// Datadog has no application tag; without a version, the application
+	// name stands in for it as before.
+	version := cfg.ServiceVersion
+	if version == "" {
+		version = cfg.ServiceApp
+	}
+	opts := []tracer.StartOption{
+		tracer.WithService(cfg.ServiceName),
+		tracer.WithEnv(cfg.ServiceEnv),
+		tracer.WithServiceVersion(version),
+		tracer.WithAgentAddr(cfg.ApmURL),
+		tracer.WithAnalyticsRate(cfg.SampleRate),
+	}
+	for _, attr := range cfg.ResourceAttributes {
+		opts = append(opts, tracer.WithGlobalTag(string(attr.Key), attr.Value.Emit()))
+	}
+	if b, err := strconv.ParseBool(os.Getenv("DD_TRACE_128_BIT_TRACEID_LOGGING_ENABLED")); err == nil {
+		datadog64BitTraceIDs.Store(!b)
+	}
+	return opts
+}
+
 // datadogShutdowner implements the Shutdowner interface for Datadog.
 type datadogShutdowner struct{}
 
@@ -39,17 +61,17 @@ func (d *datadogShutdowner) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// ForceFlush sends finished spans to the Datadog agent.
+func (d *datadogShutdowner) ForceFlush(ctx context.Context) error {
+	tracer.Flush()
+	return nil
+}
+
 // ShutdownOrLog implements the Shutdowner interface for the datadogShutdowner.
 func (d *datadogShutdowner) ShutdownOrLog(msg string) {
 	d.Shutdown(context.Background())
 }
 
 func init() {
-	setupFuncs[Datadog] = setupDatadog
-	setupFuncs[OTLP] = func(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL string, sampleRate float64) (Shutdowner, error) {
-		return nil, fmt.Errorf("OTLP APM is not included in this build. Please use the 'datadog' build tag.")
-	}
-	setupFuncs[None] = func(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL string, sampleRate float64) (Shutdowner, error) {
-		return &noOpShutdowner{}, nil
-	}
+	RegisterAPMProvider(string(Datadog), setupDatadog, datadogSpanFactory{})
 }
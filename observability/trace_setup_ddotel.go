@@ -0,0 +1,106 @@
+//go:build (otlp && datadog) || !(otlp || datadog || none)
+
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace"
+	ddotel "gopkg.in/DataDog/dd-trace-go.v1/ddtrace/opentelemetry"
+	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/tracer"
+)
+
+// setupDatadogOTel starts the Datadog tracer behind dd-trace-go's
+// OpenTelemetry TracerProvider, which it needs the code of both the OTLP and
+// the Datadog APM types for.
+func setupDatadogOTel(ctx context.Context, cfg TracingConfig) (Shutdowner, error) {
+	tp := ddotel.NewTracerProvider(datadogStartOptions(cfg)...)
+	if cfg.Global {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		))
+	}
+
+	obs := NewObservability(ctx, cfg.ServiceName, string(DatadogOTel), true, slog.LevelDebug, slog.LevelInfo, false)
+	obs.Log.Info("Datadog OpenTelemetry TracerProvider initialized successfully",
+		"APMURL", cfg.ApmURL,
+		"APMType", DatadogOTel,
+		"SampleRate", cfg.SampleRate,
+	)
+
+	return &ddotelShutdowner{
+		provider: tp,
+		spans:    ddotelSpanFactory{otelSpanFactory{tracer: tp.Tracer(cfg.ServiceName)}},
+	}, nil
+}
+
+// ddotelShutdowner shuts down the TracerProvider and hands the factory the
+// span factory bound to it.
+type ddotelShutdowner struct {
+	provider *ddotel.TracerProvider
+	spans    ddotelSpanFactory
+}
+
+// Shutdown stops the Datadog tracer.
+func (d *ddotelShutdowner) Shutdown(ctx context.Context) error {
+	return d.provider.Shutdown()
+}
+
+// ForceFlush sends finished spans to the Datadog agent.
+func (d *ddotelShutdowner) ForceFlush(ctx context.Context) error {
+	tracer.Flush()
+	return nil
+}
+
+// ShutdownOrLog implements the Shutdowner interface for the ddotelShutdowner.
+func (d *ddotelShutdowner) ShutdownOrLog(msg string) {
+	d.Shutdown(context.Background())
+}
+
+func (d *ddotelShutdowner) spanFactory() SpanFactory {
+	return d.spans
+}
+
+// ddotelSpanFactory is the SpanFactory for the DatadogOTel APM type. Its
+// spans are OpenTelemetry spans, which dd-trace-go backs with Datadog spans
+// and stores in the context as both, so trace context is propagated, and
+// IDs are reported, as the Datadog APM type does.
+type ddotelSpanFactory struct {
+	otelSpanFactory
+}
+
+func (ddotelSpanFactory) TraceIDs(ctx context.Context) (traceID, spanID string) {
+	return datadogSpanFactory{}.TraceIDs(ctx)
+}
+
+func (ddotelSpanFactory) Inject(ctx context.Context, header http.Header) {
+	datadogSpanFactory{}.Inject(ctx, header)
+}
+
+// Extract passes the remote span context to the next span started as its
+// parent, which dd-trace-go's OpenTelemetry tracer takes as a start option.
+func (ddotelSpanFactory) Extract(ctx context.Context, header http.Header) context.Context {
+	ctx = datadogSpanFactory{}.Extract(ctx, header)
+	if remote, ok := ctx.Value(datadogRemoteKey{}).(ddtrace.SpanContext); ok {
+		ctx = ddotel.ContextWithStartOptions(ctx, tracer.ChildOf(remote))
+	}
+	return ctx
+}
+
+func (ddotelSpanFactory) baggageItem(ctx context.Context, key string) string {
+	return datadogSpanFactory{}.baggageItem(ctx, key)
+}
+
+func (ddotelSpanFactory) traceState(ctx context.Context) string {
+	return datadogSpanFactory{}.traceState(ctx)
+}
+
+func init() {
+	RegisterAPMProvider(string(DatadogOTel), setupDatadogOTel, ddotelSpanFactory{})
+}
@@ -0,0 +1,110 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// setupFile configures a TracerProvider that appends spans, as JSON lines,
+// to the file at cfg.ApmURL, rotated as cfg.FileRotation says.
+func setupFile(ctx context.Context, cfg TracingConfig) (Shutdowner, error) {
+	if cfg.ApmURL == "" {
+		return nil, errors.New("the file APM type needs the path of the span file as its APM URL")
+	}
+	file, err := openRotatingFile(cfg.ApmURL, cfg.FileRotation)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open span file: %w", err)
+	}
+	return newSDKTracer(cfg, &fileSpanExporter{file: file}, func(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+		return sdktrace.NewBatchSpanProcessor(exporter)
+	}, nil), nil
+}
+
+// fileSpanExporter writes each span as a SpanRecord on a line of its own.
+type fileSpanExporter struct {
+	file *rotatingFile
+}
+
+func (e *fileSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	var errs []error
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, s := range spans {
+		buf.Reset()
+		if err := enc.Encode(newSpanRecord(s)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		// One write per span keeps each line within one file across
+		// rotations.
+		if _, err := e.file.Write(buf.Bytes()); err != nil {
+			return errors.Join(append(errs, err)...)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (e *fileSpanExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.file.Sync(), e.file.Close())
+}
+
+// newSpanRecord converts a finished span to its file representation.
+func newSpanRecord(s sdktrace.ReadOnlySpan) SpanRecord {
+	record := SpanRecord{
+		TraceID:    s.SpanContext().TraceID().String(),
+		SpanID:     s.SpanContext().SpanID().String(),
+		Name:       s.Name(),
+		Kind:       s.SpanKind().String(),
+		StartTime:  s.StartTime(),
+		EndTime:    s.EndTime(),
+		Attributes: attributeMap(s.Attributes()),
+		Resource:   attributeMap(s.Resource().Attributes()),
+		Scope:      s.InstrumentationScope().Name,
+	}
+	if s.Parent().HasSpanID() {
+		record.ParentSpanID = s.Parent().SpanID().String()
+	}
+	if status := s.Status(); status.Code != codes.Unset {
+		record.StatusCode = status.Code.String()
+		record.StatusMessage = status.Description
+	}
+	for _, e := range s.Events() {
+		record.Events = append(record.Events, SpanEventRecord{
+			Name:       e.Name,
+			Time:       e.Time,
+			Attributes: attributeMap(e.Attributes),
+		})
+	}
+	for _, l := range s.Links() {
+		record.Links = append(record.Links, SpanLinkRecord{
+			TraceID:    l.SpanContext.TraceID().String(),
+			SpanID:     l.SpanContext.SpanID().String(),
+			Attributes: attributeMap(l.Attributes),
+		})
+	}
+	return record
+}
+
+func attributeMap(attrs []attribute.KeyValue) map[string]any {
+	if len(attrs) == 0 {
+		return nil
+	}
+	m := make(map[string]any, len(attrs))
+	for _, kv := range attrs {
+		m[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	return m
+}
+
+func init() {
+	RegisterAPMProvider(string(File), setupFile, otelSpanFactory{})
+}
@@ -0,0 +1,36 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/contrib/propagators/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// setupJaeger configures a TracerProvider that exports to Jaeger's OTLP/HTTP
+// receiver and propagates Jaeger's uber-trace-id header alongside W3C trace
+// context, so services still instrumented with Jaeger clients join the same
+// traces.
+func setupJaeger(ctx context.Context, cfg TracingConfig) (Shutdowner, error) {
+	traceExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(jaegerTracesURL(cfg.ApmURL)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Jaeger trace exporter: %w", err)
+	}
+	propagator := propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		jaeger.Jaeger{},
+		propagation.Baggage{},
+	)
+	return newSDKTracer(cfg, traceExporter, func(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+		return sdktrace.NewBatchSpanProcessor(exporter)
+	}, propagator), nil
+}
+
+func init() {
+	RegisterAPMProvider(string(Jaeger), setupJaeger, otelSpanFactory{})
+}
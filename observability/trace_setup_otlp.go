@@ -1,4 +1,4 @@
-//go:build otlp
+//go:build otlp || !(datadog || none)
 
 package observability
 
@@ -6,60 +6,159 @@ import (
 	"context"
 	"fmt"
 
+	"go.opentelemetry.io/contrib/propagators/aws/xray"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
-	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/propagation"
-	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 )
 
-// setupOTLP configures and initializes the OpenTelemetry TracerProvider and MeterProvider.
-func setupOTLP(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL string, sampleRate float64) (Shutdowner, error) {
-	res := resource.NewWithAttributes(
-		semconv.SchemaURL,
+// newOTLPResource describes the service to OTLP backends. Traces and metrics
+// share it so both signals carry the same identity. Detected attributes are
+// added before the service identity, which they cannot override.
+func newOTLPResource(serviceName, serviceApp, serviceEnv, serviceVersion string, detected []attribute.KeyValue) *resource.Resource {
+	attrs := make([]attribute.KeyValue, 0, len(detected)+4)
+	attrs = append(attrs, detected...)
+	attrs = append(attrs,
 		semconv.ServiceNameKey.String(serviceName),
 		attribute.String("application", serviceApp),
 		attribute.String("environment", serviceEnv),
 	)
+	if serviceVersion != "" {
+		attrs = append(attrs, semconv.ServiceVersionKey.String(serviceVersion))
+	}
+	return resource.NewWithAttributes(semconv.SchemaURL, attrs...)
+}
 
-	traceExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(apmURL))
+// setupOTLP configures and initializes the OpenTelemetry TracerProvider.
+func setupOTLP(ctx context.Context, cfg TracingConfig) (Shutdowner, error) {
+	traceExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.ApmURL))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
 	}
+	return newSDKTracer(cfg, traceExporter, func(exporter sdktrace.SpanExporter) sdktrace.SpanProcessor {
+		return sdktrace.NewBatchSpanProcessor(exporter)
+	}, nil), nil
+}
 
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithBatcher(traceExporter),
-		sdktrace.WithResource(res),
-		sdktrace.WithSampler(sdktrace.TraceIDRatioBased(sampleRate)),
-	)
+// newSDKTracer creates a TracerProvider that sends the spans it samples
+// through a processor, created by newProcessor, to exporter, and installs it
+// and propagator globally if configured. A nil propagator propagates W3C
+// trace context and baggage. With cfg.XRay, X-Ray trace IDs are generated
+// and the X-Ray header is propagated too, and with cfg.DatadogPropagation,
+// the Datadog headers. The OTLP provider and the other providers built
+// on the OpenTelemetry SDK share it.
+func newSDKTracer(cfg TracingConfig, exporter sdktrace.SpanExporter, newProcessor func(sdktrace.SpanExporter) sdktrace.SpanProcessor, propagator propagation.TextMapPropagator) *otlpTracerShutdowner {
+	if transform := cfg.attributeTransform(); transform != nil {
+		exporter = transformingSpanExporter{SpanExporter: exporter, transform: transform}
+	}
+	if cfg.SpanFilter != nil {
+		// Ahead of the transform, so the filter sees the attributes as set.
+		exporter = filteringSpanExporter{SpanExporter: exporter, keep: cfg.SpanFilter}
+	}
+	if cfg.stats != nil {
+		exporter = statsSpanExporter{SpanExporter: exporter, stats: cfg.stats}
+	}
+	processor := newProcessor(exporter)
+	if cfg.SpanCompression > 0 {
+		processor = newCompressionProcessor(processor, cfg.SpanCompression)
+	}
 
-	metricExporter, err := otlpmetrichttp.New(ctx, otlpmetrichttp.WithEndpointURL(apmURL))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create OTLP metric exporter: %w", err)
+	sampler := sdktrace.TraceIDRatioBased(cfg.SampleRate)
+	switch {
+	case cfg.TailSampling != nil:
+		// Spans must be recorded for the tail sampler to see them.
+		sampler = sdktrace.AlwaysSample()
+		processor = newTailSamplingProcessor(processor, *cfg.TailSampling)
+	case cfg.AdaptiveSampling > 0:
+		sampler = newAdaptiveSampler(cfg.AdaptiveSampling)
+	}
+	if cfg.spanMetrics != nil && cfg.TailSampling == nil {
+		sampler = recordOnlySampler{sampler}
+	}
+	sampler = keepSampler{sampler}
+	if cfg.TraceStateEntry != "" {
+		sampler = newTraceStateSampler(sampler, cfg.TraceStateEntry)
+	}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSpanProcessor(processor),
+		sdktrace.WithResource(newOTLPResource(cfg.ServiceName, cfg.ServiceApp, cfg.ServiceEnv, cfg.ServiceVersion, cfg.ResourceAttributes)),
+		sdktrace.WithSampler(sampler),
+		sdktrace.WithRawSpanLimits(otelSpanLimits(cfg.SpanLimits)),
+	}
+	switch {
+	case cfg.IDGenerator != nil:
+		opts = append(opts, sdktrace.WithIDGenerator(cfg.IDGenerator))
+	case cfg.XRay:
+		opts = append(opts, sdktrace.WithIDGenerator(xray.NewIDGenerator()))
+	}
+	if cfg.stats != nil {
+		// Registered first, so spans are counted before compression
+		// merges them.
+		opts = append([]sdktrace.TracerProviderOption{sdktrace.WithSpanProcessor(statsProcessor{stats: cfg.stats})}, opts...)
+	}
+	if cfg.spanMetrics != nil {
+		opts = append(opts, sdktrace.WithSpanProcessor(spanMetricsProcessor{metrics: cfg.spanMetrics}))
 	}
 
-	mp := sdkmetric.NewMeterProvider(
-		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter)),
-		sdkmetric.WithResource(res),
-	)
+	tp := sdktrace.NewTracerProvider(opts...)
+	if propagator == nil {
+		propagator = propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		)
+	}
+	if cfg.DatadogPropagation {
+		// Extracted first, like X-Ray below, so that traceparent takes
+		// precedence when a Datadog tracer sends both.
+		propagator = propagation.NewCompositeTextMapPropagator(datadogPropagator{}, propagator)
+	}
+	if cfg.XRay {
+		// X-Ray is extracted first, so a W3C traceparent sent by an
+		// instrumented caller takes precedence over the X-Amzn-Trace-Id
+		// header that load balancers add on their own.
+		propagator = propagation.NewCompositeTextMapPropagator(xray.Propagator{}, propagator)
+	}
+	if cfg.Global {
+		otel.SetTracerProvider(tp)
+		otel.SetTextMapPropagator(propagator)
+	}
+
+	return &otlpTracerShutdowner{
+		otlpShutdowner: otlpShutdowner{provider: tp, name: "TracerProvider"},
+		spans:          otelSpanFactory{tracer: tp.Tracer(cfg.ServiceName), propagator: propagator, profiling: cfg.Profiling},
+	}
+}
+
+// otlpTracerShutdowner shuts down a TracerProvider and hands the factory
+// the span factory bound to it.
+type otlpTracerShutdowner struct {
+	otlpShutdowner
+	spans otelSpanFactory
+}
+
+func (s *otlpTracerShutdowner) spanFactory() SpanFactory {
+	return s.spans
+}
 
-	otel.SetTracerProvider(tp)
-	otel.SetMeterProvider(mp)
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
-		propagation.TraceContext{},
-		propagation.Baggage{},
-	))
-
-	return &compositeShutdowner{
-		shutdowners: []Shutdowner{
-			&otlpShutdowner{provider: tp, name: "TracerProvider"},
-			&otlpShutdowner{provider: mp, name: "MeterProvider"},
-		},
-	}, nil
+// otelSpanLimits overlays the configured limits onto the SDK defaults, which
+// already honor OTEL_SPAN_ATTRIBUTE_COUNT_LIMIT, OTEL_SPAN_EVENT_COUNT_LIMIT,
+// and OTEL_SPAN_LINK_COUNT_LIMIT.
+func otelSpanLimits(limits SpanLimits) sdktrace.SpanLimits {
+	sl := sdktrace.NewSpanLimits()
+	if limits.MaxAttributes > 0 {
+		sl.AttributeCountLimit = limits.MaxAttributes
+	}
+	if limits.MaxEvents > 0 {
+		sl.EventCountLimit = limits.MaxEvents
+	}
+	if limits.MaxLinks > 0 {
+		sl.LinkCountLimit = limits.MaxLinks
+	}
+	return sl
 }
 
 // otlpShutdowner is a wrapper for OpenTelemetry providers to implement the full Shutdowner interface.
@@ -78,17 +177,21 @@ func (s *otlpShutdowner) Shutdown(ctx context.Context) error {
 	return nil
 }
 
+// ForceFlush exports any telemetry the provider is still buffering.
+func (s *otlpShutdowner) ForceFlush(ctx context.Context) error {
+	if p, ok := s.provider.(flusher); ok {
+		if err := p.ForceFlush(ctx); err != nil {
+			return fmt.Errorf("failed to flush %s: %w", s.name, err)
+		}
+	}
+	return nil
+}
+
 // ShutdownOrLog implements the Shutdowner interface.
 func (s *otlpShutdowner) ShutdownOrLog(msg string) {
 	shutdownWithDefaultTimeout(s, msg)
 }
 
 func init() {
-	setupFuncs[OTLP] = setupOTLP
-	setupFuncs[Datadog] = func(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL string, sampleRate float64) (Shutdowner, error) {
-		return nil, fmt.Errorf("Datadog APM is not included in this build. Please use the 'otlp' build tag.")
-	}
-	setupFuncs[None] = func(ctx context.Context, serviceName, serviceApp, serviceEnv, apmURL string, sampleRate float64) (Shutdowner, error) {
-		return &noOpShutdowner{}, nil
-	}
+	RegisterAPMProvider(string(OTLP), setupOTLP, otelSpanFactory{})
 }
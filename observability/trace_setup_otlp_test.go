@@ -0,0 +1,93 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// newTestSDKTracer returns the span factory of a TracerProvider set up by
+// newSDKTracer with cfg, exporting synchronously to memory.
+func newTestSDKTracer(t *testing.T, cfg TracingConfig) otelSpanFactory {
+	cfg.SampleRate = 1
+	s := newSDKTracer(cfg, tracetest.NewInMemoryExporter(), sdktrace.NewSimpleSpanProcessor, nil)
+	t.Cleanup(func() { s.Shutdown(context.Background()) })
+	return s.spans
+}
+
+// fixedIDGenerator returns the same IDs for every span.
+type fixedIDGenerator struct{}
+
+func (fixedIDGenerator) NewIDs(context.Context) (trace.TraceID, trace.SpanID) {
+	return trace.TraceID{0xab, 15: 1}, trace.SpanID{7: 1}
+}
+
+func (fixedIDGenerator) NewSpanID(context.Context, trace.TraceID) trace.SpanID {
+	return trace.SpanID{7: 2}
+}
+
+func TestXRayTraceIDs(t *testing.T) {
+	before := uint32(time.Now().Unix())
+	f := newTestSDKTracer(t, TracingConfig{XRay: true})
+	ctx, span := f.Start(context.Background(), "op")
+	span.End()
+	after := uint32(time.Now().Unix())
+
+	traceID := trace.SpanContextFromContext(ctx).TraceID()
+	if epoch := binary.BigEndian.Uint32(traceID[:4]); epoch < before || epoch > after {
+		t.Errorf("trace ID %s does not start with the current time", traceID)
+	}
+
+	f = newTestSDKTracer(t, TracingConfig{XRay: true, IDGenerator: fixedIDGenerator{}})
+	ctx, span = f.Start(context.Background(), "op")
+	span.End()
+	if got, want := trace.SpanContextFromContext(ctx).TraceID(), (trace.TraceID{0xab, 15: 1}); got != want {
+		t.Errorf("trace ID = %s, want %s from WithIDGenerator", got, want)
+	}
+}
+
+func TestXRayPropagation(t *testing.T) {
+	const (
+		xrayHeader  = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+		traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	)
+	tests := []struct {
+		name        string
+		header      http.Header
+		wantTraceID string
+	}{
+		{
+			name:        "X-Ray header",
+			header:      http.Header{"X-Amzn-Trace-Id": {xrayHeader}},
+			wantTraceID: "5759e988bd862e3fe1be46a994272793",
+		},
+		{
+			name:        "traceparent takes precedence",
+			header:      http.Header{"X-Amzn-Trace-Id": {xrayHeader}, "Traceparent": {traceparent}},
+			wantTraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		},
+	}
+	f := newTestSDKTracer(t, TracingConfig{XRay: true})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, span := f.Start(f.Extract(context.Background(), tt.header), "op")
+			defer span.End()
+			if got := trace.SpanContextFromContext(ctx).TraceID().String(); got != tt.wantTraceID {
+				t.Errorf("trace ID = %s, want %s", got, tt.wantTraceID)
+			}
+			out := http.Header{}
+			f.Inject(ctx, out)
+			if out.Get("X-Amzn-Trace-Id") == "" || out.Get("Traceparent") == "" {
+				t.Errorf("injected %v, want both X-Amzn-Trace-Id and traceparent", out)
+			}
+		})
+	}
+}
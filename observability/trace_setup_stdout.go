@@ -0,0 +1,37 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// setupStdout configures a TracerProvider that writes every span to stdout
+// as soon as it ends, for local development without a collector. Spans are
+// written as indented JSON, or with the "json" format as one JSON object per
+// line.
+func setupStdout(ctx context.Context, cfg TracingConfig) (Shutdowner, error) {
+	var opts []stdouttrace.Option
+	switch strings.ToLower(cfg.StdoutFormat) {
+	case "", "pretty":
+		opts = append(opts, stdouttrace.WithPrettyPrint())
+	case "json":
+	default:
+		return nil, fmt.Errorf("unknown stdout trace format %q; use \"pretty\" or \"json\"", cfg.StdoutFormat)
+	}
+	exporter, err := stdouttrace.New(append(opts, stdouttrace.WithWriter(os.Stdout))...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stdout trace exporter: %w", err)
+	}
+	return newSDKTracer(cfg, exporter, sdktrace.NewSimpleSpanProcessor, nil), nil
+}
+
+func init() {
+	RegisterAPMProvider(string(Stdout), setupStdout, otelSpanFactory{})
+}
@@ -0,0 +1,23 @@
+package observability
+
+import "strings"
+
+// TraceURLKey is the attribute under which error records carry the link to
+// their trace with WithTraceURLTemplate.
+const TraceURLKey = "trace.url"
+
+// TraceURL returns the link to the trace of the active span in the trace
+// viewer set with WithTraceURLTemplate, or "" if no template is set or there
+// is no active span.
+func (o *Observability) TraceURL() string {
+	traceID, spanID := o.providers.spans.TraceIDs(o.ctx)
+	return traceURL(o.providers.traceURLTemplate, traceID, spanID)
+}
+
+// traceURL expands the {traceID} and {spanID} placeholders of template.
+func traceURL(template, traceID, spanID string) string {
+	if template == "" || traceID == "" {
+		return ""
+	}
+	return strings.NewReplacer("{traceID}", traceID, "{spanID}", spanID).Replace(template)
+}
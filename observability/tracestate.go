@@ -0,0 +1,22 @@
+package observability
+
+import "context"
+
+// traceStateReader is implemented by span factories whose provider keeps
+// the W3C tracestate of a trace.
+type traceStateReader interface {
+	traceState(ctx context.Context) string
+}
+
+// TraceState returns the W3C tracestate header value of the active span, or
+// of the incoming request before a span is started, or "" if there is none.
+// It carries the vendor-specific trace data of the services the trace went
+// through, such as their sampling hints, and is preserved across this
+// service: spans started here, and the requests they make, keep it, with the
+// entry set with WithTraceStateEntry added first.
+func (o *Observability) TraceState() string {
+	if r, ok := o.providers.spans.(traceStateReader); ok {
+		return r.traceState(o.ctx)
+	}
+	return ""
+}
@@ -0,0 +1,37 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"strings"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// traceStateSampler adds the service's vendor entry to the tracestate of
+// the spans its Sampler starts, moving it first as W3C trace context asks of
+// vendors that update their entry.
+type traceStateSampler struct {
+	sdktrace.Sampler
+	key, value string
+}
+
+// newTraceStateSampler returns sampler with the tracestate entry key=value
+// added to its results.
+func newTraceStateSampler(sampler sdktrace.Sampler, entry string) traceStateSampler {
+	key, value, _ := strings.Cut(entry, "=")
+	return traceStateSampler{Sampler: sampler, key: key, value: value}
+}
+
+func (s traceStateSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	result := s.Sampler.ShouldSample(p)
+	// An invalid entry, which Verify reports, leaves the tracestate as is.
+	if ts, err := result.Tracestate.Insert(s.key, s.value); err == nil {
+		result.Tracestate = ts
+	}
+	return result
+}
+
+func (s traceStateSampler) Description() string {
+	return "TraceState{" + s.Sampler.Description() + "}"
+}
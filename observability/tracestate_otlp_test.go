@@ -0,0 +1,75 @@
+//go:build otlp || !(datadog || none)
+
+package observability
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceStateSampler(t *testing.T) {
+	tests := []struct {
+		name     string
+		entry    string
+		incoming string
+		want     string
+	}{
+		{name: "new trace", entry: "shop=t61rcWkgMzE", want: "shop=t61rcWkgMzE"},
+		{name: "added first", entry: "shop=1", incoming: "congo=t61rcWkgMzE,rojo=00f067aa0ba902b7", want: "shop=1,congo=t61rcWkgMzE,rojo=00f067aa0ba902b7"},
+		{name: "own entry moved first", entry: "shop=2", incoming: "congo=1,shop=1", want: "shop=2,congo=1"},
+		{name: "multi-tenant key", entry: "tenant@shop=1", incoming: "congo=1", want: "tenant@shop=1,congo=1"},
+		{name: "invalid key", entry: "Shop=1", incoming: "congo=1", want: "congo=1"},
+		{name: "missing value", entry: "shop", incoming: "congo=1", want: "congo=1"},
+		{name: "invalid value", entry: "shop=a=b", incoming: "congo=1", want: "congo=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.incoming != "" {
+				ts, err := trace.ParseTraceState(tt.incoming)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ctx = trace.ContextWithRemoteSpanContext(ctx, trace.NewSpanContext(trace.SpanContextConfig{
+					TraceID:    trace.TraceID{1},
+					SpanID:     trace.SpanID{1},
+					TraceFlags: trace.FlagsSampled,
+					TraceState: ts,
+					Remote:     true,
+				}))
+			}
+			s := newTraceStateSampler(sdktrace.ParentBased(sdktrace.AlwaysSample()), tt.entry)
+			result := s.ShouldSample(sdktrace.SamplingParameters{ParentContext: ctx, TraceID: trace.TraceID{1}, Name: "op"})
+			if got := result.Tracestate.String(); got != tt.want {
+				t.Errorf("tracestate = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTraceStatePropagated(t *testing.T) {
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(newTraceStateSampler(sdktrace.AlwaysSample(), "shop=1")))
+	defer tp.Shutdown(context.Background())
+	f := otelSpanFactory{tracer: tp.Tracer("test"), propagator: propagation.TraceContext{}}
+
+	in := http.Header{}
+	in.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	in.Set("tracestate", "congo=t61rcWkgMzE")
+	ctx := f.Extract(context.Background(), in)
+	if got := f.traceState(ctx); got != "congo=t61rcWkgMzE" {
+		t.Errorf("incoming tracestate = %q", got)
+	}
+
+	ctx, span := f.Start(ctx, "op")
+	defer span.End()
+	out := http.Header{}
+	f.Inject(ctx, out)
+	if got, want := out.Get("tracestate"), "shop=1,congo=t61rcWkgMzE"; got != want {
+		t.Errorf("outgoing tracestate = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,127 @@
+package observability
+
+import (
+	"net/url"
+	"strings"
+)
+
+// redacted replaces scrubbed values in recorded URLs.
+const redacted = "REDACTED"
+
+// scrubbedParams are the query parameters whose values are always redacted:
+// common names for credentials, and the signatures of pre-signed cloud
+// storage URLs.
+var scrubbedParams = []string{
+	"access_token",
+	"api_key",
+	"apikey",
+	"auth",
+	"client_secret",
+	"code",
+	"id_token",
+	"key",
+	"password",
+	"refresh_token",
+	"secret",
+	"session",
+	"sig",
+	"signature",
+	"token",
+	"AWSAccessKeyId",
+	"X-Amz-Credential",
+	"X-Amz-Security-Token",
+	"X-Amz-Signature",
+	"X-Goog-Credential",
+	"X-Goog-Signature",
+}
+
+// URLScrubbing controls how request URLs are recorded on spans as url.query
+// and, with WithLegacyHTTPAttributes, http.url and http.target. Credentials
+// in the URL's userinfo are always replaced with REDACTED, and so are the
+// values of common credential query parameters such as token, api_key, and
+// password.
+type URLScrubbing struct {
+	// Params lists further query parameters whose values are replaced with
+	// REDACTED. Names are matched case-insensitively.
+	Params []string
+	// DropQuery removes the query string entirely, for services whose query
+	// parameters are too sensitive or too varied to list.
+	DropQuery bool
+}
+
+// scrub returns the URL and request target of u as they are recorded on
+// spans.
+func (s URLScrubbing) scrub(u *url.URL) (full, target string) {
+	if u.User == nil && (!s.DropQuery || u.RawQuery == "" && !u.ForceQuery) && !s.sensitiveQuery(u.RawQuery) {
+		return u.String(), u.RequestURI()
+	}
+	scrubbed := *u
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			scrubbed.User = url.UserPassword(redacted, redacted)
+		} else {
+			scrubbed.User = url.User(redacted)
+		}
+	}
+	if s.DropQuery {
+		scrubbed.RawQuery = ""
+		scrubbed.ForceQuery = false
+	} else {
+		scrubbed.RawQuery = s.scrubQuery(u.RawQuery)
+	}
+	return scrubbed.String(), scrubbed.RequestURI()
+}
+
+// sensitiveQuery reports whether rawQuery contains a parameter to redact.
+func (s URLScrubbing) sensitiveQuery(rawQuery string) bool {
+	for rawQuery != "" {
+		var param string
+		param, rawQuery, _ = strings.Cut(rawQuery, "&")
+		if name, _, _ := strings.Cut(param, "="); s.sensitive(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// scrubQuery redacts the values of sensitive parameters in rawQuery, leaving
+// the order and encoding of the others unchanged.
+func (s URLScrubbing) scrubQuery(rawQuery string) string {
+	params := strings.Split(rawQuery, "&")
+	for i, param := range params {
+		if name, _, _ := strings.Cut(param, "="); s.sensitive(name) {
+			params[i] = name + "=" + redacted
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+// sensitive reports whether the values of the query parameter with the
+// escaped name are redacted.
+func (s URLScrubbing) sensitive(name string) bool {
+	if unescaped, err := url.QueryUnescape(name); err == nil {
+		name = unescaped
+	}
+	for _, p := range scrubbedParams {
+		if strings.EqualFold(name, p) {
+			return true
+		}
+	}
+	for _, p := range s.Params {
+		if strings.EqualFold(name, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactedURL returns raw with the credentials in its userinfo and query
+// redacted, for reporting a configured URL.
+func redactedURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	full, _ := URLScrubbing{}.scrub(u)
+	return full
+}
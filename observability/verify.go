@@ -0,0 +1,230 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Verify checks that the configuration is consistent and that the backends
+// it sends telemetry to are reachable, so that a service can fail fast, or
+// warn, at startup instead of silently dropping its telemetry:
+//
+//	if err := f.Verify(ctx); err != nil {
+//		observability.LogFatal("Observability misconfigured", "error", err)
+//	}
+//
+// The OTLP trace and metrics endpoints are probed with an empty export
+// request, as with WithCollectorProbe, and the Datadog Agent with a request
+// to its /info endpoint. DogStatsD, which is sent over UDP, cannot be
+// probed. Verify can be called before or after Setup and returns every
+// problem found, joined.
+func (f *Factory) Verify(ctx context.Context) error {
+	var errs []error
+	if f.config.ServiceName.Source == sourceDefault {
+		errs = append(errs, errors.New("service name is not set; use WithServiceName or OBS_SERVICE_NAME"))
+	}
+	if rate := f.config.SampleRate.Value; rate < 0 || rate > 1 {
+		errs = append(errs, fmt.Errorf("sample rate %v is outside [0, 1]", rate))
+	}
+	if n := f.config.AsyncLogWorkers.Value; n < 1 {
+		errs = append(errs, fmt.Errorf("async log workers %d is less than 1", n))
+	}
+	if n := f.config.AttrValueLimit.Value; n < 0 {
+		errs = append(errs, fmt.Errorf("attribute value limit %d is negative", n))
+	}
+	if filter := f.config.AttributeFilter.Value; filter != nil {
+		if err := filter.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("attribute filter: %w", err))
+		}
+	}
+	if entry := f.config.TraceStateEntry.Value; entry != "" {
+		key, value, _ := strings.Cut(entry, "=")
+		if _, err := (trace.TraceState{}).Insert(key, value); err != nil {
+			errs = append(errs, fmt.Errorf("tracestate entry %q: %w", entry, err))
+		}
+	}
+	if n := f.config.AdaptiveSampling.Value; n < 0 {
+		errs = append(errs, fmt.Errorf("adaptive sampling target %d is negative", n))
+	}
+	if tail := f.config.TailSampling.Value; tail != nil && (tail.BaselineRatio < 0 || tail.BaselineRatio > 1) {
+		errs = append(errs, fmt.Errorf("tail sampling baseline ratio %v is outside [0, 1]", tail.BaselineRatio))
+	}
+	if schema := f.config.LogSchema.Value; !strings.EqualFold(schema, logSchemaDefault) && !strings.EqualFold(schema, logSchemaGCP) {
+		errs = append(errs, fmt.Errorf("unknown log schema %q", schema))
+	}
+	switch strings.ToLower(f.config.LogOutput.Value) {
+	case logOutputStdout, logOutputSyslog, logOutputJournald:
+	default:
+		errs = append(errs, fmt.Errorf("unknown log output %q", f.config.LogOutput.Value))
+	}
+	if u := f.config.ProfilingURL.Value; u != "" {
+		if err := checkHTTPURL(u); err != nil {
+			errs = append(errs, fmt.Errorf("profiling URL: %w", err))
+		}
+	}
+	for _, s := range f.config.LogSinks.Value {
+		if format := s.Format; format != "" && !strings.EqualFold(format, logFormatJSON) && !strings.EqualFold(format, logFormatText) {
+			errs = append(errs, fmt.Errorf("log sink %s: unknown format %q", s.Name, format))
+		}
+	}
+	if u := f.config.LokiURL.Value; u != "" {
+		if err := checkHTTPURL(u); err != nil {
+			errs = append(errs, fmt.Errorf("Loki URL: %w", err))
+		}
+	}
+
+	client := &http.Client{}
+
+	apmType := normalizeAPMType(f.config.ApmType.Value)
+	switch {
+	case apmType == None && !strings.EqualFold(f.config.ApmType.Value, string(None)):
+		errs = append(errs, fmt.Errorf("unknown APM type %q", f.config.ApmType.Value))
+	case apmType == None:
+	case !apmAvailable(apmType):
+		errs = append(errs, fmt.Errorf("APM type %q is not available in this build; check the build tags", apmType))
+	case apmType == OTLP:
+		if err := checkHTTPURL(f.config.ApmURL.Value); err != nil {
+			errs = append(errs, fmt.Errorf("APM URL: %w", err))
+		} else if err := checkOTLPEndpoint(ctx, client, f.config.ApmURL.Value); err != nil {
+			errs = append(errs, fmt.Errorf("traces: %w", err))
+		}
+	case apmType == Jaeger:
+		if err := checkHTTPURL(jaegerTracesURL(f.config.ApmURL.Value)); err != nil {
+			errs = append(errs, fmt.Errorf("APM URL: %w", err))
+		} else if err := checkOTLPEndpoint(ctx, client, jaegerTracesURL(f.config.ApmURL.Value)); err != nil {
+			errs = append(errs, fmt.Errorf("traces: %w", err))
+		}
+	case apmType == File:
+		if f.config.ApmURL.Value == "" {
+			errs = append(errs, errors.New("APM URL: the file APM type needs the path of the span file"))
+		}
+	case apmType == Datadog, apmType == DatadogOTel:
+		if err := checkDatadogAgent(ctx, client, f.config.ApmURL.Value); err != nil {
+			errs = append(errs, fmt.Errorf("traces: %w", err))
+		}
+	}
+
+	metricsType := normalizeMetricsType(f.config.MetricsType.Value)
+	switch {
+	case metricsType == NoneMetrics && !strings.EqualFold(f.config.MetricsType.Value, string(NoneMetrics)):
+		errs = append(errs, fmt.Errorf("unknown metrics type %q", f.config.MetricsType.Value))
+	case metricsType == NoneMetrics:
+	case !metricsAvailable(metricsType):
+		errs = append(errs, fmt.Errorf("metrics type %q is not available in this build; check the build tags", metricsType))
+	case metricsType == OTLPMetrics:
+		switch strings.ToLower(f.config.MetricTemporality.Value) {
+		case "", "cumulative", "delta":
+		default:
+			errs = append(errs, fmt.Errorf("unknown metric temporality %q", f.config.MetricTemporality.Value))
+		}
+		if err := checkHTTPURL(f.metricsURL()); err != nil {
+			errs = append(errs, fmt.Errorf("metrics URL: %w", err))
+		} else if err := checkOTLPEndpoint(ctx, client, f.metricsURL()); err != nil {
+			errs = append(errs, fmt.Errorf("metrics: %w", err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func apmAvailable(apmType APMType) bool {
+	_, ok := lookupAPMProvider(apmType)
+	return ok
+}
+
+func metricsAvailable(metricsType MetricsType) bool {
+	_, ok := lookupMetricsProvider(metricsType)
+	return ok
+}
+
+// checkHTTPURL reports whether rawURL is an absolute http or https URL.
+func checkHTTPURL(rawURL string) error {
+	if rawURL == "" {
+		return errors.New("not set")
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return urlErrorCause(err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%q is not an http or https URL", redactedURL(rawURL))
+	}
+	return nil
+}
+
+// checkOTLPEndpoint reports whether the OTLP/HTTP endpoint at url accepts
+// exports.
+func checkOTLPEndpoint(ctx context.Context, client *http.Client, url string) error {
+	result := probeOTLPEndpoint(ctx, client, url)
+	if result.Status != probeSupported {
+		return fmt.Errorf("endpoint %s is %s: %s", redactedURL(url), result.Status, result.Detail)
+	}
+	return nil
+}
+
+// checkDatadogAgent reports whether the Datadog Agent at addr, or at the
+// address the tracer defaults to if addr is empty, answers on /info. Agents
+// listening on a Unix socket are not checked.
+func checkDatadogAgent(ctx context.Context, client *http.Client, addr string) error {
+	base := datadogAgentURL(addr)
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		return nil
+	}
+	infoURL := base + "/info"
+	ctx, cancel := context.WithTimeout(ctx, collectorProbeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, infoURL, nil)
+	if err != nil {
+		return urlErrorCause(err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("Datadog Agent at %s is unreachable: %w", redactedURL(infoURL), urlErrorCause(err))
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Datadog Agent at %s answered %s", redactedURL(infoURL), resp.Status)
+	}
+	return nil
+}
+
+// urlErrorCause returns the cause of a *url.Error, without the URL it
+// repeats, so that errors about configured URLs leave out their credentials.
+func urlErrorCause(err error) error {
+	var uerr *url.Error
+	if errors.As(err, &uerr) {
+		return uerr.Err
+	}
+	return err
+}
+
+// datadogAgentURL returns the base URL of the Agent's trace intake, resolving
+// an empty address as the tracer does.
+func datadogAgentURL(addr string) string {
+	if strings.Contains(addr, "://") {
+		return strings.TrimSuffix(addr, "/")
+	}
+	if addr == "" {
+		if u := os.Getenv("DD_TRACE_AGENT_URL"); u != "" {
+			return strings.TrimSuffix(u, "/")
+		}
+		host, port := os.Getenv("DD_AGENT_HOST"), os.Getenv("DD_TRACE_AGENT_PORT")
+		if host == "" {
+			host = "localhost"
+		}
+		if port == "" {
+			port = "8126"
+		}
+		addr = net.JoinHostPort(host, port)
+	}
+	return "http://" + addr
+}
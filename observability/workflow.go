@@ -0,0 +1,52 @@
+package observability
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"math/rand/v2"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// WorkflowIDKey is the baggage member and attribute key for the workflow ID.
+const WorkflowIDKey = "workflow.id"
+
+// StartWorkflow begins a new workflow: a long-lived unit of work, such as a
+// multi-step business process, that spans many traces and may run for hours.
+// It generates a workflow ID and returns it with a copy of ctx carrying it.
+//
+// The workflow ID is stored as W3C baggage, so it is propagated to
+// downstream services by Trace.InjectHTTP and restored by
+// Factory.StartSpanFromRequest. It is added as a "workflow.id" attribute to
+// the active span, to spans started from the returned context, and to every
+// log record written through its Observability. It is deliberately not added
+// to metrics, where an unbounded ID would explode cardinality.
+func StartWorkflow(ctx context.Context) (context.Context, string) {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], rand.Uint64())
+	binary.BigEndian.PutUint64(b[8:], rand.Uint64())
+	id := hex.EncodeToString(b[:])
+	return AdoptWorkflow(ctx, id), id
+}
+
+// AdoptWorkflow returns a copy of ctx that joins the existing workflow id,
+// for example one read from a queue message or a database row when a step
+// resumes outside of any incoming request. See StartWorkflow.
+func AdoptWorkflow(ctx context.Context, id string) context.Context {
+	member, err := baggage.NewMemberRaw(WorkflowIDKey, id)
+	if err != nil {
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	return rebindObs(baggage.ContextWithBaggage(ctx, bag), attribute.String(WorkflowIDKey, id))
+}
+
+// WorkflowFrom returns the workflow ID carried by ctx, or "" if there is none.
+func WorkflowFrom(ctx context.Context) string {
+	return baggage.FromContext(ctx).Member(WorkflowIDKey).Value()
+}